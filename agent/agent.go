@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/files"
 	"github.com/eris-ltd/eris-cli/log"
@@ -15,55 +14,74 @@ import (
 	"github.com/eris-ltd/eris-cli/util"
 )
 
-func checkHash(hash string) (string, error) {
-	splitHash := strings.Split(hash, ".")
-	if len(splitHash) >= 3 { // somefile.tar.gz for example
-		lenTAR := len(splitHash) - 2
-		lenGZ := len(splitHash) - 1
-		//probably not ipfs hash & probably tar ball
-		if splitHash[lenTAR] == "tar" && splitHash[lenGZ] == "gz" {
-			log.Debug("hash provided appears to be a tarball")
-			return "tarball", nil
+// Bundle types returned by bundleType.
+const (
+	bundleTarball = "tarball"
+	bundleIPFS    = "ipfs-hash"
+)
+
+// bundleType detects the bundle type: a tar archive in the request body
+// (recognized by its content) or, if the body is empty, an IPFS hash.
+func bundleType(hash string, body []byte) (string, error) {
+	if len(body) != 0 {
+		if util.ArchiveType(body) == "" {
+			return "", fmt.Errorf("request body is not a tar archive")
 		}
+		log.Debug("Request body is a tarball")
+		return bundleTarball, nil
 	}
 
-	if len(hash) == 46 { // ipfs hash
-		log.Debug("hash provided appears to be an IPFS hash")
-		return "ipfs-hash", nil
+	if !util.IsIPFSHash(hash) {
+		return "", fmt.Errorf("%q is not an IPFS hash", hash)
 	}
-	return "", fmt.Errorf("unable to decipher ipfs hash provided")
+	log.Debug("Hash provided is an IPFS hash")
+	return bundleIPFS, nil
 }
 
-func downloadBundleFromIPFS(params map[string]string) error {
+// checkBundleInfo makes sure the bundle install path
+// parameters don't lead outside of config.BundlesPath.
+func checkBundleInfo(params map[string]string) error {
+	return pkgs.ValidateBundle(params["groupId"], params["bundleId"], params["version"])
+}
 
-	installPath := SetTarballPath(params)
+// bundleReference returns the GROUP/BUNDLE@VERSION bundle reference.
+func bundleReference(params map[string]string) string {
+	return params["groupId"] + "/" + params["bundleId"] + "@" + params["version"]
+}
 
-	tarBallPath, err := GetTarballFromIPFS(params["hash"], installPath)
+func downloadBundleFromIPFS(params map[string]string) error {
+	tempDir, err := ioutil.TempDir("", "eris-bundle-")
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(tempDir)
 
-	if err = UnpackTarball(tarBallPath, installPath); err != nil {
+	tarBallPath, err := GetTarballFromIPFS(params["hash"], tempDir)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = pkgs.AddBundle(params["groupId"], params["bundleId"], params["version"], params["hash"], tarBallPath)
+	return err
 }
 
-func downloadBundleFromTarball(body []byte, installPath, fileName string) error {
-	if err := os.MkdirAll(installPath, 0777); err != nil {
+func downloadBundleFromTarball(body []byte, params map[string]string) error {
+	file, err := ioutil.TempFile("", "eris-bundle-")
+	if err != nil {
 		return err
 	}
+	defer os.Remove(file.Name())
 
-	tarBallPath := filepath.Join(installPath, fileName)
-	if err := ioutil.WriteFile(tarBallPath, body, 0777); err != nil {
-		return err
+	_, err = file.Write(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
 	}
-
-	if err := UnpackTarball(tarBallPath, installPath); err != nil {
+	if err != nil {
 		return err
 	}
-	return nil
+
+	_, err = pkgs.AddBundle(params["groupId"], params["bundleId"], params["version"], params["hash"], file.Name())
+	return err
 }
 
 // takes URL request for bundle install &
@@ -101,7 +119,7 @@ func IsChainRunning(chainName string) bool {
 // returns path to downloaded tarball
 func GetTarballFromIPFS(hash, installPath string) (string, error) {
 	if !util.DoesDirExist(installPath) {
-		if err := os.MkdirAll(installPath, 0777); err != nil {
+		if err := os.MkdirAll(installPath, 0755); err != nil {
 			return "", err
 		}
 	}
@@ -116,14 +134,20 @@ func GetTarballFromIPFS(hash, installPath string) (string, error) {
 	return do.Path, nil
 }
 
-// for consistency in InstallAgent()
+// UnpackTarball extracts the bundle tarball into installPath
+// (see util.UnpackTarball).
 func UnpackTarball(tarBallPath, installPath string) error {
 	return util.UnpackTarball(tarBallPath, installPath)
 }
 
-func DeployContractBundle(path, chainName, address string) error {
+// DeployContractBundle runs the package of the bundle installed in the path
+// directory against the chain (from a copy of the directory, see
+// pkgs.RunPackage) and returns the job results (epm.json) of the run.
+// The run is recorded in the chain ledger with the bundle reference.
+func DeployContractBundle(path, bundle, chainName, address string) ([]byte, error) {
 
 	doRun := definitions.NowDo()
+	doRun.Bundle = bundle
 	doRun.Path = path
 	doRun.EPMConfigFile = filepath.Join(path, "epm.yaml")
 	doRun.PackagePath = path
@@ -134,17 +158,11 @@ func DeployContractBundle(path, chainName, address string) error {
 	doRun.ChainPort = "46657" // [csk] note this is too opinionated. down the road we should be reading from the chain definition file to acquire right port
 
 	if err := pkgs.RunPackage(doRun); err != nil {
-		return err
+		return nil, err
 	}
-	log.Warn(doRun.Result)
-
-	return nil
+	return []byte(doRun.Result), nil
 }
 
 func SetTarballPath(bundleInfo map[string]string) string {
-	groupID := strings.Replace(bundleInfo["groupId"], ".", "/", 1)
-	bundleID := bundleInfo["bundleId"]
-	version := bundleInfo["version"]
-
-	return filepath.Join(config.BundlesPath, groupID, bundleID, version)
+	return pkgs.BundlePath(bundleInfo["groupId"], bundleInfo["bundleId"], bundleInfo["version"])
 }
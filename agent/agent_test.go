@@ -14,8 +14,10 @@ import (
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/keys"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/pkgs"
 	"github.com/eris-ltd/eris-cli/services"
 	"github.com/eris-ltd/eris-cli/testutil"
+	"github.com/eris-ltd/eris-cli/util"
 )
 
 var (
@@ -78,6 +80,48 @@ func TestParsePayload(t *testing.T) {
 	}
 }
 
+func TestBundleType(t *testing.T) {
+	gzipped := []byte{0x1f, 0x8b, 0x08, 0x00}
+
+	for _, test := range []struct {
+		hash     string
+		body     []byte
+		expected string
+	}{
+		{hash, nil, bundleIPFS},
+		{"bundle.tar.gz", gzipped, bundleTarball},
+		{hash, gzipped, bundleTarball},
+		{"bundle.tar.gz", nil, ""},
+		{"QmdbzmNH1iDg2H86Uk3USuJ2vaugvwU7HubvCxMC2fUyk0", nil, ""},
+		{hash, []byte("not an archive"), ""},
+	} {
+		whichHash, err := bundleType(test.hash, test.body)
+		if test.expected == "" && err == nil {
+			t.Fatalf("expected %q to fail, got %q", test.hash, whichHash)
+		}
+		if whichHash != test.expected {
+			t.Fatalf("expected %q for %q, got %q (%v)", test.expected, test.hash, whichHash, err)
+		}
+	}
+}
+
+func TestCheckBundleInfo(t *testing.T) {
+	if err := checkBundleInfo(bundleInfo); err != nil {
+		t.Fatalf("expected %v to pass, got %v", bundleInfo, err)
+	}
+
+	for _, bad := range []map[string]string{
+		{"groupId": "io.monax", "bundleId": "..", "version": "1.0.0"},
+		{"groupId": "io.monax", "bundleId": "marmots", "version": "../../etc"},
+		{"groupId": "io/../..", "bundleId": "marmots", "version": "1.0.0"},
+		{"groupId": ".", "bundleId": "marmots", "version": "1.0.0"},
+	} {
+		if err := checkBundleInfo(bad); err == nil {
+			t.Fatalf("expected %v to fail", bad)
+		}
+	}
+}
+
 // the test that matters!
 func TestDeployContract(t *testing.T) {
 	defer testutil.RemoveAllContainers()
@@ -102,7 +146,7 @@ func TestDeployContract(t *testing.T) {
 
 	testMakeABundle(t) // untar's the bundle into installPath for deployment
 
-	if err := DeployContractBundle(installPath, chainName, address); err != nil {
+	if _, err := DeployContractBundle(installPath, "", chainName, address); err != nil {
 		t.Fatalf("error deploying contract bundle: %v\n", err)
 	}
 
@@ -187,6 +231,10 @@ jobs:
 		t.Fatalf("%v", err)
 	}
 
+	if err := util.WriteBundleManifest(idiPath); err != nil {
+		t.Fatalf("%v", err)
+	}
+
 	tarballName := "addMeToIPFSeventually.tar.gz"
 	// os.Exec the tar function on them
 	if err := os.Chdir(config.AppsPath); err != nil {
@@ -201,9 +249,8 @@ jobs:
 	log.Warn(string(stdOut))
 
 	from := filepath.Join(config.AppsPath, tarballName)
-	to := installPath
 
-	if err := UnpackTarball(from, to); err != nil {
+	if _, err := pkgs.AddBundle(bundleInfo["groupId"], bundleInfo["bundleId"], bundleInfo["version"], tarballName, from); err != nil {
 		t.Fatalf("%v", err)
 	}
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"path/filepath"
 	"strings"
 
 	"github.com/eris-ltd/eris-cli/definitions"
@@ -26,6 +25,7 @@ Available endpoints are:
 
 /download (POST)
   => /download?groupId=<abc>&bundleId=<def>&version=<1.0.2>&hash=<ipfs>
+  (the bundle tarball can be sent in the request body instead)
 
 /install (POST)
   => /install?groupId=<abc>&bundleId=<def>&version=<1.0.2>&hash=<ipfs>&chainName=<alice>&address=<addr>"
@@ -113,23 +113,25 @@ func DownloadAgent(w http.ResponseWriter, r *http.Request) *agentError {
 			return &agentError{err, ErrorParsingURL, 400}
 		}
 
-		installPath := SetTarballPath(params)
-		whichHash, err := checkHash(params["hash"])
+		if err := checkBundleInfo(params); err != nil {
+			return &agentError{err, ErrorParsingURL, 400}
+		}
+
+		tarBody, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, util.MaxUnpackSize))
+		if err != nil {
+			return &agentError{err, ErrorReadingTarball, 400}
+		}
+		whichHash, err := bundleType(params["hash"], tarBody)
 		if err != nil {
 			return &agentError{err, ErrorCheckingIPFShash, 400}
 		}
 
-		if whichHash == "tarball" {
-			tarBody, err := ioutil.ReadAll(r.Body)
-			if err != nil {
-				return &agentError{err, ErrorReadingTarball, 400}
-			}
-
-			if err := downloadBundleFromTarball(tarBody, installPath, params["hash"]); err != nil {
+		if whichHash == bundleTarball {
+			if err := downloadBundleFromTarball(tarBody, params); err != nil {
 				return &agentError{err, ErrorDownloadingBundle, 500}
 			}
 
-		} else if whichHash == "ipfs-hash" { // not directly tarball, get from ipfs
+		} else if whichHash == bundleIPFS { // not directly tarball, get from ipfs
 			if err := downloadBundleFromIPFS(params); err != nil {
 				return &agentError{err, ErrorDownloadingBundle, 500}
 			}
@@ -157,23 +159,26 @@ func InstallAgent(w http.ResponseWriter, r *http.Request) *agentError {
 			return &agentError{nil, "chain name provided is not running", 404}
 		}
 
+		if err := checkBundleInfo(params); err != nil {
+			return &agentError{err, ErrorParsingURL, 400}
+		}
 		installPath := SetTarballPath(params)
-		whichHash, err := checkHash(params["hash"])
+
+		tarBody, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, util.MaxUnpackSize))
+		if err != nil {
+			return &agentError{err, ErrorReadingTarball, 400}
+		}
+		whichHash, err := bundleType(params["hash"], tarBody)
 		if err != nil {
 			return &agentError{err, ErrorCheckingIPFShash, 400}
 		}
 
-		if whichHash == "tarball" {
-			tarBody, err := ioutil.ReadAll(r.Body)
-			if err != nil {
-				return &agentError{err, ErrorReadingTarball, 400}
-			}
-
-			if err := downloadBundleFromTarball(tarBody, installPath, params["hash"]); err != nil {
+		if whichHash == bundleTarball {
+			if err := downloadBundleFromTarball(tarBody, params); err != nil {
 				return &agentError{err, ErrorDownloadingBundle, 500}
 			}
 
-		} else if whichHash == "ipfs-hash" {
+		} else if whichHash == bundleIPFS {
 			if err := downloadBundleFromIPFS(params); err != nil {
 				return &agentError{err, ErrorDownloadingBundle, 500}
 			}
@@ -182,15 +187,13 @@ func InstallAgent(w http.ResponseWriter, r *http.Request) *agentError {
 		// chain is running
 		// contract bundle unbundled
 		// time to deploy
-		if err := DeployContractBundle(installPath, params["chainName"], params["address"]); err != nil {
+		epmByte, err := DeployContractBundle(installPath, bundleReference(params), params["chainName"], params["address"])
+		if err != nil {
 			return &agentError{err, ErrorDeployingContractBundle, 403}
 			// TODO reap bad addr error => func AuthenticateUser()
 		}
-
-		epmJSON := filepath.Join(installPath, "epm.json")
-		epmByte, err := ioutil.ReadFile(epmJSON)
-		if err != nil {
-			return &agentError{err, ErrorReadingEPMjson, 500}
+		if len(epmByte) == 0 {
+			return &agentError{fmt.Errorf("There are no job results of the deployment"), ErrorReadingEPMjson, 500}
 		}
 		w.Write(epmByte)
 	}
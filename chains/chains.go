@@ -119,7 +119,8 @@ func LogsChain(do *definitions.Do) error {
 	return nil
 }
 
-// CheckoutChain writes to the ChainPath/HEAD file the name
+// CheckoutChain writes to the ChainPath/HEAD file (or the project's
+// .eris/HEAD file inside a project) the name
 // of the chain to be "checked out". It returns an error. This
 // operates similar to git branches and is predominantly a
 // scoping function which is used by other portions of the
@@ -129,22 +130,22 @@ func LogsChain(do *definitions.Do) error {
 //
 func CheckoutChain(do *definitions.Do) error {
 	if do.Name == "" {
-		return util.NullHead()
+		return util.ChangeProjectHead("")
 	}
 
-	curHead, _ := util.GetHead()
+	curHead, _ := util.GetProjectHead()
 	if do.Name == curHead {
 		return nil
 	}
 
-	return util.ChangeHead(do.Name)
+	return util.ChangeProjectHead(do.Name)
 }
 
 // CurrentChain displays the currently in scope (or checked out) chain. It
 // returns an error (which should never be triggered)
 //
 func CurrentChain(do *definitions.Do) (string, error) {
-	head, _ := util.GetHead()
+	head, _ := util.GetProjectHead()
 
 	if head == "" {
 		head = "There is no chain checked out"
@@ -214,6 +215,63 @@ func PortsChain(do *definitions.Do) error {
 	return nil
 }
 
+// DiffChain compares the chain definition with the existing chain
+// container (see perform.DockerDiff) and displays the differences.
+// The definition is completed the way [eris chains start] does it,
+// so the flags the chain was started with should be given again.
+//
+//  do.Name       - chain name (required)
+//  do.Path       - chain directory the chain was started from
+//                  (the chain's root by default)
+//  do.Env        - environment variables the chain was started with
+//  do.Links      - links the chain was started with
+//  do.Operations - ports the chain was started with
+//  do.Apply      - recreate the container if it differs from the definition
+//  do.Timeout    - seconds to wait for the chain to stop before recreating
+//
+func DiffChain(do *definitions.Do) error {
+	if !util.IsChain(do.Name, false) {
+		return fmt.Errorf("The chain container does not exist. Start it with [eris chains start %s]", do.Name)
+	}
+
+	if do.Path == "" {
+		do.Path = filepath.Join(config.ChainsPath, do.Name)
+	}
+	var err error
+	if do.Path, err = resolveChainsPath(do.Name, do.Path); err != nil {
+		return err
+	}
+	chain, err := chainDefinition(do)
+	if err != nil {
+		return err
+	}
+
+	// Labels set by whoever started the chain (stacks, throwaway
+	// chains) aren't in the definition, but should survive --apply.
+	container, err := util.DockerClient.InspectContainer(chain.Operations.SrvContainerName)
+	if err != nil {
+		return util.DockerError(err)
+	}
+	for _, label := range []string{definitions.LabelStack, definitions.LabelThrowaway} {
+		if value, ok := container.Config.Labels[label]; ok {
+			chain.Operations.Labels[label] = value
+		}
+	}
+
+	diffs, err := perform.DockerDiff(chain.Service, chain.Operations)
+	if err != nil {
+		return err
+	}
+	if err := perform.PrintDifferences(diffs); err != nil {
+		return err
+	}
+
+	if len(diffs) == 0 || !do.Apply {
+		return nil
+	}
+	return perform.DockerRebuild(chain.Service, chain.Operations, false, do.Timeout)
+}
+
 func RemoveChain(do *definitions.Do) error {
 	chain, err := loaders.LoadChainDefinition(do.Name)
 	if err != nil {
@@ -356,25 +414,14 @@ func bootDependencies(chain *definitions.ChainDefinition, do *definitions.Do) er
 	return nil
 }
 
-// setupChain is invoked on [eris chains start CHAIN_NAME] command and
-// creates chain and (if they're missing) keys containers.
-func setupChain(do *definitions.Do) (err error) {
-	// do.Name is mandatory.
-	if do.Name == "" {
-		return fmt.Errorf("Setting up chain without a chain name. Aborting")
-	}
-
-	containerName := util.ChainContainerName(do.Name)
-	containerDst := path.Join(config.ErisContainerRoot, "chains", do.Name)
-	hostSrc := do.Path
-
+// chainDefinition loads the chain definition from the chain directory
+// (do.Path) and completes it with the chain variables, the environment
+// variables, links, and operations given by the user.
+func chainDefinition(do *definitions.Do) (*definitions.ChainDefinition, error) {
 	chain, err := loaders.LoadChainDefinition(do.Name, filepath.Join(do.Path, "config"))
 	if err != nil {
-		do.RmD = true
-		RemoveChain(do)
-		return fmt.Errorf("Failed to load chain config: %v", err)
+		return nil, err
 	}
-	log.WithField("image", chain.Service.Image).Debug("Chain loaded")
 
 	chain.Service.Name = do.Name
 	util.Merge(chain.Operations, do.Operations)
@@ -385,13 +432,34 @@ func setupChain(do *definitions.Do) (err error) {
 		fmt.Sprintf("CHAIN_ID=%s", chain.Name),
 		// [zr] replacement for CHAIN_ID is CHAIN_NAME
 		fmt.Sprintf("CHAIN_NAME=%s", chain.Name),
-		fmt.Sprintf("ERIS_DB_WORKDIR=%s", containerDst),
-		fmt.Sprintf("CONTAINER_NAME=%s", containerName),
+		fmt.Sprintf("ERIS_DB_WORKDIR=%s", path.Join(config.ErisContainerRoot, "chains", do.Name)),
+		fmt.Sprintf("CONTAINER_NAME=%s", util.ChainContainerName(do.Name)),
 	}
 	envVars = append(envVars, do.Env...)
 
 	chain.Service.Environment = append(chain.Service.Environment, envVars...)
 	chain.Service.Links = append(chain.Service.Links, do.Links...)
+	return chain, nil
+}
+
+// setupChain is invoked on [eris chains start CHAIN_NAME] command and
+// creates chain and (if they're missing) keys containers.
+func setupChain(do *definitions.Do) (err error) {
+	// do.Name is mandatory.
+	if do.Name == "" {
+		return fmt.Errorf("Setting up chain without a chain name. Aborting")
+	}
+
+	containerDst := path.Join(config.ErisContainerRoot, "chains", do.Name)
+	hostSrc := do.Path
+
+	chain, err := chainDefinition(do)
+	if err != nil {
+		do.RmD = true
+		RemoveChain(do)
+		return fmt.Errorf("Failed to load chain config: %v", err)
+	}
+	log.WithField("image", chain.Service.Image).Debug("Chain loaded")
 	log.WithFields(log.Fields{
 		"environment": chain.Service.Environment,
 		"links":       chain.Service.Links,
@@ -408,6 +476,13 @@ func setupChain(do *definitions.Do) (err error) {
 		log.WithField("=>", do.Name).Debug("Chain data container already exists")
 	} else {
 		ops := loaders.LoadDataDefinition(do.Name)
+		// Data containers carry the same custom labels (e.g. the stack
+		// or throwaway chain labels) as the chain container.
+		for k, v := range do.Operations.Labels {
+			if _, ok := ops.Labels[k]; !ok {
+				ops.Labels[k] = v
+			}
+		}
 		if err := perform.DockerCreateData(ops); err != nil {
 			return fmt.Errorf("Could not create data container: %v", err)
 		}
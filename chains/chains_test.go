@@ -283,6 +283,82 @@ func TestRmChain(t *testing.T) {
 	}
 }
 
+func TestDiffChain(t *testing.T) {
+	defer testutil.RemoveAllContainers()
+
+	const chain = "test-diff-chain"
+
+	create(t, chain)
+	defer kill(t, chain)
+
+	diff := func(env []string, apply bool) string {
+		buf := new(bytes.Buffer)
+		config.Global.Writer = buf
+
+		do := definitions.NowDo()
+		do.Name = chain
+		do.Env = env
+		do.Apply = apply
+		do.Operations.PublishAllPorts = true
+		if err := DiffChain(do); err != nil {
+			t.Fatalf("expected diff to succeed, got %v", err)
+		}
+		return buf.String()
+	}
+
+	// Variables set by [eris chains start] are part of the definition.
+	if out := diff(nil, false); !strings.Contains(out, "matches the definition") {
+		t.Fatalf("expected no differences, got %v", out)
+	}
+
+	if out := diff([]string{"DIFF_TEST=1"}, true); !strings.Contains(out, "env DIFF_TEST") {
+		t.Fatalf("expected env DIFF_TEST difference, got %v", out)
+	}
+	if out := diff([]string{"DIFF_TEST=1"}, false); !strings.Contains(out, "matches the definition") {
+		t.Fatalf("expected no differences after --apply, got %v", out)
+	}
+
+	container, err := util.DockerClient.InspectContainer(util.ChainContainerName(chain))
+	if err != nil {
+		t.Fatalf("expected the chain container, got %v", err)
+	}
+	for _, variable := range []string{"CHAIN_NAME=" + chain, "CONTAINER_NAME=" + util.ChainContainerName(chain), "DIFF_TEST=1"} {
+		if !util.Contains(container.Config.Env, variable) {
+			t.Fatalf("expected %v in the recreated container, got %v", variable, container.Config.Env)
+		}
+	}
+}
+
+func TestThrowawayChain(t *testing.T) {
+	defer testutil.RemoveAllContainers()
+
+	var chain string
+	// The work runs in a goroutine, hence t.Errorf.
+	err := ThrowawayChain(definitions.NowDo(), func(name, address string) error {
+		chain = name
+		if !strings.HasPrefix(name, definitions.ThrowawayPrefix) {
+			t.Errorf("expected a generated chain name, got %v", name)
+		}
+		if address == "" {
+			t.Errorf("expected the validator address")
+		}
+		if !util.Running(definitions.TypeChain, name) {
+			t.Errorf("expected the throwaway chain running")
+		}
+		return fmt.Errorf("marmots are tired")
+	})
+	if err == nil || err.Error() != "marmots are tired" {
+		t.Fatalf("expected the work error returned, got %v", err)
+	}
+
+	if util.Exists(definitions.TypeChain, chain) || util.Exists(definitions.TypeData, chain) {
+		t.Fatalf("expected throwaway chain containers removed after a failure")
+	}
+	if util.DoesDirExist(filepath.Join(config.ChainsPath, chain)) {
+		t.Fatalf("expected throwaway chain directory removed")
+	}
+}
+
 func TestServiceLinkNoChain(t *testing.T) {
 	defer testutil.RemoveAllContainers()
 
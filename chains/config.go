@@ -0,0 +1,448 @@
+package chains
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/data"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configSetting is a config.toml setting managed
+// by [eris chains config].
+type configSetting struct {
+	Section string // e.g. tendermint.configuration
+	Key     string
+	Default string
+	Bool    bool // written unquoted
+
+	// check returns the value to write or an error.
+	check func(value string) (string, error)
+
+	// Settings changed along with this one to the same
+	// HOST:PORT address (without the tcp:// prefix).
+	Linked []*configSetting
+}
+
+var configSettings = map[string]*configSetting{
+	"seeds": {
+		Section: "tendermint.configuration",
+		Key:     "seeds",
+		check: func(value string) (string, error) {
+			if value == "" {
+				return "", nil
+			}
+			seeds, err := parseSeeds([]string{value})
+			return strings.Join(seeds, ","), err
+		},
+	},
+	"moniker": {
+		Section: "tendermint.configuration",
+		Key:     "moniker",
+		check: func(value string) (string, error) {
+			if !monikerRe.MatchString(value) {
+				return "", fmt.Errorf("Bad moniker %q. Use letters, digits, and the _.- characters", value)
+			}
+			return value, nil
+		},
+	},
+	"rpc_laddr": {
+		Section: "tendermint.configuration",
+		Key:     "rpc_laddr",
+		Default: "0.0.0.0:46657",
+		check:   listenAddressCheck("46657"),
+
+		// Eris DB servers and ErisMint connect to the Tendermint RPC.
+		Linked: []*configSetting{
+			{Section: "servers.tendermint", Key: "rpc_local_address"},
+			{Section: "erismint", Key: "tendermint_host"},
+		},
+	},
+	"node_laddr": {
+		Section: "tendermint.configuration",
+		Key:     "node_laddr",
+		Default: "0.0.0.0:46656",
+		check:   listenAddressCheck("46656"),
+	},
+	"fast_sync": {
+		Section: "tendermint.configuration",
+		Key:     "fast_sync",
+		Default: "false",
+		Bool:    true,
+		check: func(value string) (string, error) {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", fmt.Errorf("Bad fast_sync value %q. Use true or false", value)
+			}
+			return strconv.FormatBool(b), nil
+		},
+	},
+	"log_level": {
+		Section: "tendermint.configuration",
+		Key:     "log_level",
+		Default: "info",
+		check: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			for _, level := range logLevels {
+				if value == level {
+					return value, nil
+				}
+			}
+			return "", fmt.Errorf("Bad log level %q. Use one of %s", value, strings.Join(logLevels, ", "))
+		},
+	},
+}
+
+var (
+	monikerRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	logLevels = []string{"debug", "info", "notice", "warn", "error", "crit"}
+)
+
+// ConfigKeys returns the names of the settings
+// [eris chains config] manages.
+func ConfigKeys() []string {
+	keys := []string{}
+	for key := range configSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetChainConfig writes the value of the chain config.toml setting, or
+// of every managed setting if none is given, to config.Global.Writer.
+// The config of the chain's data container is read if there's one.
+//
+//  do.Name            - chain name (required)
+//  do.Operations.Args - setting name (optional)
+//
+func GetChainConfig(do *definitions.Do) error {
+	keys := do.Operations.Args
+	if len(keys) == 0 {
+		keys = ConfigKeys()
+	}
+
+	content, source, err := readChainConfig(do.Name)
+	if err != nil {
+		return err
+	}
+	log.WithField("=>", source).Debug("Reading chain config")
+
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	for _, key := range keys {
+		setting, err := lookupSetting(key)
+		if err != nil {
+			return err
+		}
+		value, err := configValue(content, setting)
+		if err != nil {
+			return err
+		}
+		if len(do.Operations.Args) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\n", setting.Key, value)
+		} else {
+			fmt.Fprintln(tw, value)
+		}
+	}
+	return tw.Flush()
+}
+
+// SetChainConfig changes the chain config.toml setting in the chain
+// directory on the host and in the chain's data container (the ones
+// that exist) and optionally restarts the running chain.
+//
+//  do.Name            - chain name (required)
+//  do.Operations.Args - setting name and value (required)
+//  do.Restart         - restart the chain if it runs (optional)
+//
+func SetChainConfig(do *definitions.Do) error {
+	if len(do.Operations.Args) != 2 {
+		return fmt.Errorf("A setting name and a value are required")
+	}
+	setting, err := lookupSetting(do.Operations.Args[0])
+	if err != nil {
+		return err
+	}
+	value, err := setting.check(do.Operations.Args[1])
+	if err != nil {
+		return err
+	}
+	return updateChainConfig(do, setting, value)
+}
+
+// UnsetChainConfig resets the chain config.toml setting to its default
+// value (see SetChainConfig). The moniker defaults to the chain name.
+//
+//  do.Name            - chain name (required)
+//  do.Operations.Args - setting name (required)
+//  do.Restart         - restart the chain if it runs (optional)
+//
+func UnsetChainConfig(do *definitions.Do) error {
+	if len(do.Operations.Args) != 1 {
+		return fmt.Errorf("A setting name is required")
+	}
+	setting, err := lookupSetting(do.Operations.Args[0])
+	if err != nil {
+		return err
+	}
+	value := setting.Default
+	if setting.Key == "moniker" {
+		value = do.Name
+	}
+	return updateChainConfig(do, setting, value)
+}
+
+func updateChainConfig(do *definitions.Do, setting *configSetting, value string) error {
+	hostFile := filepath.Join(config.ChainsPath, do.Name, "config.toml")
+	inHost, inContainer := util.DoesFileExist(hostFile), util.IsData(do.Name)
+	if !inHost && !inContainer {
+		return fmt.Errorf("Chain %s has neither %s nor a data container", do.Name, util.Tilde(hostFile))
+	}
+
+	// Both copies are changed in memory first and the host file is
+	// written last, so that they don't differ if a step fails.
+	var hostContent, containerContent []byte
+	if inHost {
+		content, err := ioutil.ReadFile(hostFile)
+		if err != nil {
+			return err
+		}
+		if hostContent, err = setConfigValues(content, setting, value); err != nil {
+			return fmt.Errorf("Cannot change %s: %v", util.Tilde(hostFile), err)
+		}
+	}
+	if inContainer {
+		content, err := catChainFile(do.Name, "config.toml")
+		if err != nil {
+			return fmt.Errorf("Cannot read config.toml of chain %s: %v", do.Name, err)
+		}
+		if containerContent, err = setConfigValues(content, setting, value); err != nil {
+			return fmt.Errorf("Cannot change config.toml of chain %s: %v", do.Name, err)
+		}
+	}
+
+	if inContainer {
+		if err := importChainConfig(do.Name, containerContent); err != nil {
+			return err
+		}
+	}
+	if inHost {
+		if err := ioutil.WriteFile(hostFile, hostContent, 0644); err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"=>":           do.Name,
+		setting.Key:    value,
+		"host":         inHost,
+		"in container": inContainer,
+	}).Warn("Chain config changed")
+
+	if !util.IsChain(do.Name, true) {
+		return nil
+	}
+	if !do.Restart {
+		log.WithField("=>", do.Name).Warn("Restart the chain for the change to take effect: [eris chains restart " + do.Name + "]")
+		return nil
+	}
+
+	doRestart := definitions.NowDo()
+	doRestart.Name = do.Name
+	doRestart.Timeout = 10
+	if err := StopChain(doRestart); err != nil {
+		return err
+	}
+	return StartChain(doRestart)
+}
+
+// readChainConfig returns the config.toml file of the chain from its
+// data container or, if there's none, from the chain directory.
+func readChainConfig(chain string) ([]byte, string, error) {
+	if util.IsData(chain) {
+		content, err := catChainFile(chain, "config.toml")
+		if err != nil {
+			return nil, "", fmt.Errorf("Cannot read config.toml of chain %s: %v", chain, err)
+		}
+		return content, chain + " (container)", nil
+	}
+
+	file := filepath.Join(config.ChainsPath, chain, "config.toml")
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("Chain %s has neither %s nor a data container", chain, util.Tilde(file))
+	}
+	return content, file, nil
+}
+
+// importChainConfig copies the config.toml content
+// into the chain's data container.
+func importChainConfig(chain string, content []byte) error {
+	tmp, err := ioutil.TempDir("", "eris-config-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, "config.toml")
+	if err := ioutil.WriteFile(file, content, 0644); err != nil {
+		return err
+	}
+
+	doData := definitions.NowDo()
+	doData.Name = chain
+	doData.Source = file
+	doData.Destination = path.Join(config.ErisContainerRoot, "chains", chain)
+	if err := data.ImportData(doData); err != nil {
+		return fmt.Errorf("Cannot copy config.toml into the data container: %v", err)
+	}
+	return nil
+}
+
+func lookupSetting(key string) (*configSetting, error) {
+	setting, ok := configSettings[strings.Replace(strings.ToLower(key), "-", "_", -1)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown setting %q. Use one of %s", key, strings.Join(ConfigKeys(), ", "))
+	}
+	return setting, nil
+}
+
+// listenAddressCheck returns a check of HOST:PORT listen addresses
+// allowing only the host to change: the chain container publishes
+// the fixed port.
+func listenAddressCheck(fixed string) func(string) (string, error) {
+	return func(value string) (string, error) {
+		address := strings.TrimPrefix(value, "tcp://")
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return "", fmt.Errorf("Bad address %q. Use HOST:PORT (e.g. 0.0.0.0:%s)", value, fixed)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("Bad address %q: port %q is not valid", value, port)
+		}
+		if port != fixed {
+			return "", fmt.Errorf("Bad address %q: only the host can change, the port %s is published by the chain container", value, fixed)
+		}
+		if host != "" && net.ParseIP(host) == nil && !monikerRe.MatchString(host) {
+			return "", fmt.Errorf("Bad address %q: host %q is not valid", value, host)
+		}
+		return value, nil
+	}
+}
+
+// configValue returns the setting value of the config.toml content
+// (or the default value if the setting is not there).
+func configValue(content []byte, setting *configSetting) (string, error) {
+	var tree map[string]interface{}
+	if _, err := toml.Decode(string(content), &tree); err != nil {
+		return "", fmt.Errorf("Cannot read config.toml: %v", err)
+	}
+
+	for _, section := range strings.Split(setting.Section, ".") {
+		child, ok := tree[section].(map[string]interface{})
+		if !ok {
+			return setting.Default, nil
+		}
+		tree = child
+	}
+	value, ok := tree[setting.Key]
+	if !ok {
+		return setting.Default, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// setConfigValues returns the config.toml content with the setting
+// and the settings linked to it changed.
+func setConfigValues(content []byte, setting *configSetting, value string) ([]byte, error) {
+	content, err := setConfigValue(content, setting, value)
+	if err != nil {
+		return nil, err
+	}
+	for _, linked := range setting.Linked {
+		if content, err = setConfigValue(content, linked, strings.TrimPrefix(value, "tcp://")); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// setConfigValue returns the config.toml content with the setting
+// changed. Other lines, comments included, are kept as they are.
+func setConfigValue(content []byte, setting *configSetting, value string) ([]byte, error) {
+	line := setting.Key + " = " + strconv.Quote(value)
+	if setting.Bool {
+		line = setting.Key + " = " + value
+	}
+
+	lines := strings.Split(string(content), "\n")
+	header, end := -1, len(lines)
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if header < 0 {
+			if trimmed == "["+setting.Section+"]" {
+				header = i
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			end = i
+			break
+		}
+	}
+
+	if header < 0 {
+		// No section: add it at the end.
+		lines = append(lines, "["+setting.Section+"]", line)
+		return checkConfig(strings.Join(lines, "\n"), setting, value)
+	}
+
+	indent := leadingSpace(lines[header])
+	last := header
+	for i := header + 1; i < end; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		last, indent = i, leadingSpace(lines[i])
+		if key := strings.TrimSpace(strings.SplitN(trimmed, "=", 2)[0]); key == setting.Key {
+			lines[i] = indent + line
+			return checkConfig(strings.Join(lines, "\n"), setting, value)
+		}
+	}
+
+	// No key in the section: add it after the last one.
+	lines = append(lines[:last+1], append([]string{indent + line}, lines[last+1:]...)...)
+	return checkConfig(strings.Join(lines, "\n"), setting, value)
+}
+
+// checkConfig makes sure the changed config.toml
+// content is valid and has the value set.
+func checkConfig(content string, setting *configSetting, value string) ([]byte, error) {
+	got, err := configValue([]byte(content), setting)
+	if err != nil {
+		return nil, err
+	}
+	if got != value {
+		return nil, fmt.Errorf("%s is %q after the change, not %q", setting.Key, got, value)
+	}
+	return []byte(content), nil
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
@@ -0,0 +1,101 @@
+package chains
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetConfigValue(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := configTemplate.Execute(buf, nodeConfig{Chain: "marmot", Moniker: "marmot_full_000"}); err != nil {
+		t.Fatalf("expected config, got %v", err)
+	}
+	content := buf.Bytes()
+
+	for _, test := range []struct {
+		key, value, expected string
+	}{
+		{"seeds", "10.0.0.5:46656, 10.0.0.6:46656", `seeds = "10.0.0.5:46656,10.0.0.6:46656"`},
+		{"moniker", "beaver", `moniker = "beaver"`},
+		{"rpc-laddr", "tcp://127.0.0.1:46657", `rpc_laddr = "tcp://127.0.0.1:46657"`},
+		{"fast_sync", "1", `fast_sync = true`},
+		{"log_level", "DEBUG", `log_level = "debug"`},
+	} {
+		setting, err := lookupSetting(test.key)
+		if err != nil {
+			t.Fatalf("expected setting %s, got %v", test.key, err)
+		}
+		value, err := setting.check(test.value)
+		if err != nil {
+			t.Fatalf("expected %s value %q accepted, got %v", test.key, test.value, err)
+		}
+		if content, err = setConfigValues(content, setting, value); err != nil {
+			t.Fatalf("expected %s changed, got %v", test.key, err)
+		}
+		if !strings.Contains(string(content), "  "+test.expected+"\n") {
+			t.Fatalf("expected %q in config, got %s", test.expected, content)
+		}
+	}
+
+	// Addresses connecting to the Tendermint RPC follow rpc_laddr.
+	for _, expected := range []string{`rpc_local_address = "127.0.0.1:46657"`, `tendermint_host = "127.0.0.1:46657"`} {
+		if !strings.Contains(string(content), expected+"\n") {
+			t.Fatalf("expected %q in config, got %s", expected, content)
+		}
+	}
+
+	// Everything else stays in place.
+	if !strings.HasPrefix(string(content), "# This is a TOML config file.") || !strings.Contains(string(content), `assert_chain_id = "marmot"`) {
+		t.Fatalf("expected the rest of the config kept, got %s", content)
+	}
+}
+
+func TestSetConfigValueMissing(t *testing.T) {
+	setting, _ := lookupSetting("log_level")
+
+	content, err := setConfigValue([]byte("[chain]\nassert_chain_id = \"marmot\"\n"), setting, "warn")
+	if err != nil {
+		t.Fatalf("expected section added, got %v", err)
+	}
+	if value, _ := configValue(content, setting); value != "warn" {
+		t.Fatalf("expected warn, got %q in %s", value, content)
+	}
+
+	content, err = setConfigValue([]byte("[tendermint.configuration]\n  moniker = \"marmot\"\n\n[erismint]\n"), setting, "error")
+	if err != nil {
+		t.Fatalf("expected key added, got %v", err)
+	}
+	if !strings.Contains(string(content), "  moniker = \"marmot\"\n  log_level = \"error\"\n") {
+		t.Fatalf("expected key added after the last one, got %s", content)
+	}
+
+	if value, _ := configValue([]byte("[chain]\n"), setting); value != "info" {
+		t.Fatalf("expected default value, got %q", value)
+	}
+}
+
+func TestConfigValueChecks(t *testing.T) {
+	for key, values := range map[string][]string{
+		"seeds":      {"10.0.0.5", "10.0.0.5:0"},
+		"moniker":    {"", "marmot node"},
+		"rpc_laddr":  {"46657", "0.0.0.0:port", "bad host:46657", "0.0.0.0:46700"},
+		"fast_sync":  {"maybe"},
+		"log_level":  {"loud"},
+		"node_laddr": {"0.0.0.0:99999", "0.0.0.0:46700"},
+	} {
+		setting, err := lookupSetting(key)
+		if err != nil {
+			t.Fatalf("expected setting %s, got %v", key, err)
+		}
+		for _, value := range values {
+			if _, err := setting.check(value); err == nil {
+				t.Fatalf("expected %s value %q to fail", key, value)
+			}
+		}
+	}
+
+	if _, err := lookupSetting("db_backend"); err == nil {
+		t.Fatalf("expected unknown setting to fail")
+	}
+}
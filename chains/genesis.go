@@ -0,0 +1,625 @@
+package chains
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Genesis is the genesis.json file of a chain.
+type Genesis struct {
+	GenesisTime string              `json:"genesis_time,omitempty"`
+	ChainID     string              `json:"chain_id"`
+	Params      *GenesisParams      `json:"params,omitempty"`
+	Accounts    []*GenesisAccount   `json:"accounts"`
+	Validators  []*GenesisValidator `json:"validators"`
+}
+
+// GenesisParams are chain-wide parameters of the genesis.
+type GenesisParams struct {
+	// Permissions of accounts which don't set them.
+	GlobalPermissions *AccountPermissions `json:"global_permissions"`
+}
+
+// GlobalPermissions returns the global permissions (params/global_permissions)
+// or no permissions set if the genesis has none.
+func (g *Genesis) GlobalPermissions() BasePermissions {
+	if g.Params == nil || g.Params.GlobalPermissions == nil {
+		return BasePermissions{}
+	}
+	return g.Params.GlobalPermissions.Base
+}
+
+// GenesisAccount is an account with tokens at the start of the chain.
+type GenesisAccount struct {
+	Address     string              `json:"address"`
+	Amount      int64               `json:"amount"`
+	Name        string              `json:"name"`
+	Permissions *AccountPermissions `json:"permissions"`
+}
+
+// AccountPermissions are permissions of a genesis account.
+type AccountPermissions struct {
+	Base  BasePermissions `json:"base"`
+	Roles []string        `json:"roles"`
+}
+
+// BasePermissions are permission bits (see Permissions). Perms holds
+// granted permissions, SetBit holds permissions set for the account
+// (unset ones fall back to the global permissions).
+type BasePermissions struct {
+	Perms  uint64 `json:"perms"`
+	SetBit uint64 `json:"set"`
+}
+
+// Fallback returns the permissions with those not set
+// taken from the global permissions.
+func (p BasePermissions) Fallback(global BasePermissions) BasePermissions {
+	return BasePermissions{
+		Perms:  p.Perms&p.SetBit | global.Perms&global.SetBit&^p.SetBit,
+		SetBit: p.SetBit | global.SetBit,
+	}
+}
+
+// GenesisValidator is a validator at the start of the chain.
+type GenesisValidator struct {
+	PubKey   Key         `json:"pub_key"`
+	Amount   int64       `json:"amount"`
+	Name     string      `json:"name"`
+	UnbondTo []*UnbondTo `json:"unbond_to"`
+}
+
+// UnbondTo is an account receiving the validator bond after unbonding.
+type UnbondTo struct {
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+}
+
+// PrivValidator is the priv_validator.json file of a validating node.
+type PrivValidator struct {
+	Address    string `json:"address"`
+	PubKey     Key    `json:"pub_key"`
+	PrivKey    Key    `json:"priv_key"`
+	LastHeight int    `json:"last_height"`
+	LastRound  int    `json:"last_round"`
+	LastStep   int    `json:"last_step"`
+}
+
+// keyTypeEd25519 is the Tendermint type byte of Ed25519 keys.
+const keyTypeEd25519 = 1
+
+// Key is a hex encoded Ed25519 key. In JSON files it is written
+// the Tendermint way, as a [type byte, hex key] pair.
+type Key string
+
+func (k Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{keyTypeEd25519, string(k)})
+}
+
+func (k *Key) UnmarshalJSON(data []byte) error {
+	var pair []interface{}
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("Bad key %s: expected a [type, key] pair", data)
+	}
+	if t, ok := pair[0].(float64); !ok || t != keyTypeEd25519 {
+		return fmt.Errorf("Bad key %s: only Ed25519 (type %d) keys are supported", data, keyTypeEd25519)
+	}
+	key, ok := pair[1].(string)
+	if !ok {
+		return fmt.Errorf("Bad key %s: expected a hex string", data)
+	}
+	*k = Key(strings.ToUpper(key))
+	return nil
+}
+
+// PubKeyAddress returns the account address of the hex encoded
+// Ed25519 public key: the RIPEMD-160 digest of the key prefixed
+// with the type byte and the key length (the way Tendermint
+// serializes keys).
+func PubKeyAddress(pubKey string) (string, error) {
+	key, err := hex.DecodeString(pubKey)
+	if err != nil || len(key) != 32 {
+		return "", fmt.Errorf("Bad Ed25519 public key %q", pubKey)
+	}
+
+	encoded := append([]byte{keyTypeEd25519, 0x01, byte(len(key))}, key...)
+	return strings.ToUpper(hex.EncodeToString(util.Ripemd160(encoded))), nil
+}
+
+// ValidateGenesis checks the genesis.json file of the chain and writes
+// the problems found to config.Global.Writer: unknown fields, bad or
+// duplicate addresses and keys, negative balances, validators without
+// voting power, and the chain ID not matching the assert_chain_id of
+// the config.toml file next to genesis.json.
+//
+//  do.Name - chain name, genesis.json file, or a directory with it (required)
+//
+func ValidateGenesis(do *definitions.Do) error {
+	genesis, err := LoadGenesis(do.Name)
+	if err != nil {
+		return err
+	}
+
+	problems := genesis.Validate()
+	for _, problem := range problems {
+		fmt.Fprintf(config.Global.Writer, "%s: %s\n", genesis.Source, problem)
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("Found %d problem(s) in %s", len(problems), genesis.Source)
+	}
+
+	fmt.Fprintf(config.Global.Writer, "%s: chain %s with %d account(s) and %d validator(s), voting power %d\n",
+		genesis.Source, genesis.ChainID, len(genesis.Accounts), len(genesis.Validators), genesis.VotingPower())
+	return nil
+}
+
+// DiffGenesis writes the differences between two genesis.json
+// files to config.Global.Writer: lines starting with - for accounts and
+// validators only in the first file, + for those only in the second
+// one, and ~ for those changed. It returns an error if the files differ.
+//
+//  do.Operations.Args - two chain names, genesis.json files, or directories (required)
+//
+func DiffGenesis(do *definitions.Do) error {
+	if len(do.Operations.Args) != 2 {
+		return fmt.Errorf("Two genesis files to compare are required")
+	}
+
+	a, err := LoadGenesis(do.Operations.Args[0])
+	if err != nil {
+		return err
+	}
+	b, err := LoadGenesis(do.Operations.Args[1])
+	if err != nil {
+		return err
+	}
+
+	diffs := diffGenesis(a, b)
+	if len(diffs) == 0 {
+		log.WithFields(log.Fields{
+			"a": a.Source,
+			"b": b.Source,
+		}).Warn("Genesis files are the same")
+		return nil
+	}
+
+	fmt.Fprintf(config.Global.Writer, "--- %s\n+++ %s\n", a.Source, b.Source)
+	for _, diff := range diffs {
+		fmt.Fprintln(config.Global.Writer, diff)
+	}
+	return fmt.Errorf("Genesis files differ in %d place(s)", len(diffs))
+}
+
+// GenesisAccounts writes a table of the genesis accounts with their
+// balances, bonded tokens, and permissions to config.Global.Writer.
+// Permissions not set for an account are taken from the global
+// permissions (params/global_permissions), if there are any.
+//
+//  do.Name - chain name, genesis.json file, or a directory with it (required)
+//
+func GenesisAccounts(do *definitions.Do) error {
+	genesis, err := LoadGenesis(do.Name)
+	if err != nil {
+		return err
+	}
+
+	bonds := make(map[string]int64)
+	for _, v := range genesis.Validators {
+		if address, err := PubKeyAddress(string(v.PubKey)); err == nil {
+			bonds[address] += v.Amount
+		}
+	}
+
+	global := genesis.GlobalPermissions()
+
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tNAME\tAMOUNT\tBOND\tPERMISSIONS\tROLES")
+	for _, a := range genesis.Accounts {
+		bond, roles := "-", "-"
+		if amount, ok := bonds[strings.ToUpper(a.Address)]; ok {
+			bond = strconv.FormatInt(amount, 10)
+		}
+		var base BasePermissions
+		if a.Permissions != nil {
+			base = a.Permissions.Base
+			if len(a.Permissions.Roles) != 0 {
+				roles = strings.Join(a.Permissions.Roles, ",")
+			}
+		}
+		perms := permissionNames(base.Fallback(global))
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", a.Address, a.Name, a.Amount, bond, perms, roles)
+	}
+	return tw.Flush()
+}
+
+// LoadedGenesis is a genesis.json file read by LoadGenesis.
+type LoadedGenesis struct {
+	*Genesis
+
+	// File or container the genesis was read from.
+	Source string
+
+	// The assert_chain_id value of the config.toml file next
+	// to genesis.json, if there is one.
+	AssertChainID string
+
+	// Fields not part of the genesis.json schema.
+	Unknown []string
+}
+
+// LoadGenesis reads the genesis.json file given by its path, the
+// directory it is in, or the chain name. Files of the chain are read
+// from the config.ChainsPath/NAME directory or, if there's none, from
+// the chain's data container.
+func LoadGenesis(arg string) (*LoadedGenesis, error) {
+	var genesisJSON, configTOML []byte
+
+	file := arg
+	if util.DoesDirExist(file) {
+		file = filepath.Join(file, "genesis.json")
+	} else if !util.DoesFileExist(file) {
+		file = filepath.Join(config.ChainsPath, arg, "genesis.json")
+	}
+
+	loaded := &LoadedGenesis{Source: file}
+	if util.DoesFileExist(file) {
+		var err error
+		if genesisJSON, err = ioutil.ReadFile(file); err != nil {
+			return nil, err
+		}
+		configTOML, _ = ioutil.ReadFile(filepath.Join(filepath.Dir(file), "config.toml"))
+	} else if util.IsChain(arg, false) {
+		var err error
+		if genesisJSON, err = catChainFile(arg, "genesis.json"); err != nil {
+			return nil, fmt.Errorf("Cannot read genesis.json of chain %s: %v", arg, err)
+		}
+		configTOML, _ = catChainFile(arg, "config.toml")
+		loaded.Source = arg + " (container)"
+	} else {
+		return nil, fmt.Errorf("There is no %s file and no chain %s", arg, arg)
+	}
+
+	genesis, unknown, err := parseGenesis(genesisJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read %s: %v", loaded.Source, err)
+	}
+	loaded.Genesis, loaded.Unknown = genesis, unknown
+
+	if len(configTOML) != 0 {
+		var cfg struct {
+			Chain definitions.Chain `toml:"chain"`
+		}
+		if _, err := toml.Decode(string(configTOML), &cfg); err != nil {
+			return nil, fmt.Errorf("Cannot read config.toml next to %s: %v", loaded.Source, err)
+		}
+		loaded.AssertChainID = cfg.Chain.ChainID
+	}
+	return loaded, nil
+}
+
+// parseGenesis decodes the genesis.json content
+// and returns the names of unknown fields.
+func parseGenesis(content []byte) (*Genesis, []string, error) {
+	genesis := new(Genesis)
+	if err := json.Unmarshal(content, genesis); err != nil {
+		return nil, nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(content, &v); err != nil {
+		return nil, nil, err
+	}
+	return genesis, unknownFields(v, reflect.TypeOf(genesis), ""), nil
+}
+
+// unknownFields compares object keys of the decoded JSON value with
+// the JSON field names of the type t and returns the keys not matching
+// any field (as dotted paths). Keys match field names case insensitively,
+// like they do for json.Unmarshal.
+func unknownFields(v interface{}, t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return nil
+	}
+
+	unknown := []string{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields[strings.ToLower(name)] = field.Type
+		}
+
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldType, ok := fields[strings.ToLower(key)]
+			if !ok {
+				unknown = append(unknown, prefix+key)
+				continue
+			}
+			unknown = append(unknown, unknownFields(v[key], fieldType, prefix+key+".")...)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for _, element := range v {
+			unknown = append(unknown, unknownFields(element, t.Elem(), prefix)...)
+		}
+	}
+	return unknown
+}
+
+// Validate returns problems found in the genesis.
+func (g *LoadedGenesis) Validate() []string {
+	problems := []string{}
+	for _, field := range g.Unknown {
+		problems = append(problems, fmt.Sprintf("unknown field %q", field))
+	}
+	problems = append(problems, g.Genesis.Validate()...)
+
+	if g.AssertChainID != "" && g.AssertChainID != g.ChainID {
+		problems = append(problems, fmt.Sprintf("chain_id %q does not match assert_chain_id %q of config.toml", g.ChainID, g.AssertChainID))
+	}
+	return problems
+}
+
+// Validate returns problems found in the genesis.
+func (g *Genesis) Validate() []string {
+	problems := []string{}
+	all := (uint64(1) << uint(len(Permissions))) - 1
+
+	if g.ChainID == "" {
+		problems = append(problems, "chain_id is empty")
+	}
+
+	addresses := make(map[string]bool)
+	for i, a := range g.Accounts {
+		account := fmt.Sprintf("account #%d (%s)", i+1, a.Name)
+		address := strings.ToUpper(a.Address)
+		if b, err := hex.DecodeString(address); err != nil || len(b) != 20 {
+			problems = append(problems, fmt.Sprintf("%s: bad address %q", account, a.Address))
+		} else if addresses[address] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate address %s", account, address))
+		}
+		addresses[address] = true
+
+		if a.Amount < 0 {
+			problems = append(problems, fmt.Sprintf("%s: negative amount %d", account, a.Amount))
+		}
+		if p := a.Permissions; p != nil && (p.Base.Perms > all || p.Base.SetBit > all) {
+			problems = append(problems, fmt.Sprintf("%s: unknown permission bits in %d/%d", account, p.Base.Perms, p.Base.SetBit))
+		}
+	}
+	if g.Params != nil {
+		if p := g.Params.GlobalPermissions; p != nil && (p.Base.Perms > all || p.Base.SetBit > all) {
+			problems = append(problems, fmt.Sprintf("global permissions: unknown permission bits in %d/%d", p.Base.Perms, p.Base.SetBit))
+		}
+	}
+
+	if len(g.Validators) == 0 {
+		problems = append(problems, "there are no validators")
+	}
+	keys := make(map[Key]bool)
+	for i, v := range g.Validators {
+		validator := fmt.Sprintf("validator #%d (%s)", i+1, v.Name)
+		if _, err := PubKeyAddress(string(v.PubKey)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: bad public key %q", validator, v.PubKey))
+		} else if keys[v.PubKey] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate public key %s", validator, v.PubKey))
+		}
+		keys[v.PubKey] = true
+
+		if v.Amount <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: no voting power (amount %d)", validator, v.Amount))
+		}
+		if len(v.UnbondTo) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no unbond_to accounts", validator))
+		}
+		for _, u := range v.UnbondTo {
+			if b, err := hex.DecodeString(u.Address); err != nil || len(b) != 20 {
+				problems = append(problems, fmt.Sprintf("%s: bad unbond_to address %q", validator, u.Address))
+			}
+			if u.Amount <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: unbond_to amount %d is not positive", validator, u.Amount))
+			}
+		}
+	}
+
+	if power := g.VotingPower(); power < 0 {
+		problems = append(problems, "total voting power overflows")
+	}
+	return problems
+}
+
+// VotingPower returns the total amount bonded by validators
+// (negative on overflow).
+func (g *Genesis) VotingPower() int64 {
+	var power int64
+	for _, v := range g.Validators {
+		if v.Amount > 0 {
+			if power+v.Amount < power {
+				return -1
+			}
+			power += v.Amount
+		}
+	}
+	return power
+}
+
+// diffGenesis returns the differences between genesis files.
+func diffGenesis(a, b *LoadedGenesis) []string {
+	diffs := []string{}
+	if a.ChainID != b.ChainID {
+		diffs = append(diffs, fmt.Sprintf("~ chain_id %q -> %q", a.ChainID, b.ChainID))
+	}
+	if a.GenesisTime != b.GenesisTime {
+		diffs = append(diffs, fmt.Sprintf("~ genesis_time %q -> %q", a.GenesisTime, b.GenesisTime))
+	}
+	if ga, gb := globalPermissions(a.Genesis), globalPermissions(b.Genesis); ga != gb {
+		diffs = append(diffs, fmt.Sprintf("~ global_permissions %s -> %s", ga, gb))
+	}
+
+	accountsA, accountsB := make(map[string]*GenesisAccount), make(map[string]*GenesisAccount)
+	for _, account := range a.Accounts {
+		accountsA[strings.ToUpper(account.Address)] = account
+	}
+	for _, account := range b.Accounts {
+		accountsB[strings.ToUpper(account.Address)] = account
+	}
+	for _, account := range a.Accounts {
+		address := strings.ToUpper(account.Address)
+		other, ok := accountsB[address]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("- account %s (%s) amount %d", address, account.Name, account.Amount))
+			continue
+		}
+		if account.Amount != other.Amount {
+			diffs = append(diffs, fmt.Sprintf("~ account %s (%s) amount %d -> %d", address, account.Name, account.Amount, other.Amount))
+		}
+		if account.Name != other.Name {
+			diffs = append(diffs, fmt.Sprintf("~ account %s name %q -> %q", address, account.Name, other.Name))
+		}
+		if pa, pb := accountPermissions(account), accountPermissions(other); pa != pb {
+			diffs = append(diffs, fmt.Sprintf("~ account %s (%s) permissions %s -> %s", address, account.Name, pa, pb))
+		}
+	}
+	for _, account := range b.Accounts {
+		if address := strings.ToUpper(account.Address); accountsA[address] == nil {
+			diffs = append(diffs, fmt.Sprintf("+ account %s (%s) amount %d", address, account.Name, account.Amount))
+		}
+	}
+
+	validatorsA, validatorsB := make(map[Key]*GenesisValidator), make(map[Key]*GenesisValidator)
+	for _, v := range a.Validators {
+		validatorsA[v.PubKey] = v
+	}
+	for _, v := range b.Validators {
+		validatorsB[v.PubKey] = v
+	}
+	for _, v := range a.Validators {
+		other, ok := validatorsB[v.PubKey]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("- validator %s (%s) amount %d", v.PubKey, v.Name, v.Amount))
+			continue
+		}
+		if v.Amount != other.Amount {
+			diffs = append(diffs, fmt.Sprintf("~ validator %s (%s) amount %d -> %d", v.PubKey, v.Name, v.Amount, other.Amount))
+		}
+		if v.Name != other.Name {
+			diffs = append(diffs, fmt.Sprintf("~ validator %s name %q -> %q", v.PubKey, v.Name, other.Name))
+		}
+		if ua, ub := unbondTo(v), unbondTo(other); ua != ub {
+			diffs = append(diffs, fmt.Sprintf("~ validator %s (%s) unbond_to %s -> %s", v.PubKey, v.Name, ua, ub))
+		}
+	}
+	for _, v := range b.Validators {
+		if validatorsA[v.PubKey] == nil {
+			diffs = append(diffs, fmt.Sprintf("+ validator %s (%s) amount %d", v.PubKey, v.Name, v.Amount))
+		}
+	}
+	return diffs
+}
+
+func globalPermissions(g *Genesis) string {
+	global := g.GlobalPermissions()
+	if global.SetBit == 0 {
+		return "none"
+	}
+	return permissionNames(global)
+}
+
+func accountPermissions(a *GenesisAccount) string {
+	if a.Permissions == nil {
+		return "global"
+	}
+	perms := permissionNames(a.Permissions.Base)
+	if len(a.Permissions.Roles) != 0 {
+		perms += " roles " + strings.Join(a.Permissions.Roles, ",")
+	}
+	return perms
+}
+
+func unbondTo(v *GenesisValidator) string {
+	accounts := []string{}
+	for _, u := range v.UnbondTo {
+		accounts = append(accounts, fmt.Sprintf("%s:%d", strings.ToUpper(u.Address), u.Amount))
+	}
+	return strings.Join(accounts, ",")
+}
+
+// permissionNames lists the permissions granted (and, prefixed with !,
+// denied) by the base permissions. Permissions not set fall back to
+// the global ones and are not listed.
+func permissionNames(base BasePermissions) string {
+	all := (uint64(1) << uint(len(Permissions))) - 1
+	switch {
+	case base.SetBit&all == 0:
+		return "global"
+	case base.SetBit&all == all && base.Perms&all == all:
+		return "all"
+	}
+
+	names := []string{}
+	for i, name := range Permissions {
+		bit := uint64(1) << uint(i)
+		if base.SetBit&bit == 0 {
+			continue
+		}
+		if base.Perms&bit == 0 {
+			name = "!" + name
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+// catChainFile reads the file from the chain directory
+// in the chain's data container.
+func catChainFile(chain, file string) ([]byte, error) {
+	doCat := definitions.NowDo()
+	doCat.Name = chain
+	doCat.Operations.SkipLink = true
+	doCat.Operations.Args = []string{"cat", path.Join(config.ErisContainerRoot, "chains", chain, file)}
+
+	buf, err := ExecChain(doCat)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,204 @@
+package chains
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+)
+
+func TestKeyJSON(t *testing.T) {
+	content, err := json.Marshal(Key(testPubKey))
+	if err != nil || string(content) != `[1,"`+testPubKey+`"]` {
+		t.Fatalf("expected [type, key] pair, got %s, %v", content, err)
+	}
+
+	var key Key
+	if err := json.Unmarshal([]byte(`[1,"`+strings.ToLower(testPubKey)+`"]`), &key); err != nil || string(key) != testPubKey {
+		t.Fatalf("expected key read back, got %v, %v", key, err)
+	}
+	if err := json.Unmarshal([]byte(`[2,"`+testPubKey+`"]`), &key); err == nil {
+		t.Fatalf("expected non-Ed25519 key to fail")
+	}
+}
+
+func TestLoadGenesisValidate(t *testing.T) {
+	defer tempChainsPath(t)()
+
+	dir := filepath.Join(config.ChainsPath, "marmot")
+	os.MkdirAll(dir, 0755)
+	writeJSON(filepath.Join(dir, "genesis.json"), testGenesis(), 0644)
+	writeConfig(filepath.Join(dir, "config.toml"), nodeConfig{Chain: "marmot", Moniker: "marmot_full_000"})
+
+	genesis, err := LoadGenesis("marmot")
+	if err != nil {
+		t.Fatalf("expected genesis loaded, got %v", err)
+	}
+	if genesis.AssertChainID != "marmot" {
+		t.Fatalf("expected assert_chain_id read, got %q", genesis.AssertChainID)
+	}
+	if problems := genesis.Validate(); len(problems) != 0 {
+		t.Fatalf("expected valid genesis, got %v", problems)
+	}
+
+	// Break it in every way.
+	broken := testGenesis()
+	broken.ChainID = "beaver"
+	broken.Accounts = append(broken.Accounts, broken.Accounts[0])
+	broken.Validators[0].Amount = 0
+	content, _ := json.Marshal(broken)
+	content = bytes.Replace(content, []byte(`"accounts"`), []byte(`"marmots":{},"accounts"`), 1)
+	content = bytes.Replace(content, []byte(`"unbond_to"`), []byte(`"beavers":1,"unbond_to"`), 1)
+	ioutil.WriteFile(filepath.Join(dir, "genesis.json"), content, 0644)
+
+	if genesis, err = LoadGenesis(filepath.Join(dir, "genesis.json")); err != nil {
+		t.Fatalf("expected genesis loaded by path, got %v", err)
+	}
+	problems := strings.Join(genesis.Validate(), "\n")
+	for _, expected := range []string{`unknown field "marmots"`, `unknown field "validators.beavers"`, "duplicate address", "no voting power", "does not match assert_chain_id"} {
+		if !strings.Contains(problems, expected) {
+			t.Fatalf("expected %q among problems, got %v", expected, problems)
+		}
+	}
+}
+
+func TestDiffGenesis(t *testing.T) {
+	a, b := testGenesis(), testGenesis()
+	b.ChainID = "beaver"
+	b.Accounts[0].Amount = 5
+	b.Accounts[1].Permissions.Base = BasePermissions{Perms: 2, SetBit: 6}
+	b.Accounts = append(b.Accounts[:1], b.Accounts[1], &GenesisAccount{Address: strings.Repeat("AB", 20), Name: "new"})
+	b.Validators[0].UnbondTo[0].Amount = 1
+	b.GenesisTime = "2016-10-19T00:00:00Z"
+	b.Params = &GenesisParams{GlobalPermissions: &AccountPermissions{Base: BasePermissions{Perms: 2, SetBit: 2}}}
+
+	diffs := strings.Join(diffGenesis(&LoadedGenesis{Genesis: a}, &LoadedGenesis{Genesis: b}), "\n")
+	for _, expected := range []string{
+		`~ chain_id "marmot" -> "beaver"`,
+		"amount 1000 -> 5",
+		"permissions global -> send,!call",
+		"+ account ABABABABABABABABABABABABABABABABABABABAB (new)",
+		"unbond_to",
+		`~ genesis_time "" -> "2016-10-19T00:00:00Z"`,
+		"~ global_permissions none -> send",
+	} {
+		if !strings.Contains(diffs, expected) {
+			t.Fatalf("expected %q among differences, got %v", expected, diffs)
+		}
+	}
+
+	if diffs := diffGenesis(&LoadedGenesis{Genesis: a}, &LoadedGenesis{Genesis: testGenesis()}); len(diffs) != 0 {
+		t.Fatalf("expected no differences, got %v", diffs)
+	}
+
+	defer tempChainsPath(t)()
+	os.MkdirAll(config.ChainsPath, 0755)
+	writeJSON(filepath.Join(config.ChainsPath, "a.json"), a, 0644)
+	writeJSON(filepath.Join(config.ChainsPath, "b.json"), b, 0644)
+	config.Global.Writer = ioutil.Discard
+	defer func() { config.Global.Writer = os.Stdout }()
+
+	do := definitions.NowDo()
+	do.Operations.Args = []string{filepath.Join(config.ChainsPath, "a.json"), filepath.Join(config.ChainsPath, "b.json")}
+	if err := DiffGenesis(do); err == nil {
+		t.Fatalf("expected different genesis files to fail")
+	}
+	do.Operations.Args[1] = do.Operations.Args[0]
+	if err := DiffGenesis(do); err != nil {
+		t.Fatalf("expected the same genesis files not to fail, got %v", err)
+	}
+}
+
+func TestGenesisAccounts(t *testing.T) {
+	defer tempChainsPath(t)()
+
+	file := filepath.Join(config.ChainsPath, "genesis.json")
+	writeJSON(file, testGenesis(), 0644)
+
+	buf := new(bytes.Buffer)
+	config.Global.Writer = buf
+	defer func() { config.Global.Writer = os.Stdout }()
+
+	do := definitions.NowDo()
+	do.Name = file
+	if err := GenesisAccounts(do); err != nil {
+		t.Fatalf("expected accounts listed, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "marmot_full_000") || !strings.Contains(lines[1], "100") || !strings.Contains(lines[1], "all") {
+		t.Fatalf("expected validator account with bond and all permissions, got %s", buf)
+	}
+	if !strings.Contains(lines[2], "global") {
+		t.Fatalf("expected account with global permissions, got %s", lines[2])
+	}
+
+	genesis := testGenesis()
+	genesis.Params = &GenesisParams{GlobalPermissions: &AccountPermissions{Base: BasePermissions{Perms: 2, SetBit: 6}}}
+	genesis.Accounts[1].Permissions.Base = BasePermissions{Perms: 4, SetBit: 4}
+	writeJSON(file, genesis, 0644)
+
+	buf.Reset()
+	if err := GenesisAccounts(do); err != nil {
+		t.Fatalf("expected accounts listed, got %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "send,call") {
+		t.Fatalf("expected account permissions set over the global ones, got %s", buf)
+	}
+}
+
+func TestParseGenesisParams(t *testing.T) {
+	genesis := testGenesis()
+	genesis.Params = &GenesisParams{GlobalPermissions: &AccountPermissions{Base: BasePermissions{Perms: 2, SetBit: 2}, Roles: []string{}}}
+	content, _ := json.Marshal(genesis)
+
+	parsed, unknown, err := parseGenesis(content)
+	if err != nil {
+		t.Fatalf("expected genesis parsed, got %v", err)
+	}
+	if len(unknown) != 0 {
+		t.Fatalf("expected no unknown fields, got %v", unknown)
+	}
+	if parsed.Params == nil || parsed.Params.GlobalPermissions == nil || parsed.Params.GlobalPermissions.Base.Perms != 2 {
+		t.Fatalf("expected global permissions read, got %v", parsed.Params)
+	}
+	if problems := parsed.Validate(); len(problems) != 0 {
+		t.Fatalf("expected valid genesis, got %v", problems)
+	}
+}
+
+func testGenesis() *Genesis {
+	address, _ := PubKeyAddress(testPubKey)
+	return &Genesis{
+		ChainID: "marmot",
+		Accounts: []*GenesisAccount{
+			{
+				Address:     address,
+				Amount:      1000,
+				Name:        "marmot_full_000",
+				Permissions: &AccountPermissions{Base: BasePermissions{16383, 16383}, Roles: []string{}},
+			},
+			{
+				Address:     strings.Repeat("12", 20),
+				Amount:      10,
+				Name:        "marmot_participant_000",
+				Permissions: &AccountPermissions{Roles: []string{}},
+			},
+		},
+		Validators: []*GenesisValidator{
+			{
+				PubKey:   Key(testPubKey),
+				Amount:   100,
+				Name:     "marmot_full_000",
+				UnbondTo: []*UnbondTo{{address, 100}},
+			},
+		},
+	}
+}
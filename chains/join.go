@@ -0,0 +1,240 @@
+package chains
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/services"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+// JoinChain sets up and starts a node for a chain run elsewhere. The
+// genesis.json file, a config.toml file with the seeds, and the
+// priv_validator.json file of the node key are written into the
+// config.ChainsPath/NAME directory and the chain is started from there
+// (the key is imported into eris-keys the way [eris chains start] does).
+// The node validates the chain if its key is a genesis validator key.
+//
+//  do.Name        - name of the chain on this host (required)
+//  do.GenesisFile - path, URL, or IPFS hash of the chain's genesis.json file (required)
+//  do.Seeds       - HOST:PORT addresses of nodes to connect to (required)
+//  do.Priv        - priv_validator.json file or an eris-keys address of the node key (the key of an
+//                   existing chain directory is kept or a new key is generated if empty)
+//  do.Force       - overwrite files of an existing chain directory, except for the node
+//                   key if do.Priv is empty, and join under a name other than the
+//                   genesis chain ID (optional)
+//  do.Operations  - container operations for the chain (e.g. published ports) (optional)
+//
+func JoinChain(do *definitions.Do) error {
+	if do.Name == "" {
+		return fmt.Errorf("No chain name given")
+	}
+	if do.GenesisFile == "" {
+		return fmt.Errorf("No genesis file given. Use the --genesis flag")
+	}
+	seeds, err := parseSeeds(do.Seeds)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(config.ChainsPath, do.Name)
+	if util.DoesFileExist(filepath.Join(dir, "config.toml")) && !do.Force {
+		return fmt.Errorf("Chain %s already has files in %s. Use the --force flag to overwrite them", do.Name, util.Tilde(dir))
+	}
+
+	content, err := fetchGenesis(do.GenesisFile)
+	if err != nil {
+		return err
+	}
+	genesis, unknown, err := parseGenesis(content)
+	if err != nil {
+		return fmt.Errorf("Cannot read the genesis file %s: %v", do.GenesisFile, err)
+	}
+	if problems := (&LoadedGenesis{Genesis: genesis, Unknown: unknown}).Validate(); len(problems) != 0 {
+		return fmt.Errorf("The genesis file %s has problems: %s. See [eris chains genesis validate]", do.GenesisFile, strings.Join(problems, "; "))
+	}
+	if genesis.ChainID != do.Name {
+		// The chain container gets the name as its chain ID (CHAIN_ID),
+		// while config.toml asserts the genesis chain ID.
+		if !do.Force {
+			return fmt.Errorf("Chain name %s differs from the chain ID %s in the genesis file. Join as [eris chains join %s] or use the --force flag", do.Name, genesis.ChainID, genesis.ChainID)
+		}
+		log.WithFields(log.Fields{
+			"name":     do.Name,
+			"chain ID": genesis.ChainID,
+		}).Warn("Chain name differs from the chain ID")
+	}
+
+	doKeys := definitions.NowDo()
+	doKeys.Name = "keys"
+	if err := services.EnsureRunning(doKeys); err != nil {
+		return err
+	}
+
+	// The node key may have signed blocks already,
+	// so it is only replaced by a key given explicitly.
+	priv, keep := do.Priv, false
+	if existing := filepath.Join(dir, "priv_validator.json"); priv == "" && util.DoesFileExist(existing) {
+		log.WithField("=>", util.Tilde(existing)).Warn("Keeping the node key")
+		priv, keep = existing, true
+	}
+	key, err := joinKey(priv)
+	if err != nil {
+		return err
+	}
+
+	validator := false
+	for _, v := range genesis.Validators {
+		if v.PubKey == key.PubKey {
+			validator = true
+		}
+	}
+	log.WithFields(log.Fields{
+		"=>":        do.Name,
+		"address":   key.Address,
+		"validator": validator,
+	}).Warn("Joining the chain")
+
+	node := nodeConfig{
+		Chain:   genesis.ChainID,
+		Moniker: fmt.Sprintf("%s_%s", do.Name, strings.ToLower(key.Address[:8])),
+		Seeds:   strings.Join(seeds, ","),
+	}
+	write := key
+	if keep {
+		write = nil
+	}
+	if err := writeJoin(dir, content, node, write); err != nil {
+		return err
+	}
+
+	doStart := definitions.NowDo()
+	doStart.Name = do.Name
+	doStart.Path = dir
+	doStart.Force = true
+	doStart.Operations = do.Operations
+	return StartChain(doStart)
+}
+
+// parseSeeds checks and returns the HOST:PORT seed addresses.
+func parseSeeds(args []string) ([]string, error) {
+	seeds := []string{}
+	for _, arg := range args {
+		for _, seed := range strings.Split(arg, ",") {
+			if seed = strings.TrimSpace(seed); seed == "" {
+				continue
+			}
+			host, port, err := net.SplitHostPort(seed)
+			if err != nil || host == "" {
+				return nil, fmt.Errorf("Bad seed %q. Seeds are HOST:PORT addresses", seed)
+			}
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("Bad seed %q: port %q is not valid", seed, port)
+			}
+			seeds = append(seeds, seed)
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("No seeds given. Use the --seeds flag with HOST:PORT addresses of the chain nodes")
+	}
+	return seeds, nil
+}
+
+// fetchGenesis reads the genesis file from a path,
+// a URL, or an IPFS hash.
+func fetchGenesis(source string) ([]byte, error) {
+	if util.DoesFileExist(source) {
+		return ioutil.ReadFile(source)
+	}
+
+	url := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+	if !url && !util.IsIPFSHash(source) {
+		return nil, fmt.Errorf("There is no genesis file %s. Give a path, a URL, or an IPFS hash", source)
+	}
+
+	tmp, err := ioutil.TempDir("", "eris-genesis-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if url {
+		err = util.DownloadFromUrlToFile(source, "genesis.json", tmp)
+	} else {
+		err = util.GetFromIPFS(source, "genesis.json", tmp, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get the genesis file %s: %v", source, err)
+	}
+	return ioutil.ReadFile(filepath.Join(tmp, "genesis.json"))
+}
+
+// joinKey returns the node key: read from the priv_validator.json
+// file or from eris-keys by its address, or a new one if priv is empty.
+func joinKey(priv string) (*PrivValidator, error) {
+	switch {
+	case priv == "":
+		log.Warn("Generating a new node key")
+		return newKey()
+	case util.DoesFileExist(priv):
+		return readPrivValidator(priv)
+	default:
+		// The address is a path in the keys container.
+		address := strings.ToUpper(priv)
+		if b, err := hex.DecodeString(address); err != nil || len(b) != 20 {
+			return nil, fmt.Errorf("Bad key %q. Give a priv_validator.json file or a 40 character hex eris-keys address", priv)
+		}
+		return readKey(address)
+	}
+}
+
+// readPrivValidator reads the priv_validator.json file
+// and checks that the address matches the public key.
+func readPrivValidator(file string) (*PrivValidator, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	key := new(PrivValidator)
+	if err := json.Unmarshal(content, key); err != nil {
+		return nil, fmt.Errorf("Cannot read the validator key %s: %v", file, err)
+	}
+	address, err := PubKeyAddress(string(key.PubKey))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the validator key %s: %v", file, err)
+	}
+	if !strings.EqualFold(address, key.Address) {
+		return nil, fmt.Errorf("Validator key %s: address %s does not belong to the public key (expected %s)", file, key.Address, address)
+	}
+	key.Address = address
+	return key, nil
+}
+
+// writeJoin writes the node files into the dir directory. The
+// priv_validator.json file is left as it is if the key is nil.
+func writeJoin(dir string, genesis []byte, node nodeConfig, key *PrivValidator) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "genesis.json"), genesis, 0644); err != nil {
+		return err
+	}
+	if err := writeConfig(filepath.Join(dir, "config.toml"), node); err != nil {
+		return err
+	}
+	if key == nil {
+		return nil
+	}
+	return writeJSON(filepath.Join(dir, "priv_validator.json"), key, 0600)
+}
@@ -0,0 +1,127 @@
+package chains
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+)
+
+func TestParseSeeds(t *testing.T) {
+	seeds, err := parseSeeds([]string{"10.0.0.5:46656, 10.0.0.6:46656", "seed.example.com:46656"})
+	if err != nil {
+		t.Fatalf("expected seeds parsed, got %v", err)
+	}
+	if strings.Join(seeds, ",") != "10.0.0.5:46656,10.0.0.6:46656,seed.example.com:46656" {
+		t.Fatalf("expected three seeds, got %v", seeds)
+	}
+
+	for _, bad := range [][]string{nil, {"10.0.0.5"}, {":46656"}, {"10.0.0.5:marmot"}, {"10.0.0.5:70000"}} {
+		if _, err := parseSeeds(bad); err == nil {
+			t.Fatalf("expected %v to fail", bad)
+		}
+	}
+}
+
+func TestReadPrivValidator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-join-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	address, _ := PubKeyAddress(testPubKey)
+	file := filepath.Join(dir, "priv_validator.json")
+	writeJSON(file, &PrivValidator{Address: strings.ToLower(address), PubKey: Key(testPubKey), PrivKey: Key(strings.Repeat("AB", 32) + testPubKey)}, 0600)
+
+	key, err := joinKey(file)
+	if err != nil {
+		t.Fatalf("expected key read, got %v", err)
+	}
+	if key.Address != address || key.PubKey != Key(testPubKey) {
+		t.Fatalf("expected key %s, got %v", address, key)
+	}
+
+	writeJSON(file, &PrivValidator{Address: strings.Repeat("12", 20), PubKey: Key(testPubKey)}, 0600)
+	if _, err := readPrivValidator(file); err == nil {
+		t.Fatalf("expected address not matching the key to fail")
+	}
+
+	for _, bad := range []string{"../../etc/passwd", "1234", strings.Repeat("ZZ", 20)} {
+		if _, err := joinKey(bad); err == nil {
+			t.Fatalf("expected key %q to fail", bad)
+		}
+	}
+}
+
+func TestFetchGenesisUnknown(t *testing.T) {
+	if _, err := fetchGenesis("no-such-genesis.json"); err == nil || !strings.Contains(err.Error(), "There is no genesis file") {
+		t.Fatalf("expected missing file not taken for an IPFS hash, got %v", err)
+	}
+}
+
+func TestWriteJoin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-join-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "downloaded.json")
+	writeJSON(source, testGenesis(), 0644)
+	content, err := fetchGenesis(source)
+	if err != nil {
+		t.Fatalf("expected genesis read, got %v", err)
+	}
+
+	chainDir := filepath.Join(dir, "ourchain")
+	key := &PrivValidator{Address: strings.Repeat("12", 20), PubKey: Key(testPubKey)}
+	if err := writeJoin(chainDir, content, nodeConfig{Chain: "marmot", Moniker: "ourchain_12121212", Seeds: "10.0.0.5:46656"}, key); err != nil {
+		t.Fatalf("expected node files written, got %v", err)
+	}
+
+	genesis, err := LoadGenesis(chainDir)
+	if err != nil {
+		t.Fatalf("expected genesis loaded, got %v", err)
+	}
+	if problems := genesis.Validate(); genesis.AssertChainID != "marmot" || len(problems) != 0 {
+		t.Fatalf("expected valid genesis matching assert_chain_id, got %q, %v", genesis.AssertChainID, problems)
+	}
+
+	config, _ := ioutil.ReadFile(filepath.Join(chainDir, "config.toml"))
+	if !strings.Contains(string(config), `seeds = "10.0.0.5:46656"`) || !strings.Contains(string(config), `moniker = "ourchain_12121212"`) {
+		t.Fatalf("expected seeds and moniker in config, got %s", config)
+	}
+	if info, err := os.Stat(filepath.Join(chainDir, "priv_validator.json")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected priv_validator.json readable by the owner only, got %v", err)
+	}
+
+	// A nil key leaves the existing file in place.
+	before, _ := ioutil.ReadFile(filepath.Join(chainDir, "priv_validator.json"))
+	if err := writeJoin(chainDir, content, nodeConfig{Chain: "marmot", Moniker: "ourchain_12121212"}, nil); err != nil {
+		t.Fatalf("expected node files written, got %v", err)
+	}
+	if after, _ := ioutil.ReadFile(filepath.Join(chainDir, "priv_validator.json")); string(after) != string(before) {
+		t.Fatalf("expected priv_validator.json kept, got %s", after)
+	}
+}
+
+func TestJoinChainID(t *testing.T) {
+	defer tempChainsPath(t)()
+
+	source := filepath.Join(config.ChainsPath, "downloaded.json")
+	os.MkdirAll(config.ChainsPath, 0755)
+	writeJSON(source, testGenesis(), 0644)
+
+	do := definitions.NowDo()
+	do.Name = "beaver"
+	do.GenesisFile = source
+	do.Seeds = []string{"10.0.0.5:46656"}
+	if err := JoinChain(do); err == nil || !strings.Contains(err.Error(), "differs from the chain ID marmot") {
+		t.Fatalf("expected a name other than the chain ID to fail, got %v", err)
+	}
+}
@@ -1,12 +1,24 @@
 package chains
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"text/template"
 
 	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/data"
@@ -17,29 +29,685 @@ import (
 	"github.com/eris-ltd/eris-cli/util"
 )
 
-// MakeChain runs the `eris-cm make` command in a Docker container.
-// It returns an error. Note that if do.Known, do.AccountTypes
-// or do.ChainType are not set the command will run via interactive
-// shell.
+// MakeChain writes the genesis.json file of a new chain into the
+// config.ChainsPath/NAME directory. For account and chain types, keys
+// of every account are generated with eris-keys and each account also
+// gets a NAME_TYPE_NNN directory with the genesis.json, config.toml,
+// and priv_validator.json files necessary to run a node. If the chain
+// has a single validator, its files are also put into the chain directory
+// for [eris chains start] to pick up. With do.Known, only the genesis.json
+// file is written from the given CSV files.
+//
+// The interactive wizard (do.Wizard without account or chain types)
+// still runs `eris-cm make` in a Docker container.
 //
 //  do.Name          - name of the chain to be created (required)
-//  do.Known         - will use the mintgen tool to parse csv's and create a genesis.json (requires do.ChainMakeVals and do.ChainMakeActs) (optional)
-//  do.ChainMakeVals - csv file to use for validators (optional)
-//  do.ChainMakeActs - csv file to use for accounts (optional)
-//  do.AccountTypes  - use eris-cm make account-types paradigm (example: Root:1,Participants:25,...) (optional)
-//  do.ChainType     - use eris-cm make chain-types paradigm (example: simplechain) (optional)
-//  do.Tarball       - instead of outputing raw files in directories, output packages of tarbals (optional)
+//  do.Known         - assemble genesis.json from CSV files of known keys (requires do.ChainMakeVals and do.ChainMakeActs) (optional)
+//  do.ChainMakeVals - comma separated list of validators CSV files (optional)
+//  do.ChainMakeActs - comma separated list of accounts CSV files (optional)
+//  do.AccountTypes  - account types and numbers of accounts (example: Root:1,Participant:25,...) (optional)
+//  do.ChainType     - chain type to make (example: simplechain, the default) (optional)
+//  do.Tarball       - instead of outputing raw files in directories, output tarballs (optional)
 //  do.ZipFile       - similar to do.Tarball except uses zipfiles (optional)
-//  do.Verbose       - verbose output (optional)
-//  do.Debug         - debug output (optional)
+//  do.Output        - print the accounts made (optional)
+//  do.Wizard        - run the interactive eris-cm wizard (optional)
 //
 func MakeChain(do *definitions.Do) error {
+	if do.Name == "" {
+		return fmt.Errorf("No chain name given")
+	}
+	if do.Wizard && len(do.AccountTypes) == 0 && do.ChainType == "" {
+		return makeChainWizard(do)
+	}
+	if do.Known {
+		return makeKnownChain(do)
+	}
+
+	var (
+		counts    []*AccountCount
+		consensus ConsensusParams
+		err       error
+	)
+	if len(do.AccountTypes) != 0 {
+		counts, err = ParseAccountTypes(do.AccountTypes)
+	} else {
+		chainType := do.ChainType
+		if chainType == "" {
+			chainType = "simplechain"
+		}
+		var t *ChainType
+		if t, err = LoadChainType(chainType); err == nil {
+			counts, err = t.Accounts()
+			consensus = t.Consensus
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	doKeys := definitions.NowDo()
+	doKeys.Name = "keys"
+	if err := services.EnsureRunning(doKeys); err != nil {
+		return err
+	}
+
+	accounts, err := makeAccounts(do.Name, counts)
+	if err != nil {
+		return err
+	}
+	genesis, err := accountsGenesis(do.Name, accounts)
+	if err != nil {
+		return err
+	}
+	if err := writeChain(do, genesis, accounts, consensus); err != nil {
+		return err
+	}
+
+	if do.Output {
+		printAccounts(config.Global.Writer, accounts)
+	}
+	return nil
+}
+
+// NodeDir returns the directory to start a chain made with MakeChain from
+// (see [eris chains start --init-dir]): the chain directory if the chain
+// has a single validator, or the directory of its first validator
+// (as listed in the validators.csv file) otherwise.
+func NodeDir(name string) (string, error) {
+	dir := filepath.Join(config.ChainsPath, name)
+	if util.DoesFileExist(filepath.Join(dir, "config.toml")) && util.DoesFileExist(filepath.Join(dir, "priv_validator.json")) {
+		return dir, nil
+	}
+
+	var validator string
+	err := readKnown(filepath.Join(dir, "validators.csv"), func(r *knownRecord) error {
+		if validator == "" {
+			validator = r.Name
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if validator == "" || !util.DoesFileExist(filepath.Join(dir, validator, "config.toml")) {
+		return "", fmt.Errorf("Cannot find the files of a validator node of chain %s in %s", name, util.Tilde(dir))
+	}
+	return filepath.Join(dir, validator), nil
+}
+
+// account is an account made for the chain.
+type account struct {
+	Name string
+	Type *AccountType
+	Key  *PrivValidator
+}
+
+// newKey generates a key in eris-keys and returns it
+// in the priv_validator.json format.
+var newKey = func() (*PrivValidator, error) {
+	buf, err := services.ExecHandler("keys", []string{"eris-keys", "gen", "--no-pass"})
+	if err != nil {
+		return nil, fmt.Errorf("Cannot generate a key: %v", err)
+	}
+	fields := strings.Fields(buf.String())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("Cannot generate a key: eris-keys returned no address")
+	}
+	address := strings.ToUpper(fields[len(fields)-1])
+	if b, err := hex.DecodeString(address); err != nil || len(b) != 20 {
+		return nil, fmt.Errorf("Cannot generate a key: bad address %q returned by eris-keys", address)
+	}
+
+	return readKey(address)
+}
+
+// readKey reads the key from eris-keys
+// in the priv_validator.json format.
+func readKey(address string) (*PrivValidator, error) {
+	buf, err := services.ExecHandler("keys", []string{"cat", path.Join(config.KeysContainerPath, address, address)})
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the key %s: %v", address, err)
+	}
+	return parseKeyFile(buf.Bytes())
+}
+
+// parseKeyFile converts the eris-keys key file
+// into the priv_validator.json format.
+func parseKeyFile(content []byte) (*PrivValidator, error) {
+	var key struct {
+		Address    string
+		PrivateKey string
+	}
+	if err := json.Unmarshal(content, &key); err != nil {
+		return nil, fmt.Errorf("Cannot read the key file: %v", err)
+	}
+
+	priv, err := hex.DecodeString(key.PrivateKey)
+	if err != nil {
+		priv, err = base64.StdEncoding.DecodeString(key.PrivateKey)
+	}
+	if err != nil || len(priv) != 64 {
+		return nil, fmt.Errorf("Key %s is not an Ed25519 key", key.Address)
+	}
+
+	return &PrivValidator{
+		Address: strings.ToUpper(key.Address),
+		PubKey:  Key(strings.ToUpper(hex.EncodeToString(priv[32:]))),
+		PrivKey: Key(strings.ToUpper(hex.EncodeToString(priv))),
+	}, nil
+}
+
+// makeAccounts generates keys for the accounts of the chain,
+// named NAME_TYPE_NNN.
+func makeAccounts(chain string, counts []*AccountCount) ([]*account, error) {
+	accounts := []*account{}
+	for _, count := range counts {
+		for i := 0; i < count.Number; i++ {
+			name := fmt.Sprintf("%s_%s_%03d", chain, strings.ToLower(count.Type.Name), i)
+			log.WithField("=>", name).Info("Generating account key")
+
+			key, err := newKey()
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, &account{name, count.Type, key})
+		}
+	}
+	return accounts, nil
+}
+
+// accountsGenesis returns the genesis of the chain with the accounts.
+func accountsGenesis(chain string, accounts []*account) (*Genesis, error) {
+	genesis := &Genesis{
+		ChainID:    chain,
+		Accounts:   []*GenesisAccount{},
+		Validators: []*GenesisValidator{},
+	}
+
+	for _, a := range accounts {
+		genesis.Accounts = append(genesis.Accounts, &GenesisAccount{
+			Address: a.Key.Address,
+			Amount:  a.Type.DefaultTokens,
+			Name:    a.Name,
+			Permissions: &AccountPermissions{
+				Base:  a.Type.Permissions(),
+				Roles: []string{},
+			},
+		})
+
+		if a.Type.Validator() {
+			genesis.Validators = append(genesis.Validators, &GenesisValidator{
+				PubKey:   a.Key.PubKey,
+				Amount:   a.Type.DefaultBond,
+				Name:     a.Name,
+				UnbondTo: []*UnbondTo{{a.Key.Address, a.Type.DefaultBond}},
+			})
+		}
+	}
+
+	if len(genesis.Validators) == 0 {
+		return nil, fmt.Errorf("Chain %s has no validators. Add an account type with default_bond (e.g. Full or Validator)", chain)
+	}
+	return genesis, nil
+}
+
+// writeChain writes the chain files into the config.ChainsPath/NAME
+// directory.
+func writeChain(do *definitions.Do, genesis *Genesis, accounts []*account, consensus ConsensusParams) error {
+	dir := filepath.Join(config.ChainsPath, do.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeJSON(filepath.Join(dir, "genesis.json"), genesis, 0644); err != nil {
+		return err
+	}
+	if err := writeCSVs(dir, accounts); err != nil {
+		return err
+	}
+
+	validators := []*account{}
+	for _, a := range accounts {
+		if a.Type.Validator() {
+			validators = append(validators, a)
+		}
+
+		accountDir := filepath.Join(dir, a.Name)
+		if err := writeNode(accountDir, do.Name, a, genesis, consensus); err != nil {
+			return err
+		}
+
+		switch {
+		case do.Tarball:
+			err := packDir(accountDir, accountDir+".tar.gz", writeTarball)
+			if err != nil {
+				return err
+			}
+		case do.ZipFile:
+			err := packDir(accountDir, accountDir+".zip", writeZip)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(validators) == 1 {
+		a := validators[0]
+		if err := writeConfig(filepath.Join(dir, "config.toml"), nodeConfig{Chain: do.Name, Moniker: a.Name, Consensus: consensus}); err != nil {
+			return err
+		}
+		if err := writeJSON(filepath.Join(dir, "priv_validator.json"), a.Key, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNode writes the files to run a node of the account.
+func writeNode(dir, chain string, a *account, genesis *Genesis, consensus ConsensusParams) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, "genesis.json"), genesis, 0644); err != nil {
+		return err
+	}
+	if err := writeConfig(filepath.Join(dir, "config.toml"), nodeConfig{Chain: chain, Moniker: a.Name, Consensus: consensus}); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, "priv_validator.json"), a.Key, 0600)
+}
+
+// writeCSVs writes accounts.csv and validators.csv files (usable with
+// [eris chains make --known]) and addresses.csv with account addresses.
+func writeCSVs(dir string, accounts []*account) error {
+	var accts, vals, addrs [][]string
+	for _, a := range accounts {
+		perms := a.Type.Permissions()
+		row := func(amount int64) []string {
+			return []string{
+				string(a.Key.PubKey),
+				strconv.FormatInt(amount, 10),
+				a.Name,
+				strconv.FormatUint(perms.Perms, 10),
+				strconv.FormatUint(perms.SetBit, 10),
+			}
+		}
+
+		accts = append(accts, row(a.Type.DefaultTokens))
+		if a.Type.Validator() {
+			vals = append(vals, row(a.Type.DefaultBond))
+		}
+		addrs = append(addrs, []string{a.Key.Address, a.Name})
+	}
+
+	for file, records := range map[string][][]string{
+		"accounts.csv":   accts,
+		"validators.csv": vals,
+		"addresses.csv":  addrs,
+	} {
+		buf := new(bytes.Buffer)
+		w := csv.NewWriter(buf)
+		w.WriteAll(records)
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, file), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(file string, v interface{}, perm os.FileMode) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(content, '\n'), perm)
+}
+
+var configTemplate = template.Must(template.New("config").Parse(`# This is a TOML config file.
+# For more information, see https://github.com/toml-lang/toml
+
+[chain]
+assert_chain_id = "{{.Chain}}"
+major_version = 0
+minor_version = 12
+genesis_file = "genesis.json"
+
+  [chain.consensus]
+  name = "tendermint"
+  major_version = 0
+  minor_version = 6
+
+  [chain.manager]
+  name = "erismint"
+  major_version = 0
+  minor_version = 12
+
+[servers]
+
+  [servers.bind]
+  address = ""
+  port = 1337
+
+  [servers.tls]
+  tls = false
+  cert_path = ""
+  key_path = ""
+
+  [servers.cors]
+  enable = false
+  allow_origins = []
+  allow_credentials = false
+  allow_methods = []
+  allow_headers = []
+  expose_headers = []
+  max_age = 0
+
+  [servers.http]
+  json_rpc_endpoint = "/rpc"
+
+  [servers.websocket]
+  endpoint = "/socketrpc"
+  max_sessions = 50
+  read_buffer_size = 4096
+  write_buffer_size = 4096
+
+  [servers.tendermint]
+  rpc_local_address = "0.0.0.0:46657"
+  endpoint = "/websocket"
+
+[tendermint]
+private_validator_file = "priv_validator.json"
+
+  [tendermint.configuration]
+  moniker = "{{.Moniker}}"
+  seeds = "{{.Seeds}}"
+  fast_sync = false
+  db_backend = "leveldb"
+  log_level = "info"
+  node_laddr = "0.0.0.0:46656"
+  rpc_laddr = "0.0.0.0:46657"
+  proxy_app = "tcp://127.0.0.1:46658"
+{{- with .Consensus}}
+  {{- if .TimeoutPropose}}
+  timeout_propose = {{.TimeoutPropose}}
+  {{- end}}
+  {{- if .TimeoutCommit}}
+  timeout_commit = {{.TimeoutCommit}}
+  {{- end}}
+  {{- if .BlockSize}}
+  block_size = {{.BlockSize}}
+  {{- end}}
+{{- end}}
+
+[erismint]
+db_backend = "leveldb"
+tendermint_host = "0.0.0.0:46657"
+`))
+
+// nodeConfig are the config.toml template values.
+type nodeConfig struct {
+	Chain     string // chain ID
+	Moniker   string
+	Seeds     string // comma separated list of HOST:PORT peers
+	Consensus ConsensusParams
+}
+
+// writeConfig writes the config.toml file of the node.
+func writeConfig(file string, node nodeConfig) error {
+	buf := new(bytes.Buffer)
+	if err := configTemplate.Execute(buf, node); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// packDir packs the files of the dir directory into the archive file
+// and removes the directory.
+func packDir(dir, archive string, pack func(w io.Writer, dir string, files []os.FileInfo) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(archive, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := pack(f, dir, files); err != nil {
+		f.Close()
+		return fmt.Errorf("Cannot pack %s: %v", archive, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func writeTarball(w io.Writer, dir string, files []os.FileInfo) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(file, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(filepath.Base(dir), file.Name())
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, dir string, files []os.FileInfo) error {
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(file)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(filepath.Base(dir), file.Name())
+		header.Method = zip.Deflate
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func printAccounts(w io.Writer, accounts []*account) {
+	tw := tabwriter.NewWriter(w, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tADDRESS\tTOKENS\tBOND")
+	for _, a := range accounts {
+		var bond int64
+		if a.Type.Validator() {
+			bond = a.Type.DefaultBond
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", a.Name, a.Key.Address, a.Type.DefaultTokens, bond)
+	}
+	tw.Flush()
+}
+
+// makeKnownChain writes the genesis.json file of the chain
+// from accounts and validators CSV files.
+func makeKnownChain(do *definitions.Do) error {
+	genesis := &Genesis{
+		ChainID:    do.Name,
+		Accounts:   []*GenesisAccount{},
+		Validators: []*GenesisValidator{},
+	}
+
+	for _, file := range splitList(do.ChainMakeActs) {
+		if err := readKnown(file, func(r *knownRecord) error {
+			address := r.Key
+			if len(address) != 40 {
+				var err error
+				if address, err = PubKeyAddress(r.Key); err != nil {
+					return err
+				}
+			}
+			genesis.Accounts = append(genesis.Accounts, &GenesisAccount{
+				Address: address,
+				Amount:  r.Amount,
+				Name:    r.Name,
+				Permissions: &AccountPermissions{
+					Base:  r.Perms,
+					Roles: []string{},
+				},
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range splitList(do.ChainMakeVals) {
+		if err := readKnown(file, func(r *knownRecord) error {
+			address, err := PubKeyAddress(r.Key)
+			if err != nil {
+				return fmt.Errorf("%v (validators need public keys)", err)
+			}
+			genesis.Validators = append(genesis.Validators, &GenesisValidator{
+				PubKey:   Key(r.Key),
+				Amount:   r.Amount,
+				Name:     r.Name,
+				UnbondTo: []*UnbondTo{{address, r.Amount}},
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(genesis.Validators) == 0 {
+		return fmt.Errorf("Chain %s has no validators. Check the --validators files", do.Name)
+	}
+
+	dir := filepath.Join(config.ChainsPath, do.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, "genesis.json"), genesis, 0644)
+}
+
+// knownRecord is a line of the accounts or validators CSV file
+// in the PUBKEY,AMOUNT,NAME,PERMS,SETBIT format (accounts can also
+// be given by an address instead of a public key). NAME, PERMS, and
+// SETBIT are optional; permissions default to all permissions.
+type knownRecord struct {
+	Key    string
+	Amount int64
+	Name   string
+	Perms  BasePermissions
+}
+
+// readKnown reads the CSV file and calls fn with every record.
+// Errors are reported with the file name and line number.
+func readKnown(file string, fn func(r *knownRecord) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	all := (uint64(1) << uint(len(Permissions))) - 1
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		fields, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		record, err := parseKnownRecord(fields, all)
+		if err == nil {
+			err = fn(record)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", file, line, err)
+		}
+	}
+}
+
+func parseKnownRecord(fields []string, all uint64) (*knownRecord, error) {
+	if len(fields) < 2 || len(fields) > 5 {
+		return nil, fmt.Errorf("Expected PUBKEY,AMOUNT[,NAME[,PERMS,SETBIT]], got %d fields", len(fields))
+	}
+
+	record := &knownRecord{
+		Key:   strings.ToUpper(strings.TrimSpace(fields[0])),
+		Perms: BasePermissions{all, all},
+	}
+	if _, err := hex.DecodeString(record.Key); err != nil || (len(record.Key) != 40 && len(record.Key) != 64) {
+		return nil, fmt.Errorf("Bad public key or address %q", fields[0])
+	}
+
+	var err error
+	if record.Amount, err = strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64); err != nil || record.Amount < 0 {
+		return nil, fmt.Errorf("Bad amount %q", fields[1])
+	}
+	if len(fields) > 2 {
+		record.Name = strings.TrimSpace(fields[2])
+	}
+	if len(fields) > 3 {
+		if record.Perms.Perms, err = strconv.ParseUint(strings.TrimSpace(fields[3]), 10, 64); err != nil || record.Perms.Perms > all {
+			return nil, fmt.Errorf("Bad permissions %q", fields[3])
+		}
+	}
+	if len(fields) > 4 {
+		if record.Perms.SetBit, err = strconv.ParseUint(strings.TrimSpace(fields[4]), 10, 64); err != nil || record.Perms.SetBit > all {
+			return nil, fmt.Errorf("Bad permissions set bit %q", fields[4])
+		}
+	}
+	return record, nil
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// makeChainWizard runs the interactive `eris-cm make`
+// wizard in a Docker container.
+func makeChainWizard(do *definitions.Do) error {
 	doKeys := definitions.NowDo()
 	doKeys.Name = "keys"
 	if err := services.EnsureRunning(doKeys); err != nil {
 		return err
 	}
 
+	log.Debug("Using [eris-cm]")
 	do.Service.Name = do.Name
 	do.Service.Image = path.Join(config.Global.DefaultRegistry, config.Global.ImageCM)
 	do.Service.User = "eris"
@@ -48,39 +716,24 @@ func MakeChain(do *definitions.Do) error {
 	do.Service.DNS = []string{"8.8.8.8", "8.8.4.4"}
 	do.Service.Environment = []string{
 		fmt.Sprintf("ERIS_KEYS_PATH=http://keys:%d", 4767), // note, needs to be made aware of keys port...
-		fmt.Sprintf("ERIS_CHAINMANAGER_ACCOUNTTYPES=%s", strings.Join(do.AccountTypes, ",")),
-		fmt.Sprintf("ERIS_CHAINMANAGER_CHAINTYPE=%s", do.ChainType),
 		fmt.Sprintf("ERIS_CHAINMANAGER_TARBALLS=%v", do.Tarball),
 		fmt.Sprintf("ERIS_CHAINMANAGER_ZIPFILES=%v", do.ZipFile),
 		fmt.Sprintf("ERIS_CHAINMANAGER_OUTPUT=%v", do.Output),
 		fmt.Sprintf("ERIS_CHAINMANAGER_VERBOSE=%v", do.Verbose),
 		fmt.Sprintf("ERIS_CHAINMANAGER_DEBUG=%v", do.Debug),
 	}
+	do.Service.EntryPoint = fmt.Sprintf("eris-cm make %s", do.Name)
 
 	do.Operations.ContainerType = definitions.TypeService
 	do.Operations.SrvContainerName = util.ServiceContainerName(do.Name)
 	do.Operations.DataContainerName = util.DataContainerName(do.Name)
 	do.Operations.Labels = util.Labels(do.Name, do.Operations)
+	do.Operations.Interactive = true
+	do.Operations.Args = strings.Split(do.Service.EntryPoint, " ")
 	if do.RmD {
 		do.Operations.Remove = true
 	}
 
-	if do.Known {
-		log.Debug("Using [mintgen]")
-		do.Service.EntryPoint = "mintgen"
-		do.Service.Command = fmt.Sprintf("known %s --csv=%s,%s", do.Name, do.ChainMakeVals, do.ChainMakeActs)
-		do.Operations.Args = append(do.Operations.Args, strings.Split(do.Service.Command, " ")...)
-		do.Service.WorkDir = path.Join(config.ErisContainerRoot, "chains", do.Name)
-	} else {
-		log.Debug("Using [eris-cm]")
-		do.Service.EntryPoint = fmt.Sprintf("eris-cm make %s", do.Name)
-	}
-
-	if do.Wizard && len(do.AccountTypes) == 0 && do.ChainType == "" {
-		do.Operations.Interactive = true
-		do.Operations.Args = strings.Split(do.Service.EntryPoint, " ")
-	}
-
 	doData := definitions.NowDo()
 	doData.Name = do.Name
 
@@ -110,42 +763,22 @@ func MakeChain(do *definitions.Do) error {
 
 	buf, err := perform.DockerExecService(do.Service, do.Operations)
 	if err != nil {
-		// Log to both screen and logs for further analysis in Bugsnag.
 		if buf != nil {
 			log.Debug("Dumping output")
 			log.Error(buf.String())
 		}
-
-		// After all the imports are in place, [eris-cm] should not fail,
-		// so it is worth investigating why it still failed.
-		util.SendReport("`eris chains make` failed")
-
 		return err
 	}
-
-	if do.Known {
-		if err := ioutil.WriteFile(filepath.Join(config.ErisRoot, "chains", do.Name, "genesis.json"), buf.Bytes(), 0644); err != nil {
-			return err
-		}
-	}
-
-	// TODO(pv): remove writing to Global.Writer after the [eris-cm]
-	// command line handling is fixed. This is necessary now to
-	// capture [eris-cm] errors which return exit code 0.
 	io.Copy(config.Global.Writer, buf)
 
-	// Nothing to export to host when running with [--known].
-	if !do.Known {
-		doData.Source = path.Join(config.ErisContainerRoot, "chains")
-		doData.Destination = config.ErisRoot
-		if err := data.ExportData(doData); err != nil {
-			return fmt.Errorf("Cannot copy chain directory back to host: %v", err)
-		}
+	doData.Source = path.Join(config.ErisContainerRoot, "chains")
+	doData.Destination = config.ErisRoot
+	if err := data.ExportData(doData); err != nil {
+		return fmt.Errorf("Cannot copy chain directory back to host: %v", err)
 	}
 
 	if !do.RmD {
 		return data.RmData(doData)
 	}
-
 	return nil
 }
@@ -0,0 +1,215 @@
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+const testPubKey = "CB3688B7561D488A2A4834E1AEE9398BEF94844D8BDBBCA980C11E3654A45906"
+
+func TestParseKeyFile(t *testing.T) {
+	priv := strings.Repeat("AB", 32) + testPubKey
+	key, err := parseKeyFile([]byte(`{"Address": "1a2b3c4d5e6f1a2b3c4d5e6f1a2b3c4d5e6f1a2b", "PrivateKey": "` + priv + `"}`))
+	if err != nil {
+		t.Fatalf("expected key parsed, got %v", err)
+	}
+	if key.Address != "1A2B3C4D5E6F1A2B3C4D5E6F1A2B3C4D5E6F1A2B" || string(key.PubKey) != testPubKey || string(key.PrivKey) != priv {
+		t.Fatalf("expected key converted, got %v", key)
+	}
+
+	if _, err := parseKeyFile([]byte(`{"Address": "1A2B", "PrivateKey": "ABCD"}`)); err == nil {
+		t.Fatalf("expected short key to fail")
+	}
+}
+
+func TestMakeAccountsGenesis(t *testing.T) {
+	defer stubKeys()()
+
+	chainType, err := LoadChainType("adminchain")
+	if err != nil {
+		t.Fatalf("expected chain type loaded, got %v", err)
+	}
+	counts, err := chainType.Accounts()
+	if err != nil {
+		t.Fatalf("expected accounts, got %v", err)
+	}
+	accounts, err := makeAccounts("marmot", counts)
+	if err != nil {
+		t.Fatalf("expected accounts made, got %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].Name != "marmot_full_000" || accounts[1].Name != "marmot_root_000" {
+		t.Fatalf("expected full and root accounts, got %v, %v", accounts[0].Name, accounts[1].Name)
+	}
+
+	genesis, err := accountsGenesis("marmot", accounts)
+	if err != nil {
+		t.Fatalf("expected genesis, got %v", err)
+	}
+	if genesis.ChainID != "marmot" || len(genesis.Accounts) != 2 || len(genesis.Validators) != 1 {
+		t.Fatalf("expected 2 accounts and 1 validator, got %v, %v", len(genesis.Accounts), len(genesis.Validators))
+	}
+	if v := genesis.Validators[0]; v.PubKey != accounts[0].Key.PubKey || v.Amount != 9999999999 || v.UnbondTo[0].Address != accounts[0].Key.Address {
+		t.Fatalf("expected full account validator, got %v", v)
+	}
+
+	accounts, _ = makeAccounts("marmot", []*AccountCount{counts[1]})
+	if _, err := accountsGenesis("marmot", accounts); err == nil {
+		t.Fatalf("expected chain without validators to fail")
+	}
+}
+
+func TestWriteChain(t *testing.T) {
+	defer stubKeys()()
+	defer tempChainsPath(t)()
+
+	counts, _ := ParseAccountTypes([]string{"Full:1", "Participant:2"})
+	accounts, _ := makeAccounts("marmot", counts)
+	genesis, _ := accountsGenesis("marmot", accounts)
+
+	do := definitions.NowDo()
+	do.Name = "marmot"
+	do.Tarball = true
+	if err := writeChain(do, genesis, accounts, ConsensusParams{TimeoutCommit: 500}); err != nil {
+		t.Fatalf("expected chain written, got %v", err)
+	}
+
+	dir := filepath.Join(config.ChainsPath, "marmot")
+	for _, file := range []string{"genesis.json", "config.toml", "priv_validator.json", "accounts.csv", "validators.csv", "addresses.csv", "marmot_full_000.tar.gz", "marmot_participant_001.tar.gz"} {
+		if !util.DoesFileExist(filepath.Join(dir, file)) {
+			t.Fatalf("expected %s written", file)
+		}
+	}
+	if util.DoesDirExist(filepath.Join(dir, "marmot_full_000")) {
+		t.Fatalf("expected account directory packed and removed")
+	}
+
+	config, _ := ioutil.ReadFile(filepath.Join(dir, "config.toml"))
+	if !strings.Contains(string(config), `moniker = "marmot_full_000"`) || !strings.Contains(string(config), `assert_chain_id = "marmot"`) || !strings.Contains(string(config), "timeout_commit = 500") {
+		t.Fatalf("expected config with moniker and chain ID, got %s", config)
+	}
+
+	var written Genesis
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "genesis.json"))
+	if err := json.Unmarshal(content, &written); err != nil {
+		t.Fatalf("expected genesis read back, got %v", err)
+	}
+	if len(written.Accounts) != 3 || written.Validators[0].PubKey != accounts[0].Key.PubKey {
+		t.Fatalf("expected genesis written, got %s", content)
+	}
+}
+
+func TestMakeKnownChain(t *testing.T) {
+	defer tempChainsPath(t)()
+
+	address, err := PubKeyAddress(testPubKey)
+	if err != nil || len(address) != 40 {
+		t.Fatalf("expected address, got %v, %v", address, err)
+	}
+
+	accounts := filepath.Join(config.ChainsPath, "accounts.csv")
+	validators := filepath.Join(config.ChainsPath, "validators.csv")
+	ioutil.WriteFile(accounts, []byte(testPubKey+",1000,marmot_full_000\n1A2B3C4D5E6F1A2B3C4D5E6F1A2B3C4D5E6F1A2B,5,marmot_participant_000,2,2\n"), 0644)
+	ioutil.WriteFile(validators, []byte(testPubKey+",100,marmot_full_000\n"), 0644)
+
+	do := definitions.NowDo()
+	do.Name = "marmot"
+	do.Known = true
+	do.ChainMakeActs = accounts
+	do.ChainMakeVals = validators
+	if err := MakeChain(do); err != nil {
+		t.Fatalf("expected chain made, got %v", err)
+	}
+
+	var genesis Genesis
+	content, _ := ioutil.ReadFile(filepath.Join(config.ChainsPath, "marmot", "genesis.json"))
+	if err := json.Unmarshal(content, &genesis); err != nil {
+		t.Fatalf("expected genesis written, got %v", err)
+	}
+	if len(genesis.Accounts) != 2 || genesis.Accounts[0].Address != address || genesis.Accounts[0].Permissions.Base.Perms != 16383 {
+		t.Fatalf("expected account with all permissions, got %s", content)
+	}
+	if perms := genesis.Accounts[1].Permissions.Base; perms.Perms != 2 || perms.SetBit != 2 {
+		t.Fatalf("expected send permission, got %v", perms)
+	}
+	if v := genesis.Validators[0]; v.Amount != 100 || v.UnbondTo[0].Address != address {
+		t.Fatalf("expected validator unbonding to its address, got %v", v)
+	}
+
+	ioutil.WriteFile(validators, []byte(testPubKey+",100\nNOTAKEY,100\n"), 0644)
+	if err := MakeChain(do); err == nil || !strings.Contains(err.Error(), validators+":2:") {
+		t.Fatalf("expected error with file and line, got %v", err)
+	}
+}
+
+// stubKeys replaces eris-keys key generation with predictable keys.
+func stubKeys() func() {
+	n := 0
+	original := newKey
+	newKey = func() (*PrivValidator, error) {
+		n++
+		pub := fmt.Sprintf("%064X", n)
+		address, err := PubKeyAddress(pub)
+		if err != nil {
+			return nil, err
+		}
+		return &PrivValidator{
+			Address: address,
+			PubKey:  Key(pub),
+			PrivKey: Key(fmt.Sprintf("%064X", 0) + pub),
+		}, nil
+	}
+	return func() { newKey = original }
+}
+
+// tempChainsPath points config.ChainsPath to a temporary directory.
+func tempChainsPath(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "eris-chains-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	original := config.ChainsPath
+	config.ChainsPath = dir
+	return func() {
+		config.ChainsPath = original
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNodeDir(t *testing.T) {
+	defer stubKeys()()
+	defer tempChainsPath(t)()
+
+	counts, _ := ParseAccountTypes([]string{"Full:1", "Participant:2"})
+	accounts, _ := makeAccounts("marmot", counts)
+	genesis, _ := accountsGenesis("marmot", accounts)
+	do := definitions.NowDo()
+	do.Name = "marmot"
+	writeChain(do, genesis, accounts, ConsensusParams{})
+
+	if dir, err := NodeDir("marmot"); err != nil || dir != filepath.Join(config.ChainsPath, "marmot") {
+		t.Fatalf("expected the chain directory for a single validator, got %v, %v", dir, err)
+	}
+
+	counts, _ = ParseAccountTypes([]string{"Validator:2"})
+	accounts, _ = makeAccounts("beaver", counts)
+	genesis, _ = accountsGenesis("beaver", accounts)
+	do.Name = "beaver"
+	writeChain(do, genesis, accounts, ConsensusParams{})
+
+	if dir, err := NodeDir("beaver"); err != nil || dir != filepath.Join(config.ChainsPath, "beaver", "beaver_validator_000") {
+		t.Fatalf("expected the first validator directory, got %v, %v", dir, err)
+	}
+
+	if _, err := NodeDir("otter"); err == nil {
+		t.Fatalf("expected a chain not made to fail")
+	}
+}
@@ -0,0 +1,228 @@
+package chains
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// ErrInterrupted is returned by ThrowawayChain if the work is
+// interrupted by a signal.
+var ErrInterrupted = errors.New("Interrupted. The throwaway chain has been removed")
+
+// ThrowawayChain makes a single validator chain with a generated name,
+// starts it, and runs the work with the chain name and the validator
+// address (imported into eris-keys, usable to deploy contracts).
+// The chain, its data container, and its directory are removed
+// afterwards, also if the work fails or the command is interrupted
+// (in which case ErrInterrupted is returned). On interrupt, the chain
+// is only removed after the current step (making or starting the chain,
+// or the work) finishes, unless interrupted again. If the work is nil,
+// the chain runs until interrupted.
+//
+// Throwaway chain containers are labeled with definitions.LabelThrowaway
+// set to the chain name, so that leftovers can be found by [eris clean
+// --throwaway]. The work should label containers it makes the same way
+// (see ThrowawayLabels); they are removed along with the chain.
+//
+//  do.ChainType - chain type to make the chain from (defaults to simplechain)
+//
+func ThrowawayChain(do *definitions.Do, work func(name, address string) error) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := definitions.ThrowawayPrefix + hex.EncodeToString(suffix)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var once sync.Once
+	remove := func() {
+		once.Do(func() {
+			if err := removeThrowawayChain(name); err != nil {
+				log.WithField("=>", name).Errorf("Cannot remove the throwaway chain: %v. Use [eris clean --throwaway]", err)
+			}
+		})
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		address, err := startThrowawayChain(name, do)
+		if err == nil && work != nil {
+			err = work(name, address)
+		} else if err == nil {
+			fmt.Fprintf(config.Global.Writer, "%s\t%s\n", name, address)
+			log.WithField("=>", name).Warn("Throwaway chain is running. Press Ctrl-C to stop and remove it")
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil || work != nil {
+			remove()
+			return err
+		}
+		<-interrupt
+		log.WithField("=>", name).Warn("Interrupted. Removing the throwaway chain")
+		remove()
+		return nil
+	case <-interrupt:
+		// Containers made by the step in progress would
+		// be left behind if the chain was removed now.
+		log.WithField("=>", name).Warn("Interrupted. Removing the throwaway chain once the current step finishes. Press Ctrl-C again to remove it now")
+		select {
+		case <-result:
+		case <-interrupt:
+			log.WithField("=>", name).Warn("Interrupted again. Removing the throwaway chain now. Containers the current step makes may be left behind, see [eris clean --throwaway]")
+		}
+		remove()
+		if work == nil {
+			return nil
+		}
+		return ErrInterrupted
+	}
+}
+
+// ThrowawayLabels returns the labels with definitions.LabelThrowaway
+// set to the throwaway chain name, for containers made for the chain
+// run to be removed along with the chain.
+func ThrowawayLabels(labels map[string]string, name string) map[string]string {
+	return util.SetLabel(labels, definitions.LabelThrowaway, name)
+}
+
+// startThrowawayChain makes and starts the chain and returns
+// the validator address.
+func startThrowawayChain(name string, do *definitions.Do) (string, error) {
+	chainType := do.ChainType
+	if chainType == "" {
+		chainType = "simplechain"
+	}
+
+	log.WithFields(log.Fields{
+		"chain": name,
+		"type":  chainType,
+	}).Warn("Making a throwaway chain")
+
+	doMake := definitions.NowDo()
+	doMake.Name = name
+	doMake.ChainType = chainType
+	if err := MakeChain(doMake); err != nil {
+		return "", fmt.Errorf("Cannot make the throwaway chain: %v", err)
+	}
+
+	dir, err := validatorDir(name)
+	if err != nil {
+		return "", err
+	}
+	address, err := validatorAddress(dir)
+	if err != nil {
+		return "", err
+	}
+
+	doStart := definitions.NowDo()
+	doStart.Name = name
+	doStart.Path = dir
+	doStart.Operations.PublishAllPorts = true
+	doStart.Operations.Labels = ThrowawayLabels(nil, name)
+	if err := StartChain(doStart); err != nil {
+		return "", fmt.Errorf("Cannot start the throwaway chain: %v", err)
+	}
+	return address, nil
+}
+
+// removeThrowawayChain removes the chain and data containers of the
+// chain, its directory on the host, and other containers labeled with
+// the chain name (see ThrowawayLabels).
+func removeThrowawayChain(name string) error {
+	log.WithField("=>", name).Warn("Removing the throwaway chain")
+
+	do := definitions.NowDo()
+	do.Name = name
+	do.RmD, do.RmHF, do.Force, do.Volumes = true, true, true, true
+	if err := RemoveChain(do); err != nil {
+		// The chain directory is removed even if the containers are not.
+		os.RemoveAll(filepath.Join(config.ChainsPath, name))
+		return err
+	}
+
+	// Not only Eris containers: the Eris PM container isn't labeled as one.
+	containers, err := util.DockerClient.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return util.DockerError(err)
+	}
+	for _, container := range containers {
+		if container.Labels[definitions.LabelThrowaway] != name {
+			continue
+		}
+		log.WithField("=>", container.Names).Info("Removing container")
+		if err := util.DockerClient.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            container.ID,
+			RemoveVolumes: true,
+			Force:         true,
+		}); err != nil {
+			return util.DockerError(err)
+		}
+	}
+	return nil
+}
+
+// validatorDir returns the directory of the first validator [eris chains
+// make] created for the chain: the chain's root directory if the files
+// are there, or else the first NAME_*_000 subdirectory with the
+// chain config.
+func validatorDir(name string) (string, error) {
+	root := filepath.Join(config.ChainsPath, name)
+	if util.DoesFileExist(filepath.Join(root, "config.toml")) && util.DoesFileExist(filepath.Join(root, "priv_validator.json")) {
+		return root, nil
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(root, name+"_*_000"))
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		if util.DoesFileExist(filepath.Join(dir, "config.toml")) {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("Cannot find the validator directory of the chain in %s", root)
+}
+
+// validatorAddress reads the validator address from the
+// priv_validator.json file in the dir directory.
+func validatorAddress(dir string) (string, error) {
+	file := filepath.Join(dir, "priv_validator.json")
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("Cannot read the chain validator key: %v", err)
+	}
+
+	var validator struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(content, &validator); err != nil {
+		return "", fmt.Errorf("Cannot read the chain validator key %s: %v", file, err)
+	}
+	if validator.Address == "" {
+		return "", fmt.Errorf("No validator address found in %s", file)
+	}
+	return validator.Address, nil
+}
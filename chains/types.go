@@ -0,0 +1,891 @@
+package chains
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Permissions lists account permission names in the order
+// of their genesis.json permission bits (root is 1, send is 2, etc.).
+var Permissions = []string{
+	"root",
+	"send",
+	"call",
+	"create_contract",
+	"create_account",
+	"bond",
+	"name",
+	"has_base",
+	"set_base",
+	"unset_base",
+	"set_global",
+	"has_role",
+	"add_role",
+	"rm_role",
+}
+
+// AccountType is an account-types definition file
+// (in the config.AccountsTypePath directory).
+type AccountType struct {
+	Name          string         `toml:"name"`
+	Description   string         `toml:"description"`
+	TypicalUser   string         `toml:"typical_user"`
+	DefaultNumber int            `toml:"default_number"`
+	DefaultTokens int64          `toml:"default_tokens"`
+	DefaultBond   int64          `toml:"default_bond"`
+	Perms         map[string]int `toml:"perms"`
+}
+
+// Validator returns true if accounts of the type are validators.
+func (t *AccountType) Validator() bool {
+	return t.DefaultBond > 0
+}
+
+// Permissions returns the base permissions of accounts of the type: every
+// listed permission is set, and granted if its value is 1.
+func (t *AccountType) Permissions() BasePermissions {
+	var base BasePermissions
+	for i, name := range Permissions {
+		value, ok := t.Perms[name]
+		if !ok {
+			continue
+		}
+		base.SetBit |= 1 << uint(i)
+		if value == 1 {
+			base.Perms |= 1 << uint(i)
+		}
+	}
+	return base
+}
+
+// Validate checks the account type fields.
+func (t *AccountType) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("Account type has no name")
+	}
+	if t.DefaultNumber < 0 || t.DefaultTokens < 0 || t.DefaultBond < 0 {
+		return fmt.Errorf("Account type %s: default_number, default_tokens, and default_bond cannot be negative", t.Name)
+	}
+	for name, value := range t.Perms {
+		if permissionBit(name) == 0 {
+			return fmt.Errorf("Account type %s: unknown permission %q", t.Name, name)
+		}
+		if value != 0 && value != 1 {
+			return fmt.Errorf("Account type %s: permission %s must be 0 or 1, not %d", t.Name, name, value)
+		}
+	}
+	if t.Validator() && t.Perms["bond"] != 1 {
+		return fmt.Errorf("Account type %s: validators (default_bond above 0) need the bond permission", t.Name)
+	}
+	return nil
+}
+
+// ChainType is a chain-types definition file
+// (in the config.ChainTypePath directory).
+type ChainType struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+
+	// Number of accounts of each account type.
+	AccountTypes map[string]int `toml:"account_types"`
+
+	Consensus ConsensusParams `toml:"consensus"`
+}
+
+// ConsensusParams are Tendermint consensus parameters written into
+// config.toml files of the chain type nodes. Zero values leave
+// Tendermint defaults in place.
+type ConsensusParams struct {
+	TimeoutPropose int `toml:"timeout_propose"` // milliseconds
+	TimeoutCommit  int `toml:"timeout_commit"`  // milliseconds
+	BlockSize      int `toml:"block_size"`      // transactions per block
+}
+
+// Validate checks the chain type fields and its account types.
+func (t *ChainType) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("Chain type has no name")
+	}
+	if len(t.AccountTypes) == 0 {
+		return fmt.Errorf("Chain type %s has no account types", t.Name)
+	}
+	for name, number := range t.AccountTypes {
+		if number < 0 {
+			return fmt.Errorf("Chain type %s: number of %s accounts cannot be negative", t.Name, name)
+		}
+		if _, err := LoadAccountType(name); err != nil {
+			return fmt.Errorf("Chain type %s: %v", t.Name, err)
+		}
+	}
+	if c := t.Consensus; c.TimeoutPropose < 0 || c.TimeoutCommit < 0 || c.BlockSize < 0 {
+		return fmt.Errorf("Chain type %s: consensus timeout_propose, timeout_commit, and block_size cannot be negative", t.Name)
+	}
+	return nil
+}
+
+// AccountCount is a number of accounts of the account type to make.
+type AccountCount struct {
+	Type   *AccountType
+	Number int
+}
+
+// Accounts returns the numbers of accounts to make
+// for the chain type, ordered by account type names.
+func (t *ChainType) Accounts() ([]*AccountCount, error) {
+	names := []string{}
+	for name := range t.AccountTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := []*AccountCount{}
+	for _, name := range names {
+		accountType, err := LoadAccountType(name)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &AccountCount{accountType, t.AccountTypes[name]})
+	}
+	return counts, nil
+}
+
+// LoadAccountType reads the account type from the NAME.toml file in the
+// config.AccountsTypePath directory or, if there's no such file, returns
+// the default account type of that name. As with the defaults, the name
+// is case insensitive and can be given in plural (e.g. Participants).
+func LoadAccountType(name string) (*AccountType, error) {
+	file, err := typeFile(config.AccountsTypePath, name)
+	if err != nil {
+		return nil, err
+	}
+	if !util.DoesFileExist(file) && len(name) > 1 && strings.HasSuffix(strings.ToLower(name), "s") {
+		if singular, err := typeFile(config.AccountsTypePath, name[:len(name)-1]); err == nil && util.DoesFileExist(singular) {
+			file = singular
+		}
+	}
+	accountType := new(AccountType)
+	if err := loadType(file, accountType); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		def, ok := defaultAccountType(name)
+		if !ok {
+			return nil, fmt.Errorf("Unknown account type %q. See %s", name, util.Tilde(config.AccountsTypePath))
+		}
+		*accountType = *def
+		accountType.Perms = make(map[string]int)
+		for name, value := range def.Perms {
+			accountType.Perms[name] = value
+		}
+	}
+	return accountType, accountType.Validate()
+}
+
+// LoadChainType reads the chain type from the NAME.toml file in the
+// config.ChainTypePath directory or, if there's no such file, returns
+// the default chain type of that name. The name is case insensitive.
+func LoadChainType(name string) (*ChainType, error) {
+	file, err := typeFile(config.ChainTypePath, name)
+	if err != nil {
+		return nil, err
+	}
+	chainType := new(ChainType)
+	if err := loadType(file, chainType); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		def, ok := defaultChainTypes[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown chain type %q. See %s", name, util.Tilde(config.ChainTypePath))
+		}
+		*chainType = *def
+		chainType.AccountTypes = make(map[string]int)
+		for name, number := range def.AccountTypes {
+			chainType.AccountTypes[name] = number
+		}
+	}
+	return chainType, chainType.Validate()
+}
+
+// ParseAccountTypes reads account types and numbers given in the
+// TYPE:NUMBER format (e.g. Root:1). The default number of accounts
+// of the type is used if the number is omitted.
+func ParseAccountTypes(args []string) ([]*AccountCount, error) {
+	counts := []*AccountCount{}
+	for _, arg := range args {
+		name, number := arg, ""
+		if i := strings.Index(arg, ":"); i >= 0 {
+			name, number = arg[:i], arg[i+1:]
+		}
+
+		accountType, err := LoadAccountType(name)
+		if err != nil {
+			return nil, err
+		}
+
+		count := &AccountCount{accountType, accountType.DefaultNumber}
+		if number != "" {
+			if count.Number, err = strconv.Atoi(number); err != nil || count.Number < 0 {
+				return nil, fmt.Errorf("Bad number of %s accounts %q", name, number)
+			}
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// defaultAccountType looks up the default account type by its name
+// (case insensitive, also in plural, e.g. Participants).
+func defaultAccountType(name string) (*AccountType, bool) {
+	name = strings.ToLower(name)
+	if def, ok := defaultAccountTypes[name]; ok {
+		return def, true
+	}
+	def, ok := defaultAccountTypes[strings.TrimSuffix(name, "s")]
+	return def, ok
+}
+
+func loadType(file string, v interface{}) error {
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+	if _, err := toml.DecodeFile(file, v); err != nil {
+		return fmt.Errorf("Cannot read %s: %v", file, err)
+	}
+	return nil
+}
+
+// permissionBit returns the genesis.json permission bit
+// of the permission or 0 if the permission is unknown.
+func permissionBit(name string) uint64 {
+	for i, permission := range Permissions {
+		if permission == name {
+			return 1 << uint(i)
+		}
+	}
+	return 0
+}
+
+// allPermissions grants every permission.
+func allPermissions() map[string]int {
+	perms := make(map[string]int)
+	for _, name := range Permissions {
+		perms[name] = 1
+	}
+	return perms
+}
+
+// permissions grants the listed permissions and denies the rest.
+func permissions(granted ...string) map[string]int {
+	perms := make(map[string]int)
+	for _, name := range Permissions {
+		perms[name] = 0
+	}
+	for _, name := range granted {
+		perms[name] = 1
+	}
+	return perms
+}
+
+// Account and chain types used if there are no definition files
+// of the same name (the ones [eris-cm] used to bring along).
+var (
+	defaultAccountTypes = map[string]*AccountType{
+		"root": {
+			Name:          "Root",
+			Description:   "Root users have every permission on the chain and no bonded tokens.",
+			TypicalUser:   "chain administrators",
+			DefaultNumber: 1,
+			DefaultTokens: 9999999999,
+			Perms:         allPermissions(),
+		},
+		"developer": {
+			Name:          "Developer",
+			Description:   "Developers can send tokens, call and create contracts, and register names.",
+			TypicalUser:   "smart contract developers",
+			DefaultNumber: 1,
+			DefaultTokens: 9999999999,
+			Perms:         permissions("send", "call", "create_contract", "create_account", "name", "has_base", "has_role"),
+		},
+		"participant": {
+			Name:          "Participant",
+			Description:   "Participants can send tokens, call contracts, and register names.",
+			TypicalUser:   "application users",
+			DefaultNumber: 1,
+			DefaultTokens: 9999999999,
+			Perms:         permissions("send", "call", "name", "has_base", "has_role"),
+		},
+		"validator": {
+			Name:          "Validator",
+			Description:   "Validators can only bond and validate the chain.",
+			TypicalUser:   "validating nodes",
+			DefaultNumber: 1,
+			DefaultTokens: 9999999999,
+			DefaultBond:   9999999998,
+			Perms:         permissions("bond"),
+		},
+		"full": {
+			Name:          "Full",
+			Description:   "Full accounts have every permission and validate the chain.",
+			TypicalUser:   "single node development chains",
+			DefaultNumber: 1,
+			DefaultTokens: 99999999999999,
+			DefaultBond:   9999999999,
+			Perms:         allPermissions(),
+		},
+	}
+
+	defaultChainTypes = map[string]*ChainType{
+		"simplechain": {
+			Name:         "simplechain",
+			Description:  "A single node chain with one full account.",
+			AccountTypes: map[string]int{"Full": 1},
+		},
+		"adminchain": {
+			Name:         "adminchain",
+			Description:  "A single node chain with one full account and a root account.",
+			AccountTypes: map[string]int{"Full": 1, "Root": 1},
+		},
+		"demochain": {
+			Name:         "demochain",
+			Description:  "A demonstration chain with one full account, developers, and participants.",
+			AccountTypes: map[string]int{"Full": 1, "Developer": 5, "Participant": 20},
+		},
+		"sprawlchain": {
+			Name:         "sprawlchain",
+			Description:  "A chain of seven validators with root, developer, and participant accounts.",
+			AccountTypes: map[string]int{"Validator": 7, "Root": 1, "Developer": 5, "Participant": 25},
+		},
+	}
+)
+
+// ListAccountTypes writes a table of the account types defined in the
+// config.AccountsTypePath directory and the default ones to
+// config.Global.Writer. Invalid account type files are skipped with
+// a warning.
+//
+//  do.Quiet - list names only (optional)
+//
+func ListAccountTypes(do *definitions.Do) error {
+	types := allAccountTypes()
+
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	if !do.Quiet {
+		fmt.Fprintln(tw, "NAME\tTOKENS\tBOND\tPERMISSIONS\tDEFINITION")
+	}
+	for _, t := range types {
+		if do.Quiet {
+			fmt.Fprintln(tw, t.Name)
+			continue
+		}
+		bond := "-"
+		if t.Validator() {
+			bond = strconv.FormatInt(t.DefaultBond, 10)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", t.Name, t.DefaultTokens, bond, grantedPermissions(t), typeSource(config.AccountsTypePath, t.Name))
+	}
+	return tw.Flush()
+}
+
+// ListChainTypes writes a table of the chain types defined in the
+// config.ChainTypePath directory and the default ones to
+// config.Global.Writer. Invalid chain type files are skipped with
+// a warning.
+//
+//  do.Quiet - list names only (optional)
+//
+func ListChainTypes(do *definitions.Do) error {
+	types := allChainTypes()
+
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	if !do.Quiet {
+		fmt.Fprintln(tw, "NAME\tACCOUNT TYPES\tDEFINITION")
+	}
+	for _, t := range types {
+		if do.Quiet {
+			fmt.Fprintln(tw, t.Name)
+			continue
+		}
+		counts, err := t.Accounts()
+		if err != nil {
+			return err
+		}
+		accounts := []string{}
+		for _, count := range counts {
+			accounts = append(accounts, fmt.Sprintf("%s:%d", count.Type.Name, count.Number))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, strings.Join(accounts, ","), typeSource(config.ChainTypePath, t.Name))
+	}
+	return tw.Flush()
+}
+
+// allAccountTypes loads the account types, skipping invalid ones.
+func allAccountTypes() []*AccountType {
+	defaults := []string{}
+	for name := range defaultAccountTypes {
+		defaults = append(defaults, name)
+	}
+
+	types := []*AccountType{}
+	for _, name := range typeNames(config.AccountsTypePath, defaults) {
+		accountType, err := LoadAccountType(name)
+		if err != nil {
+			log.WithField("=>", name).Warnf("Skipping the account type: %v", err)
+			continue
+		}
+		types = append(types, accountType)
+	}
+	return types
+}
+
+// allChainTypes loads the chain types, skipping invalid ones.
+func allChainTypes() []*ChainType {
+	defaults := []string{}
+	for name := range defaultChainTypes {
+		defaults = append(defaults, name)
+	}
+
+	types := []*ChainType{}
+	for _, name := range typeNames(config.ChainTypePath, defaults) {
+		chainType, err := LoadChainType(name)
+		if err != nil {
+			log.WithField("=>", name).Warnf("Skipping the chain type: %v", err)
+			continue
+		}
+		types = append(types, chainType)
+	}
+	return types
+}
+
+// ShowAccountType writes the account type definition
+// to config.Global.Writer.
+//
+//  do.Name - account type name (required)
+//
+func ShowAccountType(do *definitions.Do) error {
+	accountType, err := LoadAccountType(do.Name)
+	if err != nil {
+		return err
+	}
+	return toml.NewEncoder(config.Global.Writer).Encode(accountType)
+}
+
+// ShowChainType writes the chain type definition followed by the
+// accounts it expands to to config.Global.Writer. If do.AccountTypes
+// are given instead of the name, only their expansion is written.
+//
+//  do.Name         - chain type name (optional)
+//  do.AccountTypes - account types and numbers of accounts (example: Root:1,Participant:25) (optional)
+//
+func ShowChainType(do *definitions.Do) error {
+	var (
+		counts []*AccountCount
+		err    error
+	)
+	if do.Name != "" {
+		chainType, err := LoadChainType(do.Name)
+		if err != nil {
+			return err
+		}
+		if err := toml.NewEncoder(config.Global.Writer).Encode(chainType); err != nil {
+			return err
+		}
+		fmt.Fprintln(config.Global.Writer)
+		if counts, err = chainType.Accounts(); err != nil {
+			return err
+		}
+	} else if counts, err = ParseAccountTypes(do.AccountTypes); err != nil {
+		return err
+	}
+
+	PrintAccountCounts(config.Global.Writer, counts)
+	return nil
+}
+
+// PrintAccountCounts writes a table of the accounts to make.
+func PrintAccountCounts(w io.Writer, counts []*AccountCount) {
+	tw := tabwriter.NewWriter(w, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT TYPE\tNUMBER\tTOKENS\tBOND\tPERMISSIONS")
+	for _, count := range counts {
+		bond := "-"
+		if count.Type.Validator() {
+			bond = strconv.FormatInt(count.Type.DefaultBond, 10)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", count.Type.Name, count.Number, count.Type.DefaultTokens, bond, grantedPermissions(count.Type))
+	}
+	tw.Flush()
+}
+
+// NewAccountType writes a NAME.toml account type file into the
+// config.AccountsTypePath directory, copied from another account type.
+//
+//  do.Name  - account type name (required)
+//  do.Type  - account type to start from (defaults to participant)
+//  do.Force - overwrite an existing file (optional)
+//
+func NewAccountType(do *definitions.Do) error {
+	from := do.Type
+	if from == "" {
+		from = "participant"
+	}
+	file, err := typeFile(config.AccountsTypePath, do.Name)
+	if err != nil {
+		return err
+	}
+	accountType, err := LoadAccountType(from)
+	if err != nil {
+		return err
+	}
+	accountType.Name = do.Name
+	accountType.Description = ""
+	accountType.TypicalUser = ""
+	return writeType(file, accountType, do.Force)
+}
+
+// NewChainType writes a NAME.toml chain type file into the
+// config.ChainTypePath directory, copied from another chain type
+// or made of the account types given.
+//
+//  do.Name         - chain type name (required)
+//  do.Type         - chain type to start from (defaults to simplechain)
+//  do.AccountTypes - account types and numbers of accounts, replacing those of do.Type (optional)
+//  do.Force        - overwrite an existing file (optional)
+//
+func NewChainType(do *definitions.Do) error {
+	from := do.Type
+	if from == "" {
+		from = "simplechain"
+	}
+	file, err := typeFile(config.ChainTypePath, do.Name)
+	if err != nil {
+		return err
+	}
+	chainType, err := LoadChainType(from)
+	if err != nil {
+		return err
+	}
+	chainType.Name = do.Name
+	chainType.Description = ""
+
+	if len(do.AccountTypes) != 0 {
+		counts, err := ParseAccountTypes(do.AccountTypes)
+		if err != nil {
+			return err
+		}
+		chainType.AccountTypes = make(map[string]int)
+		for _, count := range counts {
+			chainType.AccountTypes[count.Type.Name] = count.Number
+		}
+	}
+	return writeType(file, chainType, do.Force)
+}
+
+// EditAccountType opens the account type file in the editor
+// (making a copy of the default type first if there's no file) and
+// validates it afterwards.
+//
+//  do.Name - account type name (required)
+//
+func EditAccountType(do *definitions.Do) error {
+	file, err := typeFile(config.AccountsTypePath, do.Name)
+	if err != nil {
+		return err
+	}
+	if !util.DoesFileExist(file) {
+		accountType, err := LoadAccountType(do.Name)
+		if err != nil {
+			return err
+		}
+		if err := writeType(file, accountType, false); err != nil {
+			return err
+		}
+	}
+	if err := config.Editor(file); err != nil {
+		return err
+	}
+	if _, err := LoadAccountType(do.Name); err != nil {
+		return fmt.Errorf("%v. Use [eris chains account-types edit %s] to fix it", err, do.Name)
+	}
+	return nil
+}
+
+// EditChainType opens the chain type file in the editor
+// (making a copy of the default type first if there's no file) and
+// validates it afterwards.
+//
+//  do.Name - chain type name (required)
+//
+func EditChainType(do *definitions.Do) error {
+	file, err := typeFile(config.ChainTypePath, do.Name)
+	if err != nil {
+		return err
+	}
+	if !util.DoesFileExist(file) {
+		chainType, err := LoadChainType(do.Name)
+		if err != nil {
+			return err
+		}
+		if err := writeType(file, chainType, false); err != nil {
+			return err
+		}
+	}
+	if err := config.Editor(file); err != nil {
+		return err
+	}
+	if _, err := LoadChainType(do.Name); err != nil {
+		return fmt.Errorf("%v. Use [eris chains types edit %s] to fix it", err, do.Name)
+	}
+	return nil
+}
+
+// RmAccountType removes account type files. Default
+// account types are used again afterwards.
+//
+//  do.Operations.Args - account type names (required)
+//
+func RmAccountType(do *definitions.Do) error {
+	return rmTypes(config.AccountsTypePath, do.Operations.Args)
+}
+
+// RmChainType removes chain type files. Default
+// chain types are used again afterwards.
+//
+//  do.Operations.Args - chain type names (required)
+//
+func RmChainType(do *definitions.Do) error {
+	return rmTypes(config.ChainTypePath, do.Operations.Args)
+}
+
+// ExportAccountType posts the account type file to IPFS
+// and sets do.Result to its hash.
+//
+//  do.Name - account type name (required)
+//
+func ExportAccountType(do *definitions.Do) (err error) {
+	do.Result, err = exportType(config.AccountsTypePath, do.Name)
+	return err
+}
+
+// ExportChainType posts the chain type file to IPFS
+// and sets do.Result to its hash.
+//
+//  do.Name - chain type name (required)
+//
+func ExportChainType(do *definitions.Do) (err error) {
+	do.Result, err = exportType(config.ChainTypePath, do.Name)
+	return err
+}
+
+// ImportAccountType downloads the account type file from IPFS and, if
+// it is valid, moves it into the config.AccountsTypePath directory.
+//
+//  do.Name  - account type name (required)
+//  do.Hash  - IPFS hash of the file (required)
+//  do.Force - overwrite an existing file (optional)
+//
+func ImportAccountType(do *definitions.Do) error {
+	return importType(config.AccountsTypePath, do, func(file string) error {
+		accountType := new(AccountType)
+		if err := loadType(file, accountType); err != nil {
+			return err
+		}
+		return accountType.Validate()
+	})
+}
+
+// ImportChainType downloads the chain type file from IPFS and, if
+// it is valid, moves it into the config.ChainTypePath directory.
+//
+//  do.Name  - chain type name (required)
+//  do.Hash  - IPFS hash of the file (required)
+//  do.Force - overwrite an existing file (optional)
+//
+func ImportChainType(do *definitions.Do) error {
+	return importType(config.ChainTypePath, do, func(file string) error {
+		chainType := new(ChainType)
+		if err := loadType(file, chainType); err != nil {
+			return err
+		}
+		return chainType.Validate()
+	})
+}
+
+// typeNames returns names of type files in the dir directory
+// and of default types without a file, ordered by name.
+func typeNames(dir string, defaults []string) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".toml")
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+
+	for _, name := range defaults {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Sort(byLowerName(names))
+	return names
+}
+
+type byLowerName []string
+
+func (n byLowerName) Len() int           { return len(n) }
+func (n byLowerName) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+func (n byLowerName) Less(i, j int) bool { return strings.ToLower(n[i]) < strings.ToLower(n[j]) }
+
+// grantedPermissions returns the granted permissions
+// of the account type as a comma separated list.
+func grantedPermissions(t *AccountType) string {
+	granted := []string{}
+	for _, name := range Permissions {
+		if t.Perms[name] == 1 {
+			granted = append(granted, name)
+		}
+	}
+	switch len(granted) {
+	case 0:
+		return "none"
+	case len(Permissions):
+		return "all"
+	}
+	return strings.Join(granted, ",")
+}
+
+// typeSource returns the definition file of the type
+// or "default" for default types without a file.
+func typeSource(dir, name string) string {
+	file := filepath.Join(dir, name+".toml")
+	if util.DoesFileExist(file) {
+		return util.Tilde(file)
+	}
+	return "default"
+}
+
+// typeFile returns the NAME.toml type file in the dir directory.
+// Type names are case insensitive, as are the default type names:
+// an existing file with the name in a different case is returned
+// if there's no exact match. Type names cannot point outside of
+// the directory.
+func typeFile(dir, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, "/"+string(filepath.Separator)) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("Bad type name %q. Type names cannot contain path separators or ..", name)
+	}
+
+	file := filepath.Join(dir, name+".toml")
+	if util.DoesFileExist(file) {
+		return file, nil
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+	for _, existing := range files {
+		if strings.EqualFold(strings.TrimSuffix(filepath.Base(existing), ".toml"), name) {
+			return existing, nil
+		}
+	}
+	return file, nil
+}
+
+func writeType(file string, v interface{}, overwrite bool) error {
+	if util.DoesFileExist(file) && !overwrite {
+		return fmt.Errorf("%s already exists. Use the --force flag to overwrite it", util.Tilde(file))
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("# This is a TOML config file.\n# For more information, see https://github.com/toml-lang/toml\n\n")
+	if err := toml.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+
+	log.WithField("=>", util.Tilde(file)).Warn("Writing type definition")
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+func rmTypes(dir string, names []string) error {
+	for _, name := range names {
+		file, err := typeFile(dir, name)
+		if err != nil {
+			return err
+		}
+		if !util.DoesFileExist(file) {
+			return fmt.Errorf("There is no %s file to remove", util.Tilde(file))
+		}
+		log.WithField("file", util.Tilde(file)).Warn("Removing file")
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportType(dir, name string) (string, error) {
+	file, err := typeFile(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if !util.DoesFileExist(file) {
+		return "", fmt.Errorf("There is no %s file to export. Use the new or edit commands to make one", util.Tilde(file))
+	}
+	return util.SendToIPFS(file, "", "")
+}
+
+// importType downloads the type file into a temporary directory
+// and validates it before replacing the file in the dir directory.
+func importType(dir string, do *definitions.Do, validate func(file string) error) error {
+	file, err := typeFile(dir, do.Name)
+	if err != nil {
+		return err
+	}
+	if util.DoesFileExist(file) && !do.Force {
+		return fmt.Errorf("%s already exists. Use the --force flag to overwrite it", util.Tilde(file))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// The temporary directory is in the same directory
+	// (not matched by *.toml), so the file can be renamed.
+	tmp, err := ioutil.TempDir(dir, ".import-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := util.GetFromIPFS(do.Hash, filepath.Base(file), tmp, ""); err != nil {
+		return err
+	}
+	downloaded := filepath.Join(tmp, filepath.Base(file))
+
+	// Invalid types would only break [eris chains make] later.
+	if err := validate(downloaded); err != nil {
+		return fmt.Errorf("Imported type is not valid: %v", err)
+	}
+	if err := os.Chmod(downloaded, 0644); err != nil {
+		return err
+	}
+	return os.Rename(downloaded, file)
+}
@@ -0,0 +1,215 @@
+package chains
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+)
+
+func TestParseAccountTypes(t *testing.T) {
+	counts, err := ParseAccountTypes([]string{"Root:2", "participant"})
+	if err != nil {
+		t.Fatalf("expected account types parsed, got %v", err)
+	}
+	if len(counts) != 2 || counts[0].Type.Name != "Root" || counts[0].Number != 2 || counts[1].Type.Name != "Participant" || counts[1].Number != 1 {
+		t.Fatalf("expected Root:2 and Participant:1, got %v", counts)
+	}
+
+	for _, arg := range []string{"Marmot:1", "Root:many", "Root:-1"} {
+		if _, err := ParseAccountTypes([]string{arg}); err == nil {
+			t.Fatalf("expected %s to fail", arg)
+		}
+	}
+}
+
+func TestAccountTypePermissions(t *testing.T) {
+	accountType, err := LoadAccountType("validator")
+	if err != nil {
+		t.Fatalf("expected account type loaded, got %v", err)
+	}
+	if perms := accountType.Permissions(); perms.Perms != 1<<5 || perms.SetBit != 1<<uint(len(Permissions))-1 {
+		t.Fatalf("expected bond permission only, got %v", perms)
+	}
+	if !accountType.Validator() {
+		t.Fatalf("expected validator account type")
+	}
+
+	accountType.Perms["launch_missiles"] = 1
+	if err := accountType.Validate(); err == nil {
+		t.Fatalf("expected unknown permission to fail")
+	}
+}
+
+func TestChainTypeValidate(t *testing.T) {
+	chainType := &ChainType{Name: "marmotchain", AccountTypes: map[string]int{"Full": 1}}
+	if err := chainType.Validate(); err != nil {
+		t.Fatalf("expected valid chain type, got %v", err)
+	}
+
+	for _, bad := range []*ChainType{
+		{Name: "marmotchain"},
+		{Name: "marmotchain", AccountTypes: map[string]int{"Marmot": 1}},
+		{Name: "marmotchain", AccountTypes: map[string]int{"Full": -1}},
+		{Name: "marmotchain", AccountTypes: map[string]int{"Full": 1}, Consensus: ConsensusParams{TimeoutCommit: -1}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Fatalf("expected %v to fail", bad)
+		}
+	}
+
+	validator := &AccountType{Name: "Marmot", DefaultBond: 10, Perms: permissions("send")}
+	if err := validator.Validate(); err == nil {
+		t.Fatalf("expected validator without the bond permission to fail")
+	}
+}
+
+func TestNewChainType(t *testing.T) {
+	defer tempTypePaths(t)()
+
+	do := definitions.NowDo()
+	do.Name = "teamchain"
+	do.AccountTypes = []string{"Full:1", "Participants:3"}
+	if err := NewChainType(do); err != nil {
+		t.Fatalf("expected chain type made, got %v", err)
+	}
+	if err := NewChainType(do); err == nil {
+		t.Fatalf("expected existing chain type not to be overwritten")
+	}
+
+	chainType, err := LoadChainType("teamchain")
+	if err != nil {
+		t.Fatalf("expected chain type loaded, got %v", err)
+	}
+	if chainType.Name != "teamchain" || chainType.AccountTypes["Full"] != 1 || chainType.AccountTypes["Participant"] != 3 {
+		t.Fatalf("expected Full:1 and Participant:3, got %v", chainType.AccountTypes)
+	}
+
+	buf := new(bytes.Buffer)
+	config.Global.Writer = buf
+	defer func() { config.Global.Writer = os.Stdout }()
+	if err := ListChainTypes(definitions.NowDo()); err != nil {
+		t.Fatalf("expected chain types listed, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "teamchain") || !strings.Contains(buf.String(), "Full:1,Participant:3") || !strings.Contains(buf.String(), "simplechain") {
+		t.Fatalf("expected new and default chain types listed, got %s", buf)
+	}
+
+	do.Operations.Args = []string{"teamchain"}
+	if err := RmChainType(do); err != nil {
+		t.Fatalf("expected chain type removed, got %v", err)
+	}
+	if _, err := LoadChainType("teamchain"); err == nil {
+		t.Fatalf("expected removed chain type unknown")
+	}
+}
+
+func TestListChainTypesInvalid(t *testing.T) {
+	defer tempTypePaths(t)()
+
+	if err := os.MkdirAll(config.ChainTypePath, 0755); err != nil {
+		t.Fatalf("expected chain types directory, got %v", err)
+	}
+	bad := filepath.Join(config.ChainTypePath, "badchain.toml")
+	if err := ioutil.WriteFile(bad, []byte("name = \"badchain\"\n"), 0644); err != nil {
+		t.Fatalf("expected chain type file written, got %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	config.Global.Writer = buf
+	defer func() { config.Global.Writer = os.Stdout }()
+	if err := ListChainTypes(definitions.NowDo()); err != nil {
+		t.Fatalf("expected chain types listed, got %v", err)
+	}
+	if strings.Contains(buf.String(), "badchain") || !strings.Contains(buf.String(), "simplechain") {
+		t.Fatalf("expected the invalid chain type skipped, got %s", buf)
+	}
+}
+
+func TestTypeFile(t *testing.T) {
+	if file, err := typeFile("types", "marmotchain"); err != nil || file != filepath.Join("types", "marmotchain.toml") {
+		t.Fatalf("expected types/marmotchain.toml, got %v, %v", file, err)
+	}
+
+	for _, name := range []string{"", "../marmotchain", "marmots/chain", "..", "marmot..chain"} {
+		if _, err := typeFile("types", name); err == nil {
+			t.Fatalf("expected %q to fail", name)
+		}
+	}
+
+	do := definitions.NowDo()
+	do.Name = "../marmotchain"
+	if err := NewChainType(do); err == nil {
+		t.Fatalf("expected a chain type outside of the directory not to be made")
+	}
+}
+
+func TestLoadTypeFileCase(t *testing.T) {
+	defer tempTypePaths(t)()
+
+	for _, dir := range []string{config.AccountsTypePath, config.ChainTypePath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("expected types directory, got %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(config.AccountsTypePath, "Participant.toml"), []byte("name = \"Participant\"\ndefault_number = 7\n\n[perms]\nsend = 1\n"), 0644); err != nil {
+		t.Fatalf("expected account type file written, got %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(config.ChainTypePath, "Teamchain.toml"), []byte("name = \"Teamchain\"\n\n[account_types]\nparticipants = 2\n"), 0644); err != nil {
+		t.Fatalf("expected chain type file written, got %v", err)
+	}
+
+	for _, name := range []string{"Participant", "participant", "Participants", "PARTICIPANTS"} {
+		accountType, err := LoadAccountType(name)
+		if err != nil || accountType.DefaultNumber != 7 {
+			t.Fatalf("expected the edited account type loaded for %s, got %v, %v", name, accountType, err)
+		}
+	}
+
+	chainType, err := LoadChainType("teamchain")
+	if err != nil || chainType.Name != "Teamchain" {
+		t.Fatalf("expected the chain type loaded, got %v, %v", chainType, err)
+	}
+	counts, err := chainType.Accounts()
+	if err != nil || len(counts) != 1 || counts[0].Type.DefaultNumber != 7 || counts[0].Number != 2 {
+		t.Fatalf("expected the edited account type used, got %v, %v", counts, err)
+	}
+}
+
+func TestShowChainTypeAccountTypes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	config.Global.Writer = buf
+	defer func() { config.Global.Writer = os.Stdout }()
+
+	do := definitions.NowDo()
+	do.AccountTypes = []string{"Root:1", "Participants:25"}
+	if err := ShowChainType(do); err != nil {
+		t.Fatalf("expected account types shown, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "Root") || !strings.Contains(lines[1], "all") || !strings.HasPrefix(lines[2], "Participant") || !strings.Contains(lines[2], "25") {
+		t.Fatalf("expected Root and Participant rows, got %s", buf)
+	}
+}
+
+// tempTypePaths points config.AccountsTypePath and
+// config.ChainTypePath to temporary directories.
+func tempTypePaths(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "eris-types-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	accounts, chains := config.AccountsTypePath, config.ChainTypePath
+	config.AccountsTypePath = filepath.Join(dir, "account-types")
+	config.ChainTypePath = filepath.Join(dir, "chain-types")
+	return func() {
+		config.AccountsTypePath, config.ChainTypePath = accounts, chains
+		os.RemoveAll(dir)
+	}
+}
@@ -1,23 +1,59 @@
 package clean
 
 import (
+	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
 	"github.com/eris-ltd/eris-cli/util"
 )
 
+// Clean removes Eris containers, chain and scratch directories, and
+// images. Without filters, everything of the kinds requested is removed.
+// With filters, only containers matching all of them are removed,
+// along with their directories and the images no other container uses.
+//
+//  do.Yes        - don't ask for confirmation
+//  do.DryRun     - only show what would be removed
+//  do.Containers - remove containers
+//  do.ChnDirs    - remove chain directories
+//  do.Scratch    - remove scratch data directories
+//  do.RmD        - remove the Eris root directory (without filters only)
+//  do.Images     - remove images
+//
+// Filters:
+//
+//  do.Types     - container types (chain, service, or data)
+//  do.Name      - container short name glob pattern
+//  do.Stopped   - containers which aren't running
+//  do.OlderThan - containers created longer ago than the duration (e.g. 36h or 7d)
+//  do.Orphans   - containers which lost their owner (see util.OrphanFilter)
+//  do.Stack     - containers brought up with the stack (see [eris up])
+//
 func Clean(do *definitions.Do) error {
-	// in util so that other pkgs can import it easily
-	toClean := map[string]bool{
-		"yes":        do.Yes,
-		"all":        do.All,
-		"containers": do.Containers,
-		"chains":     do.ChnDirs,
-		"scratch":    do.Scratch,
-		"root":       do.RmD,
-		"images":     do.Images,
-	}
-	if err := util.Clean(toClean); err != nil {
+	plan, err := makePlan(do)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	if plan.empty() {
+		log.Warn("Nothing to clean")
+		return nil
+	}
+
+	if do.DryRun {
+		return plan.print(config.Global.Writer)
+	}
+
+	if !do.Yes {
+		log.Warn("The marmots are about to remove the following")
+		if err := plan.print(config.Global.Writer); err != nil {
+			return err
+		}
+		if util.QueryYesOrNo("Please confirm") != util.Yes {
+			log.Warn("Authorization not given, exiting")
+			return nil
+		}
+	}
+
+	return plan.apply()
 }
@@ -5,6 +5,7 @@ import (
 	"path"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/eris-ltd/eris-cli/chains"
 	"github.com/eris-ltd/eris-cli/config"
@@ -103,6 +104,123 @@ func TestCleanLatentChainDatas(t *testing.T) {
 	testCheckChainDirsExist([]string{chain0, chain1}, false, t)
 }
 
+func TestCleanFilterByName(t *testing.T) {
+	defer util.RemoveAllErisContainers()
+
+	testStartService("keys", t)
+	testStartChain("test-chain-0", t)
+	testStartChain("keep-chain", t)
+
+	do := definitions.NowDo()
+	do.Yes = true
+	do.Containers = true
+	do.ChnDirs = true
+	do.Types = []string{definitions.TypeChain}
+	do.Name = "test-*"
+	if err := Clean(do); err != nil {
+		t.Fatalf("expected clean to succeed, got %v", err)
+	}
+
+	if util.IsChain("test-chain-0", false) {
+		t.Fatalf("expected matching chain removed")
+	}
+	testCheckChainDirsExist([]string{"test-chain-0"}, false, t)
+
+	if !util.IsChain("keep-chain", false) {
+		t.Fatalf("expected chain not matching the pattern to stay")
+	}
+	testCheckChainDirsExist([]string{"keep-chain"}, true, t)
+	if !util.IsService("keys", true) {
+		t.Fatalf("expected the keys service to stay")
+	}
+}
+
+func TestCleanThrowaway(t *testing.T) {
+	defer util.RemoveAllErisContainers()
+
+	testStartService("keys", t)
+	testStartChain("keep-chain", t)
+
+	doMake := definitions.NowDo()
+	doMake.Name = definitions.ThrowawayPrefix + "test"
+	doMake.ChainType = "simplechain"
+	if err := chains.MakeChain(doMake); err != nil {
+		t.Fatalf("expected a chain to be made, got %v", err)
+	}
+	doStart := definitions.NowDo()
+	doStart.Name = doMake.Name
+	doStart.Path = filepath.Join(config.ChainsPath, doMake.Name)
+	doStart.Operations.Labels = chains.ThrowawayLabels(nil, doMake.Name)
+	if err := chains.StartChain(doStart); err != nil {
+		t.Fatalf("expected a chain to be started, got %v", err)
+	}
+
+	do := definitions.NowDo()
+	do.Yes = true
+	do.Containers = true
+	do.Throwaway = true
+	if err := Clean(do); err != nil {
+		t.Fatalf("expected clean to succeed, got %v", err)
+	}
+
+	if !util.IsChain(doMake.Name, true) {
+		t.Fatalf("expected running throwaway chain to stay without force")
+	}
+	testCheckChainDirsExist([]string{doMake.Name}, true, t)
+
+	do.Force = true
+	if err := Clean(do); err != nil {
+		t.Fatalf("expected clean to succeed, got %v", err)
+	}
+
+	if util.Exists(definitions.TypeChain, doMake.Name) || util.Exists(definitions.TypeData, doMake.Name) {
+		t.Fatalf("expected throwaway chain containers removed")
+	}
+	testCheckChainDirsExist([]string{doMake.Name}, false, t)
+
+	if !util.IsChain("keep-chain", false) {
+		t.Fatalf("expected other chains to stay")
+	}
+	testCheckChainDirsExist([]string{"keep-chain"}, true, t)
+}
+
+func TestCleanDryRun(t *testing.T) {
+	defer util.RemoveAllErisContainers()
+
+	testStartService("keys", t)
+
+	do := definitions.NowDo()
+	do.Yes = true
+	do.DryRun = true
+	do.Containers = true
+	do.Types = []string{definitions.TypeService}
+	if err := Clean(do); err != nil {
+		t.Fatalf("expected clean to succeed, got %v", err)
+	}
+
+	if !util.IsService("keys", true) {
+		t.Fatalf("expected nothing removed on dry run")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for _, test := range []struct {
+		in  string
+		out time.Duration
+		err bool
+	}{
+		{"36h", 36 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"week", 0, true},
+	} {
+		age, err := parseAge(test.in)
+		if (err != nil) != test.err || age != test.out {
+			t.Fatalf("%s: expected %v (error %v), got %v, %v", test.in, test.out, test.err, age, err)
+		}
+	}
+}
+
 func testCheckChainDirsExist(chains []string, yes bool, t *testing.T) {
 	if yes { // fail if dirs/files don't exist
 		for _, chn := range chains {
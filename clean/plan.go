@@ -0,0 +1,418 @@
+package clean
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// plan lists everything a clean up would remove.
+type plan struct {
+	Containers []*container
+	Volumes    []*volume
+	Dirs       []*dir
+	Images     []*image
+}
+
+type container struct {
+	Details *util.Details
+	Size    int64
+}
+
+// volume is a container volume removed along with the container.
+type volume struct {
+	Name      string
+	Container string
+	Size      int64 // -1 if unknown
+}
+
+type dir struct {
+	Path string
+	Size int64
+}
+
+type image struct {
+	ID   string
+	Name string
+	Size int64
+}
+
+// filtered returns true if any of the clean filters is given.
+func filtered(do *definitions.Do) bool {
+	return len(do.Types) != 0 || do.Name != "" || do.Stopped || do.OlderThan != "" || do.Orphans || do.Stack != "" || do.Throwaway
+}
+
+// containerFilter returns a function selecting containers matching all
+// the given clean filters.
+func containerFilter(do *definitions.Do) (func(*util.Details) bool, error) {
+	for _, t := range do.Types {
+		switch t {
+		case definitions.TypeChain, definitions.TypeService, definitions.TypeData:
+		default:
+			return nil, fmt.Errorf("Unknown container type %q. Use one of chain, service, or data", t)
+		}
+	}
+
+	if do.Name != "" {
+		if _, err := filepath.Match(do.Name, ""); err != nil {
+			return nil, fmt.Errorf("Bad name pattern %q: %v", do.Name, err)
+		}
+	}
+
+	var age time.Duration
+	if do.OlderThan != "" {
+		var err error
+		if age, err = parseAge(do.OlderThan); err != nil {
+			return nil, err
+		}
+	}
+
+	orphan := func(*util.Details) bool { return true }
+	if do.Orphans {
+		orphan = util.OrphanFilter()
+	}
+
+	return func(details *util.Details) bool {
+		if len(do.Types) != 0 && !util.Contains(do.Types, details.Type) {
+			return false
+		}
+		if do.Name != "" {
+			if matched, _ := filepath.Match(do.Name, details.ShortName); !matched {
+				return false
+			}
+		}
+		if do.Stopped && details.Info.State.Running {
+			return false
+		}
+		if age != 0 && time.Since(details.Info.Created) < age {
+			return false
+		}
+		if do.Stack != "" && details.Labels[definitions.LabelStack] != do.Stack {
+			return false
+		}
+		if do.Throwaway && details.Labels[definitions.LabelThrowaway] == "" {
+			return false
+		}
+		return orphan(details)
+	}, nil
+}
+
+// parseAge is like time.ParseDuration, but
+// also accepts days (e.g. "7d").
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("Bad duration %q (use e.g. 36h or 7d)", s)
+	}
+	return age, nil
+}
+
+// makePlan collects containers matching the clean filters (all Eris
+// containers if there are no filters) and the volumes, directories, and
+// images to be removed along with them.
+//
+//  do.Containers - remove containers
+//  do.ChnDirs    - remove chain directories
+//  do.Scratch    - remove scratch data directories
+//  do.RmD        - remove the Eris root directory (without filters only)
+//  do.Images     - remove images
+//  do.Force      - remove throwaway chains which are still running
+//                  (with do.Throwaway)
+//
+func makePlan(do *definitions.Do) (*plan, error) {
+	p := new(plan)
+
+	match := func(*util.Details) bool { return true }
+	if filtered(do) {
+		var err error
+		if match, err = containerFilter(do); err != nil {
+			return nil, err
+		}
+	}
+
+	// Running throwaway chains likely belong to runs still in progress.
+	active := make(map[string]bool)
+	if do.Throwaway && !do.Force {
+		active = activeThrowaways()
+	}
+
+	sizes := make(map[string]int64)
+	containers, err := util.DockerClient.ListContainers(docker.ListContainersOptions{All: true, Size: true})
+	if err != nil {
+		return nil, util.DockerError(err)
+	}
+	for _, c := range containers {
+		sizes[c.ID] = c.SizeRw
+	}
+
+	// Images used by containers which stay.
+	keep := make(map[string]bool)
+
+	util.ErisContainers(func(name string, details *util.Details) bool {
+		if !do.Containers || !match(details) || active[details.Labels[definitions.LabelThrowaway]] {
+			keep[details.Info.Image] = true
+			return false
+		}
+
+		p.Containers = append(p.Containers, &container{details, sizes[details.Info.ID]})
+		for _, mount := range details.Info.Mounts {
+			if mount.Name == "" {
+				// Bind mounts stay.
+				continue
+			}
+			p.Volumes = append(p.Volumes, &volume{mount.Name, name, sizeOf(mount.Source)})
+		}
+		return true
+	}, false)
+
+	// [pv]: Make sure legacy containers (named with the "eris_" prefix,
+	// but without labels) are removed as well.
+	if do.Containers && !filtered(do) {
+		for _, c := range containers {
+			if _, ok := c.Labels[definitions.LabelEris]; ok || len(c.Names) == 0 {
+				continue
+			}
+			if name := strings.TrimLeft(c.Names[0], "/"); strings.HasPrefix(name, "eris_") {
+				details := util.ContainerDetails(name)
+				if details.Info == nil {
+					continue
+				}
+				details.ShortName = name
+				p.Containers = append(p.Containers, &container{details, sizes[c.ID]})
+			}
+		}
+	}
+
+	if filtered(do) {
+		for _, c := range p.Containers {
+			name := c.Details.ShortName
+			if do.ChnDirs && c.Details.Type == definitions.TypeChain {
+				p.addDir(filepath.Join(config.ChainsPath, name))
+			}
+			if do.Scratch {
+				p.addDir(filepath.Join(config.DataContainersPath, name))
+			}
+		}
+
+		// Throwaway chain directories are removed even if there
+		// are no containers left (e.g. interrupted while making).
+		if do.Throwaway {
+			dirs, _ := filepath.Glob(filepath.Join(config.ChainsPath, definitions.ThrowawayPrefix+"*"))
+			for _, dir := range dirs {
+				if !active[filepath.Base(dir)] {
+					p.addDir(dir)
+				}
+			}
+		}
+	} else {
+		if do.ChnDirs {
+			files, _ := ioutil.ReadDir(config.ChainsPath)
+			for _, file := range files {
+				switch file.Name() {
+				case "account-types", "chain-types", "HEAD":
+					continue
+				}
+				p.addDir(filepath.Join(config.ChainsPath, file.Name()))
+			}
+		}
+		if do.Scratch {
+			p.addDir(config.DataContainersPath)
+		}
+		if do.RmD {
+			p.addDir(config.ErisRoot)
+		}
+	}
+
+	if do.Images {
+		images, err := util.DockerClient.ListImages(docker.ListImagesOptions{All: true})
+		if err != nil {
+			return nil, util.DockerError(err)
+		}
+
+		removed := make(map[string]bool)
+		for _, c := range p.Containers {
+			removed[c.Details.Info.Image] = true
+		}
+
+		for _, i := range images {
+			if len(i.RepoTags) == 0 || !strings.Contains(i.RepoTags[0], "eris/") {
+				continue
+			}
+			if filtered(do) && (!removed[i.ID] || keep[i.ID]) {
+				continue
+			}
+			p.Images = append(p.Images, &image{i.ID, i.RepoTags[0], i.VirtualSize})
+		}
+	}
+
+	return p, nil
+}
+
+// activeThrowaways returns the names of running throwaway chains.
+func activeThrowaways() map[string]bool {
+	active := make(map[string]bool)
+	for _, details := range util.ErisContainersByType(definitions.TypeChain, true) {
+		if name := details.Labels[definitions.LabelThrowaway]; name != "" {
+			active[name] = true
+		}
+	}
+	return active
+}
+
+func (p *plan) addDir(path string) {
+	if !util.DoesDirExist(path) {
+		return
+	}
+	for _, d := range p.Dirs {
+		if d.Path == path {
+			return
+		}
+	}
+	p.Dirs = append(p.Dirs, &dir{path, sizeOf(path)})
+}
+
+func (p *plan) empty() bool {
+	return len(p.Containers) == 0 && len(p.Dirs) == 0 && len(p.Images) == 0
+}
+
+// apply removes everything listed in the plan.
+func (p *plan) apply() error {
+	for _, c := range p.Containers {
+		log.WithField("=>", c.Details.FullName).Info("Removing container")
+		if err := util.DockerClient.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            c.Details.Info.ID,
+			RemoveVolumes: true,
+			Force:         true,
+		}); err != nil {
+			return fmt.Errorf("Error removing container %s: %v", c.Details.FullName, util.DockerError(err))
+		}
+	}
+
+	for _, d := range p.Dirs {
+		log.WithField("=>", d.Path).Info("Removing directory")
+		if err := os.RemoveAll(d.Path); err != nil {
+			return err
+		}
+	}
+	// Scratch data directory is expected to exist.
+	if util.DoesDirExist(config.ErisRoot) {
+		if err := os.MkdirAll(config.DataContainersPath, 0777); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range p.Images {
+		log.WithField("=>", i.Name).Info("Removing image")
+		if err := util.DockerClient.RemoveImageExtended(i.ID, docker.RemoveImageOptions{Force: true, NoPrune: true}); err != nil {
+			return util.DockerError(err)
+		}
+	}
+
+	return nil
+}
+
+// print writes the plan as tables, one per kind of removed things.
+func (p *plan) print(w io.Writer) error {
+	var total int64
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(w, 6, 1, 5, ' ', 0)
+	if len(p.Containers) != 0 {
+		sort.Sort(byName(p.Containers))
+		fmt.Fprintln(tw, "CONTAINER\tTYPE\tSTATE\tCREATED\tSIZE")
+		for _, c := range p.Containers {
+			state := "stopped"
+			if c.Details.Info.State.Running {
+				state = "running"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s ago\t%s\n", c.Details.ShortName, c.Details.Type, state,
+				units.HumanDuration(time.Since(c.Details.Info.Created)), size(c.Size))
+			total += c.Size
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(p.Volumes) != 0 {
+		fmt.Fprintln(tw, "VOLUME\tCONTAINER\tSIZE")
+		for _, v := range p.Volumes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Name, v.Container, size(v.Size))
+			if v.Size > 0 {
+				total += v.Size
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(p.Dirs) != 0 {
+		fmt.Fprintln(tw, "DIRECTORY\tSIZE")
+		for _, d := range p.Dirs {
+			fmt.Fprintf(tw, "%s\t%s\n", util.Tilde(d.Path), size(d.Size))
+			if d.Size > 0 {
+				total += d.Size
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(p.Images) != 0 {
+		fmt.Fprintln(tw, "IMAGE\tSIZE")
+		for _, i := range p.Images {
+			fmt.Fprintf(tw, "%s\t%s\n", i.Name, size(i.Size))
+			total += i.Size
+		}
+		fmt.Fprintln(tw)
+	}
+
+	fmt.Fprintf(tw, "TOTAL\t%s\n", size(total))
+	return tw.Flush()
+}
+
+// sizeOf returns the total size of files under the path
+// or -1 if the path cannot be read (e.g. a volume on a remote host).
+func sizeOf(path string) int64 {
+	total, err := util.DirSize(path)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+func size(n int64) string {
+	if n < 0 {
+		return "unknown"
+	}
+	return units.HumanSize(float64(n))
+}
+
+type byName []*container
+
+func (b byName) Len() int      { return len(b) }
+func (b byName) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool {
+	if b[i].Details.Type != b[j].Details.Type {
+		return b[i].Details.Type < b[j].Details.Type
+	}
+	return b[i].Details.ShortName < b[j].Details.ShortName
+}
@@ -44,6 +44,7 @@ func buildChainsCommand() {
 	Chains.AddCommand(chainsCurrent)
 	Chains.AddCommand(chainsPorts)
 	Chains.AddCommand(chainsStart)
+	Chains.AddCommand(chainsJoin)
 	Chains.AddCommand(chainsLogs)
 	Chains.AddCommand(chainsInspect)
 	Chains.AddCommand(chainsIP)
@@ -51,8 +52,12 @@ func buildChainsCommand() {
 	Chains.AddCommand(chainsExec)
 	Chains.AddCommand(chainsCat)
 	Chains.AddCommand(chainsRestart)
+	Chains.AddCommand(chainsDiff)
 	Chains.AddCommand(chainsRemove)
 	addChainsFlags()
+	buildChainsTypesCommand()
+	buildChainsGenesisCommand()
+	buildChainsConfigCommand()
 }
 
 var chainsMake = &cobra.Command{
@@ -67,10 +72,15 @@ you will let the marmots know how you would like your genesis created.
 Make can also be used with a variety of flags for fast chain making.
 
 When using make with the --known flag the marmots will *not* create keys for you
-and will instead assume that the keys exist somewhere. When using make with the
-wizard (no flags) or when using with the other flags then keys will be made along
-with the genesis.jsons and priv_validator.jsons so that everything is ready to go
-for you to [eris chains start].
+and will instead assume that the keys exist somewhere. The accounts and validators
+CSV files have one account per line in the PUBKEY,AMOUNT,NAME,PERMS,SETBIT format
+(NAME, PERMS, and SETBIT are optional; accounts may be given by an address instead).
+
+When using make with the wizard or with the other flags then keys will be made in
+eris-keys along with the genesis.json, config.toml, and priv_validator.json files
+of every account so that everything is ready to go for you to [eris chains start].
+Chains with one validator have its files also in the chain directory; the
+accounts.csv and validators.csv files written there can be used with --known.
 
 Optionally chains make provides packages of outputted priv_validator and genesis.json
 which you can email or send on your slack to your coworkers. These packages can
@@ -173,7 +183,7 @@ Please update any scripts that rely on [eris chains new].`,
 }
 
 var chainsStart = &cobra.Command{
-	Use:   "start NAME",
+	Use:   "start NAME|--throwaway",
 	Short: "start an existing chain or initialize a new one",
 	Long: `start an existing chain or initialize a new one
 
@@ -194,11 +204,47 @@ directory like so (e.g., for complex chains):
 To stop the chain use: [eris chains stop NAME]. To view a chain's logs use:
 [eris chains logs NAME].
 
-You can redefine the chain ports accessible over the network with the --ports flag.`,
+You can redefine the chain ports accessible over the network with the --ports flag.
+
+With the [--throwaway] flag a single validator chain is made from the chain
+type given with [--chain-type] (simplechain by default) under a generated name
+and started. The chain name and the validator address are printed. The chain
+runs until interrupted with Ctrl-C; then its containers and directory are
+removed. Leftovers (e.g. after a crash) can be removed with
+[eris clean --throwaway].`,
 	Run: StartChain,
 	Example: `$ eris chains start simplechain --ports 4000 -- map the first port from the config file to the host port 40000
 $ eris chains start simplechain --ports 40000,50000- -- redefine the first and the second port mapping and autoincrement the rest
-$ eris chains start simplechain --ports 46656:50000 -- redefine the specific port mapping (published host port:exposed container port)`,
+$ eris chains start simplechain --ports 46656:50000 -- redefine the specific port mapping (published host port:exposed container port)
+$ eris chains start --throwaway -- run a throwaway simplechain until Ctrl-C`,
+}
+
+var chainsJoin = &cobra.Command{
+	Use:   "join NAME --genesis FILE|URL|HASH --seeds HOST:PORT,...",
+	Short: "start a node for a chain run elsewhere",
+	Long: `start a node for a chain run elsewhere
+
+The chain's genesis.json file is taken from a path, a URL, or an IPFS
+hash given with the [--genesis] flag and is checked the way
+[eris chains genesis validate] does. The config.toml file made for the
+node connects to the nodes given with the [--seeds] flag.
+
+The node key is a priv_validator.json file or an eris-keys address given
+with the [--key] flag. If the flag is omitted, the key of an existing
+chain directory is kept (also with [--force]) or a new key is made.
+
+The NAME should be the chain ID from the genesis file; joining under
+another name requires [--force].
+The node validates the chain if its key is one of the genesis
+validators; otherwise it only follows the chain. The key is imported
+into eris-keys.
+
+The files are written into ` + util.Tilde(filepath.Join(config.ChainsPath, "NAME")) + ` and the chain
+is started from there; use [eris chains start NAME] to start it again
+after it has been stopped.`,
+	Example: `$ eris chains join ourchain --genesis https://example.com/genesis.json --seeds 10.0.0.5:46656
+$ eris chains join ourchain --genesis QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG --seeds 10.0.0.5:46656,10.0.0.6:46656 --key ~/priv_validator.json`,
+	Run: JoinChain,
 }
 
 var chainsLogs = &cobra.Command{
@@ -263,6 +309,28 @@ Command will gracefully stop then start a chain.`,
 	Run: RestartChain,
 }
 
+var chainsDiff = &cobra.Command{
+	Use:   "diff NAME",
+	Short: "compare a chain container with its definition",
+	Long: `compare a chain container with its definition
+
+The [eris chains diff] command compares the settings the chain container
+would be created with from the chain definition file with the ones of the
+existing container: the image, environment variables, ports, links, volumes,
+restart policy, and resource limits. Values of variables set from the secrets
+store are redacted.
+
+The definition is completed the way [eris chains start] does it, so give
+the --init-dir, --env, --links, --publish, and --ports flags the chain was
+started with again.
+
+With the --apply flag the container is recreated from the definition if
+there are any differences.`,
+	Example: `$ eris chains diff simplechain -- display the differences
+$ eris chains diff simplechain --apply -- recreate the container if it drifted`,
+	Run: DiffChain,
+}
+
 var chainsCat = &cobra.Command{
 	Use: "cat NAME [config|genesis]",
 	//Use:     "cat NAME [config|genesis|status|validators]",
@@ -283,7 +351,7 @@ func addChainsFlags() {
 	chainsMake.PersistentFlags().StringVarP(&do.ChainType, "chain-type", "", "", "specify the type of chain to use. find these in "+util.Tilde(filepath.Join(config.ChainsPath, "chain-types"))+"; incompatible with account-types")
 	chainsMake.PersistentFlags().BoolVarP(&do.Tarball, "tar", "", false, "instead of making directories in "+util.Tilde(config.ChainsPath)+", make tarballs; incompatible with and overrides zip")
 	chainsMake.PersistentFlags().BoolVarP(&do.ZipFile, "zip", "", false, "instead of making directories in "+util.Tilde(config.ChainsPath)+", make zip files")
-	chainsMake.PersistentFlags().BoolVarP(&do.Output, "output", "", true, "should eris-cm provide an output of its job")
+	chainsMake.PersistentFlags().BoolVarP(&do.Output, "output", "", true, "print the accounts made")
 	chainsMake.PersistentFlags().BoolVarP(&do.Known, "known", "", false, "use csv for a set of known keys to assemble genesis.json (requires both --accounts and --validators flags)")
 	chainsMake.PersistentFlags().StringVarP(&do.ChainMakeActs, "accounts", "", "", "comma separated list of the accounts.csv files you would like to utilize (requires --known flag)")
 	chainsMake.PersistentFlags().StringVarP(&do.ChainMakeVals, "validators", "", "", "comma separated list of the validators.csv files you would like to utilize (requires --known flag)")
@@ -293,6 +361,7 @@ func addChainsFlags() {
 	chainsNew.PersistentFlags().StringVarP(&do.Path, "dir", "", "", "a directory whose contents should be copied into the chain's main dir")
 	buildFlag(chainsNew, do, "publish", "chain")
 	buildFlag(chainsNew, do, "ports", "chain")
+	buildFlag(chainsNew, do, "allocate-ports", "chain")
 	buildFlag(chainsNew, do, "env", "chain")
 	buildFlag(chainsNew, do, "links", "chain")
 	chainsNew.PersistentFlags().BoolVarP(&do.Logrotate, "logrotate", "z", false, "turn on logrotate as a dependency to handle long output")
@@ -300,10 +369,21 @@ func addChainsFlags() {
 	buildFlag(chainsStart, do, "init-dir", "chain")
 	buildFlag(chainsStart, do, "publish", "chain")
 	buildFlag(chainsStart, do, "ports", "chain")
+	buildFlag(chainsStart, do, "allocate-ports", "chain")
 	buildFlag(chainsStart, do, "env", "chain")
 	buildFlag(chainsStart, do, "links", "chain")
 	chainsStart.PersistentFlags().BoolVarP(&do.Force, "force", "f", false, "force reinitialize the chain")
 	chainsStart.PersistentFlags().BoolVarP(&do.Logrotate, "logrotate", "z", false, "turn on logrotate as a dependency to handle long output")
+	chainsStart.PersistentFlags().BoolVarP(&do.Throwaway, "throwaway", "", false, "make and run a chain with a generated name, removed when interrupted")
+	chainsStart.PersistentFlags().StringVarP(&do.ChainType, "chain-type", "", "", "chain type to make the throwaway chain from (simplechain by default)")
+
+	chainsJoin.Flags().StringVarP(&do.GenesisFile, "genesis", "", "", "path, URL, or IPFS hash of the chain's genesis.json file")
+	chainsJoin.Flags().StringSliceVarP(&do.Seeds, "seeds", "", []string{}, "HOST:PORT addresses of the chain nodes to connect to")
+	chainsJoin.Flags().StringVarP(&do.Priv, "key", "", "", "priv_validator.json file or eris-keys address of the node key (a new key is generated by default)")
+	chainsJoin.Flags().BoolVarP(&do.Force, "force", "f", false, "overwrite files of an existing chain directory (the node key stays unless --key is given) and allow a NAME other than the chain ID")
+	buildFlag(chainsJoin, do, "publish", "chain")
+	buildFlag(chainsJoin, do, "ports", "chain")
+	buildFlag(chainsJoin, do, "allocate-ports", "chain")
 
 	buildFlag(chainsLogs, do, "follow", "chain")
 	buildFlag(chainsLogs, do, "tail", "chain")
@@ -319,6 +399,14 @@ func addChainsFlags() {
 	buildFlag(chainsRemove, do, "rm-volumes", "chain")
 	chainsRemove.Flags().BoolVarP(&do.RmHF, "dir", "r", false, "remove the chain directory in "+util.Tilde(config.ChainsPath))
 
+	chainsDiff.Flags().BoolVarP(&do.Apply, "apply", "", false, "recreate the container if it differs from the definition")
+	buildFlag(chainsDiff, do, "init-dir", "chain")
+	buildFlag(chainsDiff, do, "env", "chain")
+	buildFlag(chainsDiff, do, "links", "chain")
+	buildFlag(chainsDiff, do, "publish", "chain")
+	buildFlag(chainsDiff, do, "ports", "chain")
+	buildFlag(chainsDiff, do, "timeout", "chain")
+
 	buildFlag(chainsStop, do, "force", "chain")
 	buildFlag(chainsStop, do, "timeout", "chain")
 
@@ -330,11 +418,22 @@ func addChainsFlags() {
 }
 
 func StartChain(cmd *cobra.Command, args []string) {
+	if do.Throwaway {
+		util.IfExit(ArgCheck(0, "eq", cmd, args))
+		util.IfExit(chains.ThrowawayChain(do, nil))
+		return
+	}
 	util.IfExit(ArgCheck(1, "ge", cmd, args))
 	do.Name = args[0]
 	util.IfExit(chains.StartChain(do))
 }
 
+func JoinChain(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.JoinChain(do))
+}
+
 func LogChain(cmd *cobra.Command, args []string) {
 	util.IfExit(ArgCheck(1, "ge", cmd, args))
 	do.Name = args[0]
@@ -429,6 +528,12 @@ func CatChain(cmd *cobra.Command, args []string) {
 	util.IfExit(chains.CatChain(do))
 }
 
+func DiffChain(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.DiffChain(do))
+}
+
 func PortsChain(cmd *cobra.Command, args []string) {
 	util.IfExit(ArgCheck(1, "ge", cmd, args))
 	do.Name = args[0]
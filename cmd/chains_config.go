@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/chains"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var chainsConfig = &cobra.Command{
+	Use:   "config",
+	Short: "display and change chain config.toml settings",
+	Long: `display and change chain config.toml settings
+
+Settings are changed both in the chain directory on the host and in the
+chain's data container, without reinitializing the chain as
+[eris chains start --force] would. A running chain picks the changes up
+after a restart; use the --restart flag or [eris chains restart].
+
+Settings: ` + strings.Join(chains.ConfigKeys(), ", ") + `.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+var chainsConfigGet = &cobra.Command{
+	Use:   "get CHAIN [KEY]",
+	Short: "display a chain setting or all of them",
+	Long:  `display a chain setting or all of them`,
+	Example: `$ eris chains config get simplechain
+$ eris chains config get simplechain seeds`,
+	Run: GetChainConfig,
+}
+
+var chainsConfigSet = &cobra.Command{
+	Use:   "set CHAIN KEY VALUE",
+	Short: "change a chain setting",
+	Long: `change a chain setting
+
+Values are checked before they are written: seeds are comma separated
+HOST:PORT addresses, rpc_laddr and node_laddr are HOST:PORT addresses,
+fast_sync is true or false, and log_level is one of debug, info,
+notice, warn, error, or crit.
+
+Only the host of rpc_laddr and node_laddr can change; their ports
+(46657 and 46656) are the ones the chain container publishes. Changing
+rpc_laddr changes the rpc_local_address and tendermint_host addresses
+the chain connects to Tendermint with as well.`,
+	Example: `$ eris chains config set simplechain seeds 10.0.0.5:46656,10.0.0.6:46656
+$ eris chains config set simplechain log_level debug --restart
+$ eris chains config set simplechain rpc_laddr 127.0.0.1:46657`,
+	Run: SetChainConfig,
+}
+
+var chainsConfigUnset = &cobra.Command{
+	Use:   "unset CHAIN KEY",
+	Short: "reset a chain setting to its default",
+	Long: `reset a chain setting to its default
+
+The moniker is reset to the chain name.`,
+	Example: `$ eris chains config unset simplechain seeds`,
+	Run:     UnsetChainConfig,
+}
+
+func buildChainsConfigCommand() {
+	chainsConfig.AddCommand(chainsConfigGet)
+	chainsConfig.AddCommand(chainsConfigSet)
+	chainsConfig.AddCommand(chainsConfigUnset)
+	Chains.AddCommand(chainsConfig)
+
+	chainsConfigSet.Flags().BoolVarP(&do.Restart, "restart", "", false, "restart the chain if it runs")
+	chainsConfigUnset.Flags().BoolVarP(&do.Restart, "restart", "", false, "restart the chain if it runs")
+}
+
+func GetChainConfig(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "ge", cmd, args))
+	util.IfExit(ArgCheck(2, "le", cmd, args))
+	do.Name = args[0]
+	do.Operations.Args = args[1:]
+	util.IfExit(chains.GetChainConfig(do))
+}
+
+func SetChainConfig(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(3, "eq", cmd, args))
+	do.Name = args[0]
+	do.Operations.Args = args[1:]
+	util.IfExit(chains.SetChainConfig(do))
+}
+
+func UnsetChainConfig(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Name = args[0]
+	do.Operations.Args = args[1:]
+	util.IfExit(chains.UnsetChainConfig(do))
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"github.com/eris-ltd/eris-cli/chains"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var chainsGenesis = &cobra.Command{
+	Use:   "genesis",
+	Short: "inspect, validate, and compare genesis files",
+	Long: `inspect, validate, and compare genesis files
+
+Genesis files can be given by a chain name, a path to a genesis.json
+file, or a directory with it. Files of a chain are read from its host
+directory or, if there's none, from the chain's data container.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+var chainsGenesisValidate = &cobra.Command{
+	Use:   "validate FILE|CHAIN",
+	Short: "check a genesis file for problems",
+	Long: `check a genesis file for problems
+
+Problems looked for are unknown fields, bad or duplicate account
+addresses and validator keys, negative balances, validators without
+voting power, and a chain_id different from the assert_chain_id
+in the config.toml file next to genesis.json.`,
+	Example: `$ eris chains genesis validate simplechain
+$ eris chains genesis validate ~/Downloads/genesis.json`,
+	Run: ValidateGenesis,
+}
+
+var chainsGenesisDiff = &cobra.Command{
+	Use:   "diff A B",
+	Short: "compare two genesis files",
+	Long: `compare two genesis files
+
+Accounts are matched by their addresses and validators by their
+public keys. Lines starting with - are only in A, lines starting
+with + are only in B, and lines starting with ~ are changed (also
+the chain ID, genesis time, and global permissions). The command
+fails if the files differ.`,
+	Example: `$ eris chains genesis diff simplechain ~/Downloads/genesis.json`,
+	Run:     DiffGenesis,
+}
+
+var chainsGenesisAccounts = &cobra.Command{
+	Use:   "accounts FILE|CHAIN",
+	Short: "list genesis accounts with balances and permissions",
+	Long: `list genesis accounts with balances and permissions
+
+Permissions prefixed with ! are denied. Permissions not set for the
+account fall back to the global ones (params/global_permissions), which
+are listed in their place; "global" means neither sets any.`,
+	Run: GenesisAccounts,
+}
+
+func buildChainsGenesisCommand() {
+	chainsGenesis.AddCommand(chainsGenesisValidate)
+	chainsGenesis.AddCommand(chainsGenesisDiff)
+	chainsGenesis.AddCommand(chainsGenesisAccounts)
+	Chains.AddCommand(chainsGenesis)
+}
+
+func ValidateGenesis(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.ValidateGenesis(do))
+}
+
+func DiffGenesis(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Operations.Args = args
+	util.IfExit(chains.DiffGenesis(do))
+}
+
+func GenesisAccounts(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.GenesisAccounts(do))
+}
@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/eris-ltd/eris-cli/chains"
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var chainsTypes = &cobra.Command{
+	Use:   "types",
+	Short: "manage chain types used by [eris chains make]",
+	Long: `manage chain types used by [eris chains make]
+
+A chain type lists the account types and the number of accounts of each
+type to make for the chain (see [eris chains account-types]), and optionally
+Tendermint consensus parameters written into the config.toml files of the
+chain nodes. Chain types are TOML files in the ` + util.Tilde(config.ChainTypePath) + `
+directory; the simplechain, adminchain, demochain, and sprawlchain types
+are available without a file.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+var chainsTypesList = &cobra.Command{
+	Use:   "ls",
+	Short: "list chain types",
+	Long:  `list chain types`,
+	Run:   ListChainTypes,
+}
+
+var chainsTypesShow = &cobra.Command{
+	Use:   "show NAME|--account-types TYPE:NUMBER,...",
+	Short: "display a chain type and the accounts it makes",
+	Long: `display a chain type and the accounts it makes
+
+With the --account-types flag, display the accounts
+[eris chains make --account-types] would make.`,
+	Example: `$ eris chains types show sprawlchain
+$ eris chains types show --account-types Root:1,Participants:25`,
+	Run: ShowChainType,
+}
+
+var chainsTypesNew = &cobra.Command{
+	Use:   "new NAME",
+	Short: "make a new chain type",
+	Long: `make a new chain type
+
+The new type is a copy of the type given with the --from flag
+(simplechain by default) or is made of the --account-types given.`,
+	Example: `$ eris chains types new bigchain --from sprawlchain
+$ eris chains types new teamchain --account-types Full:1,Developer:10`,
+	Run: NewChainType,
+}
+
+var chainsTypesEdit = &cobra.Command{
+	Use:   "edit NAME",
+	Short: "edit a chain type",
+	Long: `edit a chain type
+
+Default types are copied into the chain types directory before editing.
+The type is validated after the editor exits.`,
+	Run: EditChainType,
+}
+
+var chainsTypesRemove = &cobra.Command{
+	Use:   "rm NAME...",
+	Short: "remove chain type files",
+	Long: `remove chain type files
+
+Default types of the same name are used again afterwards.`,
+	Run: RmChainType,
+}
+
+var chainsTypesExport = &cobra.Command{
+	Use:   "export NAME",
+	Short: "post a chain type to IPFS",
+	Long: `post a chain type to IPFS
+
+The hash printed can be given to [eris chains types import]
+to share the type.`,
+	Run: ExportChainType,
+}
+
+var chainsTypesImport = &cobra.Command{
+	Use:   "import NAME HASH",
+	Short: "pull a chain type from IPFS",
+	Long: `pull a chain type from IPFS
+
+The type is validated and then saved as NAME; an invalid type
+leaves an existing file in place. Account types it refers to
+need to be imported first.`,
+	Example: `$ eris chains types import teamchain QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG`,
+	Run:     ImportChainType,
+}
+
+var chainsAccountTypes = &cobra.Command{
+	Use:   "account-types",
+	Short: "manage account types used by [eris chains make]",
+	Long: `manage account types used by [eris chains make]
+
+An account type sets the default number of accounts to make, the tokens
+and the bonded tokens (for validators) of each account, and the account
+permissions. Account types are TOML files in the ` + util.Tilde(config.AccountsTypePath) + `
+directory; the root, developer, participant, validator, and full types
+are available without a file.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+var chainsAccountTypesList = &cobra.Command{
+	Use:   "ls",
+	Short: "list account types",
+	Long:  `list account types`,
+	Run:   ListAccountTypes,
+}
+
+var chainsAccountTypesShow = &cobra.Command{
+	Use:   "show NAME",
+	Short: "display an account type",
+	Long:  `display an account type`,
+	Run:   ShowAccountType,
+}
+
+var chainsAccountTypesNew = &cobra.Command{
+	Use:   "new NAME",
+	Short: "make a new account type",
+	Long: `make a new account type
+
+The new type is a copy of the type given with the --from flag
+(participant by default). Use [eris chains account-types edit]
+to change it.`,
+	Example: `$ eris chains account-types new auditor --from participant`,
+	Run:     NewAccountType,
+}
+
+var chainsAccountTypesEdit = &cobra.Command{
+	Use:   "edit NAME",
+	Short: "edit an account type",
+	Long: `edit an account type
+
+Default types are copied into the account types directory before editing.
+The type is validated after the editor exits.`,
+	Run: EditAccountType,
+}
+
+var chainsAccountTypesRemove = &cobra.Command{
+	Use:   "rm NAME...",
+	Short: "remove account type files",
+	Long: `remove account type files
+
+Default types of the same name are used again afterwards.`,
+	Run: RmAccountType,
+}
+
+var chainsAccountTypesExport = &cobra.Command{
+	Use:   "export NAME",
+	Short: "post an account type to IPFS",
+	Long: `post an account type to IPFS
+
+The hash printed can be given to [eris chains account-types import]
+to share the type.`,
+	Run: ExportAccountType,
+}
+
+var chainsAccountTypesImport = &cobra.Command{
+	Use:   "import NAME HASH",
+	Short: "pull an account type from IPFS",
+	Long: `pull an account type from IPFS
+
+The type is validated and then saved as NAME; an invalid type
+leaves an existing file in place.`,
+	Run: ImportAccountType,
+}
+
+func buildChainsTypesCommand() {
+	chainsTypes.AddCommand(chainsTypesList)
+	chainsTypes.AddCommand(chainsTypesShow)
+	chainsTypes.AddCommand(chainsTypesNew)
+	chainsTypes.AddCommand(chainsTypesEdit)
+	chainsTypes.AddCommand(chainsTypesRemove)
+	chainsTypes.AddCommand(chainsTypesExport)
+	chainsTypes.AddCommand(chainsTypesImport)
+
+	chainsAccountTypes.AddCommand(chainsAccountTypesList)
+	chainsAccountTypes.AddCommand(chainsAccountTypesShow)
+	chainsAccountTypes.AddCommand(chainsAccountTypesNew)
+	chainsAccountTypes.AddCommand(chainsAccountTypesEdit)
+	chainsAccountTypes.AddCommand(chainsAccountTypesRemove)
+	chainsAccountTypes.AddCommand(chainsAccountTypesExport)
+	chainsAccountTypes.AddCommand(chainsAccountTypesImport)
+
+	Chains.AddCommand(chainsTypes)
+	Chains.AddCommand(chainsAccountTypes)
+	addChainsTypesFlags()
+}
+
+func addChainsTypesFlags() {
+	chainsTypesList.Flags().BoolVarP(&do.Quiet, "quiet", "q", false, "show a list of chain type names")
+	chainsTypesShow.Flags().StringSliceVarP(&do.AccountTypes, "account-types", "", []string{}, "account types and numbers of accounts to display instead of a chain type")
+	chainsTypesNew.Flags().StringVarP(&do.Type, "from", "", "", "chain type to copy (defaults to simplechain)")
+	chainsTypesNew.Flags().StringSliceVarP(&do.AccountTypes, "account-types", "", []string{}, "account types and numbers of accounts of the new chain type")
+	chainsTypesNew.Flags().BoolVarP(&do.Force, "force", "f", false, "overwrite an existing chain type file")
+	chainsTypesImport.Flags().BoolVarP(&do.Force, "force", "f", false, "overwrite an existing chain type file")
+
+	chainsAccountTypesList.Flags().BoolVarP(&do.Quiet, "quiet", "q", false, "show a list of account type names")
+	chainsAccountTypesNew.Flags().StringVarP(&do.Type, "from", "", "", "account type to copy (defaults to participant)")
+	chainsAccountTypesNew.Flags().BoolVarP(&do.Force, "force", "f", false, "overwrite an existing account type file")
+	chainsAccountTypesImport.Flags().BoolVarP(&do.Force, "force", "f", false, "overwrite an existing account type file")
+}
+
+func ListChainTypes(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	util.IfExit(chains.ListChainTypes(do))
+}
+
+func ShowChainType(cmd *cobra.Command, args []string) {
+	if len(do.AccountTypes) != 0 {
+		util.IfExit(ArgCheck(0, "eq", cmd, args))
+	} else {
+		util.IfExit(ArgCheck(1, "eq", cmd, args))
+		do.Name = args[0]
+	}
+	util.IfExit(chains.ShowChainType(do))
+}
+
+func NewChainType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.NewChainType(do))
+}
+
+func EditChainType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.EditChainType(do))
+}
+
+func RmChainType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "ge", cmd, args))
+	do.Operations.Args = args
+	util.IfExit(chains.RmChainType(do))
+}
+
+func ExportChainType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.ExportChainType(do))
+	fmt.Fprintln(config.Global.Writer, do.Result)
+}
+
+func ImportChainType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Name = args[0]
+	do.Hash = args[1]
+	util.IfExit(chains.ImportChainType(do))
+}
+
+func ListAccountTypes(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	util.IfExit(chains.ListAccountTypes(do))
+}
+
+func ShowAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.ShowAccountType(do))
+}
+
+func NewAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.NewAccountType(do))
+}
+
+func EditAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.EditAccountType(do))
+}
+
+func RmAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "ge", cmd, args))
+	do.Operations.Args = args
+	util.IfExit(chains.RmAccountType(do))
+}
+
+func ExportAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(chains.ExportAccountType(do))
+	fmt.Fprintln(config.Global.Writer, do.Result)
+}
+
+func ImportAccountType(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Name = args[0]
+	do.Hash = args[1]
+	util.IfExit(chains.ImportAccountType(do))
+}
@@ -15,7 +15,25 @@ var Clean = &cobra.Command{
 (chains, services, data, etc.) and clean the scratch path, as well as latent directories
 and files in the ` + util.Tilde(config.ChainsPath) + ` directory. Addtional flags can be used to remove
 the Eris home directory and Eris images. Useful for rapid development
-with Docker containers`,
+with Docker containers.
+
+Filter flags (--type, --name, --stopped, --older-than, --orphans, --stack,
+and --throwaway) narrow the clean up down to the containers matching all of them.
+Chain directories (with --chains), scratch data directories, and images
+(with --images) are then removed only for the matching containers; images
+still used by other containers stay. Use --dry-run to see what would be
+removed (with sizes) without removing anything.
+
+The --throwaway filter selects containers of throwaway chains (see
+[eris chains start --throwaway]) left over after a crash; their chain
+directories are removed as well. Throwaway chains which are still running
+likely belong to commands still in progress and stay, unless --force is given.`,
+	Example: `$ eris clean --dry-run -- show what would be removed
+$ eris clean --type chain,data --name "test*" --chains -- remove test chains and their data
+$ eris clean --stopped --older-than 7d -- remove containers stopped and created over a week ago
+$ eris clean --orphans -- remove containers whose chain or service is gone
+$ eris clean --stack myapp -- remove containers brought up with [eris up]
+$ eris clean --throwaway -- remove leftover throwaway chains`,
 	Run: func(cmd *cobra.Command, args []string) {
 		CleanItUp(cmd, args)
 	},
@@ -33,6 +51,17 @@ func addCleanFlags() {
 	Clean.Flags().BoolVarP(&do.Scratch, "scratch", "s", true, "remove contents of "+util.Tilde(config.ScratchPath))
 	Clean.Flags().BoolVarP(&do.RmD, "dir", "", false, "remove the eris home directory in "+util.Tilde(config.ErisRoot))
 	Clean.Flags().BoolVarP(&do.Images, "images", "i", false, "remove all eris docker images")
+	Clean.Flags().BoolVarP(&do.DryRun, "dry-run", "", false, "show what would be removed without removing anything")
+
+	// Filters.
+	Clean.Flags().StringSliceVarP(&do.Types, "type", "t", nil, "remove containers of types (chain, service, or data)")
+	Clean.Flags().StringVarP(&do.Name, "name", "", "", "remove containers with short names matching the glob pattern")
+	Clean.Flags().BoolVarP(&do.Stopped, "stopped", "", false, "remove containers which aren't running")
+	Clean.Flags().StringVarP(&do.OlderThan, "older-than", "", "", "remove containers created longer ago than the duration (e.g. 36h or 7d)")
+	Clean.Flags().BoolVarP(&do.Orphans, "orphans", "", false, "remove containers whose chain or service no longer exists")
+	Clean.Flags().StringVarP(&do.Stack, "stack", "", "", "remove containers brought up with the stack (see [eris up])")
+	Clean.Flags().BoolVarP(&do.Throwaway, "throwaway", "", false, "remove throwaway chains and their directories")
+	Clean.Flags().BoolVarP(&do.Force, "force", "f", false, "with --throwaway, also remove throwaway chains which are still running")
 }
 
 func CleanItUp(cmd *cobra.Command, args []string) {
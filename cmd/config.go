@@ -1,6 +1,13 @@
 package commands
 
 import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/util"
+
 	"github.com/spf13/cobra"
 )
 
@@ -11,9 +18,29 @@ var Config = &cobra.Command{
 
 The [eris config] command is only for configuring Eris:
 it will not work to configure any of the blockchains, services
-or projects which are managed by Eris. To configure blockchains 
-use [eris chains config]; to configure services use [eris services config]; 
-to configure projects use [eris projects config] command.`,
+or projects which are managed by Eris. To configure blockchains
+use [eris chains config]; to configure services use [eris services config];
+to configure projects use [eris projects config] command.
+
+Settings can be grouped into named profiles (e.g. local, ci, staging-box),
+each kept in its own definition file in the ` + util.Tilde(config.ProfilesPath) + ` directory.
+Profile settings are layered on top of the ` + util.Tilde(filepath.Join(config.ErisRoot, "eris.toml")) + ` file.
+The profile in effect is chosen (in that order) by the [--profile] flag,
+the $` + config.ProfileEnvVar + ` environment variable, or the [eris config use] command.
+
+A project-level eris.toml file, found in the current directory or any
+of its parents, is layered on top of both. Besides global settings it
+can define the project's chain (Chain), services to boot (Services),
+and [eris pkgs do] defaults (PackagePath, DefaultGas, DefaultAddr, and
+Compiler):
+
+  Chain = "mychain"
+  Services = ["ipfs"]
+  PackagePath = "./contracts"
+  DefaultAddr = "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7"
+
+Chains checked out with [eris chains checkout] inside a project are
+kept in the project's .eris/HEAD file.`,
 	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
 }
 
@@ -21,31 +48,117 @@ var Cum []int
 
 func buildConfigCommand() {
 	Config.AddCommand(configPlop)
-	Config.AddCommand(configSet)
+	Config.AddCommand(configUse)
+	Config.AddCommand(configList)
 	Config.AddCommand(configEdit)
 }
 
-var configSet = &cobra.Command{
-	Use:   "set KEY:VALUE",
-	Short: "set a config value",
-	Long: `set a config value
-NOTE: the [eris config set] command only operates on the settings 
-for the eris CLI. To set the config for a blockchain use [eris chains config]
-command, and to set the config for a service use [eris services config] 
-command.`,
-	Run: func(cmd *cobra.Command, args []string) {},
-}
-
 var configPlop = &cobra.Command{
 	Use:   "show",
 	Short: "display the config",
-	Long:  `display the config`,
-	Run:   func(cmd *cobra.Command, args []string) {},
+	Long: `display the config
+
+Every setting is displayed along with the profile and
+the definition file its value came from.`,
+	Run: ShowConfig,
+}
+
+var configUse = &cobra.Command{
+	Use:   "use [PROFILE]",
+	Short: "check out a profile",
+	Long: `check out a profile
+
+Settings from the checked out profile will be used by all subsequent
+Eris commands unless overridden with the [--profile] flag or the
+$` + config.ProfileEnvVar + ` environment variable.
+
+If command is given without arguments it will clear the checked out
+profile and only the ` + util.Tilde(filepath.Join(config.ErisRoot, "eris.toml")) + ` settings will be used.`,
+	Example: `$ eris config use ci -- will use the settings from ` + util.Tilde(filepath.Join(config.ProfilesPath, "ci.toml")) + `
+$ eris config use -- will go back to the default settings`,
+	Run: UseProfile,
+}
+
+var configList = &cobra.Command{
+	Use:   "ls",
+	Short: "list available profiles",
+	Long: `list available profiles
+
+The profile in effect is marked with the '*' symbol.`,
+	Run: ListProfiles,
 }
 
 var configEdit = &cobra.Command{
-	Use:   "edit",
-	Short: "ddit a config for in an editor",
-	Long:  `edit a config for in your default editor`,
-	Run:   func(cmd *cobra.Command, args []string) {},
+	Use:   "edit [PROFILE]",
+	Short: "edit a config in an editor",
+	Long: `edit a config in your default editor
+
+Without arguments the definition file of the profile in effect is edited.`,
+	Run: EditConfig,
+}
+
+func ShowConfig(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+
+	sources, err := config.ProfileSources(config.Global.Profile, config.Global.Project)
+	util.IfExit(err)
+
+	fmt.Fprintf(config.Global.Writer, "Profile: %s\n", config.Global.Profile)
+	if config.Global.Project != nil {
+		fmt.Fprintf(config.Global.Writer, "Project: %s\n", util.Tilde(config.Global.Project.File))
+	}
+	fmt.Fprintln(config.Global.Writer)
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tPROFILE\tFILE")
+	for _, source := range sources {
+		profile, file := source.Profile, util.Tilde(source.File)
+		if source.File == "" {
+			file = "(built-in)"
+		}
+		if profile == "" {
+			profile = "(project)"
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", source.Key, source.Value, profile, file)
+	}
+	tw.Flush()
+}
+
+func UseProfile(cmd *cobra.Command, args []string) {
+	name := ""
+	if len(args) >= 1 {
+		name = args[0]
+	}
+	util.IfExit(config.UseProfile(name))
+}
+
+func ListProfiles(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+
+	for _, profile := range append([]string{config.DefaultProfile}, config.Profiles()...) {
+		marker := " "
+		if profile == config.Global.Profile {
+			marker = "*"
+		}
+		fmt.Fprintf(config.Global.Writer, "%s %s\n", marker, profile)
+	}
+}
+
+func EditConfig(cmd *cobra.Command, args []string) {
+	name := config.Global.Profile
+	if len(args) >= 1 {
+		name = args[0]
+	}
+
+	file := filepath.Join(config.ErisRoot, "eris.toml")
+	if name != config.DefaultProfile {
+		util.IfExit(config.CheckProfileName(name))
+		file = filepath.Join(config.ProfilesPath, name+".toml")
+		if matches, _ := filepath.Glob(filepath.Join(config.ProfilesPath, name+".*")); len(matches) != 0 {
+			file = matches[0]
+		}
+	}
+	util.IfExit(config.InitDataDir(filepath.Dir(file)))
+	util.IfExit(config.Editor(file))
 }
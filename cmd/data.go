@@ -48,6 +48,7 @@ func buildDataCommand() {
 	Data.AddCommand(dataExport)
 	Data.AddCommand(dataExec)
 	Data.AddCommand(dataRm)
+	Data.AddCommand(dataPrune)
 	addDataFlags()
 }
 
@@ -136,6 +137,21 @@ var dataRm = &cobra.Command{
 	Run:   RmData,
 }
 
+var dataPrune = &cobra.Command{
+	Use:   "prune",
+	Short: "remove orphaned data containers",
+	Long: `remove orphaned data containers
+
+A data container is orphaned when there's neither a chain nor a service
+(a container or a definition) it belongs to, for example after a failed
+[eris pkgs do] run. Orphaned data containers are listed with their sizes
+and last used times and removed, along with their volumes, after
+confirmation.`,
+	Example: `$ eris data prune --dry-run -- only list orphaned data containers
+$ eris data prune -y --dir -- remove them and their scratch directories without asking`,
+	Run: PruneData,
+}
+
 func addDataFlags() {
 	dataRm.Flags().BoolVarP(&do.RmHF, "dir", "", false, "remove data folder from host")
 
@@ -145,6 +161,10 @@ func addDataFlags() {
 
 	buildFlag(dataRm, do, "rm-volumes", "data")
 
+	dataPrune.Flags().BoolVarP(&do.Yes, "yes", "y", false, "don't ask for confirmation")
+	dataPrune.Flags().BoolVarP(&do.DryRun, "dry-run", "", false, "only list orphaned data containers")
+	dataPrune.Flags().BoolVarP(&do.RmHF, "dir", "", false, "remove data folders from host")
+
 	buildFlag(dataExec, do, "interactive", "data")
 
 }
@@ -223,3 +243,8 @@ func ExecData(cmd *cobra.Command, args []string) {
 	_, err := data.ExecData(do)
 	util.IfExit(err)
 }
+
+func PruneData(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	util.IfExit(data.PruneData(do))
+}
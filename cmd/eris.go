@@ -10,6 +10,7 @@ import (
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/initialize"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/secrets"
 	"github.com/eris-ltd/eris-cli/util"
 	"github.com/eris-ltd/eris-cli/version"
 
@@ -38,10 +39,20 @@ Complete documentation is available at https://monax.io/docs/documentation
 			log.SetLevel(log.DebugLevel)
 		}
 
+		log.AddHook(secrets.Hook())
+
+		if do.Profile != "" {
+			util.IfExit(config.Global.ApplyProfile(do.Profile))
+		}
+
 		// Don't try to connect to Docker for informational
 		// or bug fixing commands.
 		switch cmd.Use {
-		case "version", "update", "man":
+		case "version", "update", "man", "migrate":
+			return
+		}
+		switch cmd.Parent() {
+		case Config, Secrets:
 			return
 		}
 
@@ -121,12 +132,25 @@ func AddCommands() {
 	ErisCmd.AddCommand(Files)
 	buildDataCommand()
 	ErisCmd.AddCommand(Data)
+	buildImagesCommand()
+	ErisCmd.AddCommand(Images)
+	buildMigrateCommand()
+	ErisCmd.AddCommand(Migrate)
 	buildListCommand()
 	ErisCmd.AddCommand(List)
+	buildPortsCommand()
+	ErisCmd.AddCommand(Ports)
 	//buildAgentsCommand()
 	//ErisCmd.AddCommand(Agents)
 	buildCleanCommand()
 	ErisCmd.AddCommand(Clean)
+	buildConfigCommand()
+	ErisCmd.AddCommand(Config)
+	buildSecretsCommand()
+	ErisCmd.AddCommand(Secrets)
+	buildStacksCommand()
+	ErisCmd.AddCommand(Up)
+	ErisCmd.AddCommand(Down)
 	buildInitCommand()
 	ErisCmd.AddCommand(Init)
 	buildVerSionCommand()
@@ -150,6 +174,7 @@ func AddGlobalFlags() {
 	ErisCmd.PersistentFlags().BoolVarP(&do.Verbose, "verbose", "v", false, "verbose output")
 	ErisCmd.PersistentFlags().BoolVarP(&do.Debug, "debug", "d", false, "debug level output")
 	ErisCmd.PersistentFlags().StringVarP(&do.MachineName, "machine", "m", "eris", "machine name for docker-machine that is running VM")
+	ErisCmd.PersistentFlags().StringVarP(&do.Profile, "profile", "", "", "settings profile to use (overrides $"+config.ProfileEnvVar+" and [eris config use])")
 }
 
 func InitializeConfig() {
@@ -200,6 +225,11 @@ func ArgCheck(num int, comp string, cmd *cobra.Command, args []string) error {
 			cmd.Help()
 			return fmt.Errorf("\n**Note** you sent our marmots the wrong number of arguments.\nPlease send the marmots at least %d argument(s).", num)
 		}
+	case "le":
+		if len(args) > num {
+			cmd.Help()
+			return fmt.Errorf("\n**Note** you sent our marmots the wrong number of arguments.\nPlease send the marmots at most %d argument(s).", num)
+		}
 	}
 	return nil
 }
@@ -34,6 +34,8 @@ func buildFlag(cmd *cobra.Command, do *definitions.Do, flag, typ string) { //doe
 		cmd.PersistentFlags().BoolVarP(&do.Operations.PublishAllPorts, "publish", "p", false, "publish random ports")
 	case "ports":
 		cmd.PersistentFlags().StringVarP(&do.Operations.Ports, "ports", "", "", "reassign ports")
+	case "allocate-ports":
+		cmd.PersistentFlags().BoolVarP(&do.Operations.AllocatePorts, "allocate-ports", "", false, "reserve stable host ports not used by other chains and services")
 	case "interactive":
 		cmd.Flags().BoolVarP(&do.Operations.Interactive, "interactive", "i", false, "interactive shell")
 	case "pull":
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/images"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var Images = &cobra.Command{
+	Use:   "images",
+	Short: "manage Docker images used by Eris",
+	Long: `manage Docker images used by Eris
+
+The images lockfile ` + util.Tilde(config.ImagesLockFile) + ` pins images used by
+Eris, known services, and known chains to their registry digests,
+so that a retagged image in the registry doesn't change what
+containers are run from. When the lockfile has an entry for an
+image, containers are always created from the locked digest.
+
+When an image pulled from the registry (e.g. with [eris services update
+--pull]) no longer matches the locked digest, Eris warns about it or
+refuses to continue, depending on the ImagesLockPolicy setting in
+` + util.Tilde(config.ErisRoot) + `/eris.toml ("warn" or "refuse").`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+func buildImagesCommand() {
+	Images.AddCommand(imagesLock)
+	Images.AddCommand(imagesUpdate)
+	Images.AddCommand(imagesSave)
+	Images.AddCommand(imagesLoad)
+	addImagesFlags()
+}
+
+func addImagesFlags() {
+	imagesSave.Flags().BoolVarP(&do.All, "all", "a", false, "save the default images and images of all known services")
+	imagesSave.Flags().StringVarP(&do.Path, "output", "o", "eris-images.tar.gz", "bundle file name")
+}
+
+var imagesLock = &cobra.Command{
+	Use:   "lock [IMAGE...]",
+	Short: "pin images to their registry digests",
+	Long: `pin images to their registry digests
+
+Without arguments, all images used by Eris, known services, and known
+chains are added to the lockfile. Images already in the lockfile are
+left as they are; use [eris images update] to refresh them.`,
+	Example: `$ eris images lock -- lock all known images
+$ eris images lock quay.io/eris/ipfs -- lock the quay.io/eris/ipfs:latest image`,
+	Run: LockImages,
+}
+
+var imagesUpdate = &cobra.Command{
+	Use:   "update [IMAGE...]",
+	Short: "pull images and refresh their locked digests",
+	Long: `pull images and refresh their locked digests
+
+Without arguments, all locked and known images are pulled.`,
+	Run: UpdateImages,
+}
+
+var imagesSave = &cobra.Command{
+	Use:   "save [NAME...]",
+	Short: "save images into a bundle for offline use",
+	Long: `save images into a bundle for offline use
+
+NAME is either a default image name (` + strings.Join(util.DefaultImageNames, ", ") + `)
+or a known service name. Without arguments, the default images are saved.
+Images missing locally are pulled first. Service definition files are
+included in the bundle along with the service images.
+
+The bundle is a gzipped tar archive with a manifest of the images in it.
+Load it with [eris images load] or [eris init --from-bundle] on hosts
+with no access to image registries.`,
+	Example: `$ eris images save -- save the default images into eris-images.tar.gz
+$ eris images save --all -o all.tar.gz -- save the default and all service images
+$ eris images save keys ipfs -- save only the keys and ipfs images`,
+	Run: SaveImages,
+}
+
+var imagesLoad = &cobra.Command{
+	Use:   "load ARCHIVE",
+	Short: "load images from a bundle made with [eris images save]",
+	Long: `load images from a bundle made with [eris images save]
+
+Loaded images are verified against the bundle manifest. Service definition
+files from the bundle are added to ` + util.Tilde(config.ServicesPath) + ` unless
+files with the same names already exist there.`,
+	Run: LoadImages,
+}
+
+func LockImages(cmd *cobra.Command, args []string) {
+	do.ImagesSlice = args
+	util.IfExit(images.LockImages(do))
+}
+
+func UpdateImages(cmd *cobra.Command, args []string) {
+	do.ImagesSlice = args
+	util.IfExit(images.UpdateImages(do))
+}
+
+func SaveImages(cmd *cobra.Command, args []string) {
+	do.ImagesSlice = args
+	util.IfExit(images.SaveBundle(do))
+}
+
+func LoadImages(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Path = args[0]
+	util.IfExit(images.LoadBundle(do))
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/images"
 	"github.com/eris-ltd/eris-cli/initialize"
 	"github.com/eris-ltd/eris-cli/log"
 	"github.com/eris-ltd/eris-cli/util"
@@ -16,7 +17,11 @@ var Init = &cobra.Command{
 	Use:   "init",
 	Short: "initialize your work space for smart contract glory",
 	Long: `create the Eris root ` + util.Tilde(config.ErisRoot) + ` directory with services subdirectories
-and clone github.com/eris-ltd/eris-services into them.`,
+and clone github.com/eris-ltd/eris-services into them.
+
+With the --from-bundle flag, images are loaded from a bundle made
+with [eris images save] instead of being pulled from the registry,
+and no files are fetched from GitHub.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		Router(cmd, args)
 	},
@@ -28,14 +33,29 @@ func buildInitCommand() {
 
 func addInitFlags() {
 	Init.Flags().BoolVarP(&do.Pull, "pull-images", "", true, "by default, pulls and/or update latest primary images. use flag to skip pulling/updating of images.")
+	Init.Flags().StringVarP(&do.Path, "from-bundle", "", "", "load images from the bundle made with [eris images save] instead of pulling them")
 	Init.Flags().BoolVarP(&do.Yes, "yes", "y", false, "over-ride command-line prompts")
 	Init.Flags().BoolVarP(&do.Quiet, "testing", "", false, "DO NOT USE (for testing only)")
 }
 
 func Router(cmd *cobra.Command, args []string) {
+	bundle := do.Path
+	if bundle != "" {
+		// Don't reach out to registries and GitHub:
+		// images come from the bundle, and only
+		// built-in service definitions are written.
+		do.Pull = false
+		do.ServicesSlice = []string{"keys", "ipfs", "compilers"}
+	}
+
 	err := initialize.Initialize(do)
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
+
+	if bundle != "" {
+		log.WithField("=>", bundle).Warn("Loading images from the bundle")
+		util.IfExit(images.LoadBundle(do))
+	}
 }
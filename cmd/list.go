@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"fmt"
+
 	"github.com/eris-ltd/eris-cli/list"
 	"github.com/eris-ltd/eris-cli/util"
 
@@ -19,6 +21,13 @@ The -r flag limits the output to running services or chains only.
 
 The --json flag dumps the container information in the JSON format.
 
+The ORPHAN column marks containers whose chain or service no longer
+exists (neither a container nor a definition). Remove orphaned data
+containers with [eris data prune].
+
+The --stacks flag groups service and chain containers by stacks they
+were brought up with by the [eris up] command.
+
 The -f flag specifies an alternative format for the list, using the syntax
 of Go text templates. If the fields to be displayed are separated by the
 '\t' tab character, the output will be columnized.
@@ -40,7 +49,7 @@ the [eris ls --json] command.
 The default [eris ls] output is equivalent to this custom format:
 
   {{.ShortName}}\t{{asterisk .Info.State.Running}}\t
-  {{short .Info.ID}}\t{{short (dependent .ShortName)}}
+  {{short .Info.ID}}\t{{short (dependent .ShortName)}}\t{{asterisk (orphan .)}}
 
 The are a few helper functions available to prefix the fields with:
 
@@ -50,6 +59,7 @@ The are a few helper functions available to prefix the fields with:
   short       shorten the container ID (or any other value) to 10 symbols
   asterisk    show the '*' symbol if the value is true, '-' otherwise
   dependent   find a dependent data container for the given service or chain
+  orphan      true if the container lost its chain or service (used as {{orphan .}})
 `,
 	Example: `$ eris ls -rf '{{.ShortName}}, {{.Type}}, {{ports .Info}}'
 $ eris ls  -f '{{.ShortName}}\t{{.Type}}\t{{.Info.NetworkSettings.IPAddress}}'
@@ -64,6 +74,7 @@ func buildListCommand() {
 	List.Flags().BoolVarP(&do.Running, "running", "r", false, "show only running containers")
 	List.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
 	List.Flags().StringVarP(&do.Format, "format", "f", "", "alternate format for columnized output")
+	List.Flags().BoolVarP(&do.Stacks, "stacks", "s", false, "group containers by stack")
 }
 
 func ListAll() {
@@ -73,6 +84,13 @@ func ListAll() {
 	if do.JSON {
 		do.Format = "json"
 	}
+	if do.Stacks {
+		if do.All {
+			util.IfExit(fmt.Errorf("The --stacks flag is incompatible with the --all flag"))
+		}
+		util.IfExit(list.Stacks(do.Format, do.Running))
+		return
+	}
 
 	util.IfExit(list.Containers("all", do.Format, do.Running))
 }
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/migrate"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var Migrate = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade the Eris root directory layout",
+	Long: `upgrade the Eris root directory layout
+
+The layout version of the Eris root directory is kept in the
+` + util.Tilde(config.SchemaFile) + ` file. Migrations not yet applied to the root
+(e.g. directory moves or definition file field renames) are applied in
+order. Files and directories a migration changes are backed up to
+` + util.Tilde(config.BackupsPath) + ` first.
+
+[eris init] applies pending migrations as well.`,
+	Example: `$ eris migrate --status -- list migrations and whether they were applied
+$ eris migrate --dry-run -- show what pending migrations would change
+$ eris migrate -y -- apply pending migrations without asking`,
+	Run: MigrateRoot,
+}
+
+// Show the migrations status only.
+var migrateStatus bool
+
+func buildMigrateCommand() {
+	Migrate.Flags().BoolVarP(&migrateStatus, "status", "s", false, "list migrations and their status")
+	Migrate.Flags().BoolVarP(&do.DryRun, "dry-run", "", false, "show the changes pending migrations would make without applying them")
+	Migrate.Flags().BoolVarP(&do.Yes, "yes", "y", false, "don't ask for confirmation")
+}
+
+func MigrateRoot(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	if migrateStatus {
+		util.IfExit(migrate.Status())
+		return
+	}
+	util.IfExit(migrate.Run(do))
+}
@@ -5,9 +5,11 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/pkgs"
 	"github.com/eris-ltd/eris-cli/util"
 	"github.com/eris-ltd/eris-cli/version"
@@ -25,6 +27,14 @@ smart contract packages for use by your application`,
 
 func buildPackagesCommand() {
 	Packages.AddCommand(packagesDo)
+	Packages.AddCommand(packagesTest)
+	Packages.AddCommand(packagesPack)
+	Packages.AddCommand(packagesInstall)
+	Packages.AddCommand(packagesList)
+	Packages.AddCommand(packagesVersions)
+	Packages.AddCommand(packagesRemove)
+	Packages.AddCommand(packagesHistory)
+	Packages.AddCommand(packagesShow)
 	addPackagesFlags()
 }
 
@@ -35,41 +45,190 @@ var packagesDo = &cobra.Command{
 
 [eris pkgs do] will perform the required functionality included
 in a package definition file`,
+	Example: `$ eris pkgs do --chain simplechain --address ADDR -- deploy the package in the current directory
+$ eris pkgs do --chain simplechain --address ADDR --bundle io.monax/idi@1.0.0 -- deploy an installed bundle
+$ eris pkgs do --throwaway -- deploy the package to a throwaway chain removed afterwards`,
 	Run: PackagesDo,
 }
 
+var packagesTest = &cobra.Command{
+	Use:   "test [FILE]...",
+	Short: "run assertions in Eris PM files against a throwaway chain",
+	Long: `run assertions in Eris PM files against a throwaway chain
+
+[eris pkgs test] makes a single validator chain with a generated name,
+runs every Eris PM FILE (epm.yaml by default) against it from the file's
+directory, evaluates the assert jobs of each file against the job results,
+and removes the chain. With the --chain flag the files are run against
+an existing chain instead.
+
+Assert jobs are reported one per test in the TAP or JUnit XML format;
+a file that fails to run is reported as a single failed test. The
+command exits with an error if any test fails. Test runs are not
+recorded in chain ledgers, not even with the --chain flag.`,
+	Example: `$ eris pkgs test -- run assertions in ./epm.yaml
+$ eris pkgs test tests/*.yaml --format junit > report.xml -- run several files, report in JUnit XML
+$ eris pkgs test --chain simplechain --address ADDR -- run against an existing chain`,
+	Run: TestPackages,
+}
+
+var packagesPack = &cobra.Command{
+	Use:   "pack DIR TARBALL",
+	Short: "pack a contract bundle directory into a tarball",
+	Long: `pack a contract bundle directory into a tarball
+
+[eris pkgs pack] writes the ` + util.BundleManifestFile + ` file listing SHA-256
+digests of the files in the DIR directory and packs the directory into
+a gzipped TARBALL which can be installed with the [eris pkgs install]
+command or the eris agent.`,
+	Example: `$ eris pkgs pack ./idi idi.tar.gz -- pack the ./idi bundle directory`,
+	Run:     PackPackage,
+}
+
+var packagesInstall = &cobra.Command{
+	Use:   "install GROUP/BUNDLE@VERSION TARBALL|HASH",
+	Short: "install a contract bundle into the local registry",
+	Long: `install a contract bundle into the local registry
+
+[eris pkgs install] installs a contract bundle from a tarball file or
+from IPFS into the ` + util.Tilde(config.BundlesPath) + ` directory.
+The bundle must include a ` + util.BundleManifestFile + ` file listing
+SHA-256 digests of the bundle files; the bundle is only installed if
+the files match the manifest. Bundles packed with the [eris pkgs pack]
+command include the manifest.
+
+Installed bundles can be deployed with the [eris pkgs do --bundle] command,
+which runs the bundle from a copy of its directory.`,
+	Example: `$ eris pkgs install io.monax/idi@1.0.0 idi.tar.gz -- install a bundle from a tarball
+$ eris pkgs install io.monax/idi@1.0.1 QmdbzmNH1iDg2H86Uk3USuJ2vaugvwU7HubvCxMC2fUykm -- install a bundle from IPFS`,
+	Run: InstallPackage,
+}
+
+var packagesList = &cobra.Command{
+	Use:   "ls",
+	Short: "list installed contract bundles",
+	Long: `list installed contract bundles
+
+The DEPLOYED TO column lists chains the bundle was deployed to
+with the [eris pkgs do --bundle] command or the eris agent, as
+recorded in chain ledgers (see [eris pkgs history]).`,
+	Run: ListPackages,
+}
+
+var packagesVersions = &cobra.Command{
+	Use:   "versions GROUP/BUNDLE",
+	Short: "list installed versions of a contract bundle",
+	Long: `list installed versions of a contract bundle
+
+Versions are listed in ascending order; the last one is deployed
+by [eris pkgs do --bundle] if the version is not specified.`,
+	Example: `$ eris pkgs versions io.monax/idi`,
+	Run:     ListPackageVersions,
+}
+
+var packagesRemove = &cobra.Command{
+	Use:   "rm GROUP/BUNDLE[@VERSION]",
+	Short: "remove an installed contract bundle",
+	Long: `remove an installed contract bundle
+
+All installed versions of the bundle are removed if the version is not specified.`,
+	Example: `$ eris pkgs rm io.monax/idi@1.0.0 -- remove one version
+$ eris pkgs rm io.monax/idi -- remove all versions`,
+	Run: RemovePackage,
+}
+
+var packagesHistory = &cobra.Command{
+	Use:   "history",
+	Short: "list package runs recorded in chain ledgers",
+	Long: `list package runs recorded in chain ledgers
+
+Every successful [eris pkgs do] run (except for runs against throwaway
+chains) is recorded in the ledger of the chain it was run against (in the ` + util.Tilde(config.LedgerPath) + ` directory): the package
+name and the SHA-256 digest of its contracts, the epm.yaml digest, the
+deployed contract addresses, the deploying account, the time, and the
+Eris version. Runs are listed latest first.`,
+	Example: `$ eris pkgs history -- list runs against all chains
+$ eris pkgs history --chain simplechain -- list runs against one chain`,
+	Run: ListPackageRuns,
+}
+
+var packagesShow = &cobra.Command{
+	Use:   "show RUN",
+	Short: "display a package run recorded in a chain ledger",
+	Long: `display a package run recorded in a chain ledger
+
+RUN is the run ID from the [eris pkgs history] output
+or a prefix unique among the recorded runs.`,
+	Example: `$ eris pkgs show 4f2a9c`,
+	Run:     ShowPackageRun,
+}
+
 func addPackagesFlags() {
 	packagesDo.Flags().StringVarP(&do.ChainName, "chain", "c", "", "chain to be used for deployment")
-	packagesDo.Flags().StringSliceVarP(&do.ServicesSlice, "services", "s", []string{}, "comma separated list of services to start")
 	packagesDo.Flags().StringVarP(&do.Path, "dir", "i", "", "root directory of app (will use $pwd by default)")
 	packagesDo.Flags().BoolVarP(&do.Rm, "rm", "r", true, "remove containers after stopping")
 	packagesDo.Flags().BoolVarP(&do.RmD, "rm-data", "x", true, "remove artifacts from host")
 	packagesDo.Flags().StringVarP(&do.CSV, "output", "o", "", "results output type")
 	packagesDo.Flags().StringVarP(&do.EPMConfigFile, "file", "f", "./epm.yaml", "path to package file which Eris PM should use")
-	packagesDo.Flags().StringSliceVarP(&do.ConfigOpts, "set", "e", []string{}, "default sets to use; operates the same way as the [set] jobs, only before the epm file is ran (and after default address")
 	packagesDo.Flags().BoolVarP(&do.OutputTable, "summary", "u", true, "output a table summarizing epm jobs")
 	packagesDo.Flags().StringVarP(&do.PackagePath, "contracts-path", "p", "./contracts", "path to the contracts Eris PM should use")
 	packagesDo.Flags().StringVarP(&do.ABIPath, "abi-path", "b", "./abi", "path to the abi directory Eris PM should use when saving ABIs after the compile process")
-	packagesDo.Flags().StringVarP(&do.DefaultGas, "gas", "g", "1111111111", "default gas to use; can be overridden for any single job")
-	packagesDo.Flags().StringVarP(&do.Compiler, "compiler", "l", formCompilers(), "IP:PORT of compiler which Eris PM should use")
 	packagesDo.Flags().StringVarP(&do.DefaultAddr, "address", "a", "", "default address to use; operates the same way as the [account] job, only before the epm file is ran")
-	packagesDo.Flags().StringVarP(&do.DefaultFee, "fee", "w", "1234", "default fee to use")
-	packagesDo.Flags().StringVarP(&do.DefaultAmount, "amount", "y", "9999", "default amount to use")
-	packagesDo.Flags().StringVarP(&do.ChainPort, "chain-port", "", "46657", "chain rpc port")
-	packagesDo.Flags().StringVarP(&do.KeysPort, "keys-port", "", "4767", "port for keys server")
+	packagesDo.Flags().StringVarP(&do.DefaultFee, "fee", "w", pkgs.DefaultFee, "default fee to use")
+	packagesDo.Flags().StringVarP(&do.DefaultAmount, "amount", "y", pkgs.DefaultAmount, "default amount to use")
 	packagesDo.Flags().BoolVarP(&do.Overwrite, "overwrite", "t", true, "overwrite jobs of the same name")
-	packagesDo.Flags().BoolVarP(&do.LocalCompiler, "local-compiler", "z", false, "use a local compiler service; overwrites anything added to compilers flag")
+	packagesDo.Flags().StringVarP(&do.Bundle, "bundle", "", "", "deploy an installed bundle (GROUP/BUNDLE[@VERSION]) instead of the package directory")
+	packagesDo.Flags().BoolVarP(&do.Throwaway, "throwaway", "", false, "deploy to a chain with a generated name, removed afterwards; --address defaults to its validator")
+	addPackagesRunFlags(packagesDo)
+
+	packagesTest.Flags().StringVarP(&do.ChainName, "chain", "c", "", "run against an existing chain instead of a throwaway one")
+	packagesTest.Flags().StringVarP(&do.Format, "format", "", "", "report format: tap (default) or junit")
+	packagesTest.Flags().StringVarP(&do.DefaultAddr, "address", "a", "", "default address to use (the throwaway chain validator by default)")
+	addPackagesRunFlags(packagesTest)
+
+	packagesList.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
+	packagesHistory.Flags().StringVarP(&do.ChainName, "chain", "c", "", "only display runs against this chain")
+	packagesHistory.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
+	packagesShow.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
+	packagesVersions.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
+}
+
+// addPackagesRunFlags adds the flags passed to Eris PM and the chain
+// flags shared by the [eris pkgs do] and [eris pkgs test] commands.
+func addPackagesRunFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&do.ServicesSlice, "services", "s", []string{}, "comma separated list of services to start")
+	cmd.Flags().StringSliceVarP(&do.ConfigOpts, "set", "e", []string{}, "default sets to use; operates the same way as the [set] jobs, only before the epm file is ran (and after default address")
+	cmd.Flags().StringVarP(&do.DefaultGas, "gas", "g", pkgs.DefaultGas, "default gas to use; can be overridden for any single job")
+	cmd.Flags().StringVarP(&do.Compiler, "compiler", "l", formCompilers(), "IP:PORT of compiler which Eris PM should use")
+	cmd.Flags().BoolVarP(&do.LocalCompiler, "local-compiler", "z", false, "use a local compiler service; overwrites anything added to compilers flag")
+	cmd.Flags().StringVarP(&do.ChainPort, "chain-port", "", pkgs.DefaultChainPort, "chain rpc port")
+	cmd.Flags().StringVarP(&do.KeysPort, "keys-port", "", pkgs.DefaultKeysPort, "port for keys server")
+	cmd.Flags().StringVarP(&do.ChainType, "chain-type", "", "", "chain type to make the throwaway chain from (simplechain by default)")
 }
 
 func PackagesDo(cmd *cobra.Command, args []string) {
 	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	if project := config.Global.Project; project != nil {
+		projectDefaults(cmd, project)
+	}
+	if do.Bundle != "" {
+		bundleDefaults(cmd)
+	}
 	if do.Path == "" {
 		var err error
 		do.Path, err = os.Getwd()
 		util.IfExit(err)
 	}
+	if do.Throwaway {
+		if cmd.Flags().Changed("chain") {
+			util.IfExit(fmt.Errorf("the --chain and --throwaway flags are incompatible. Please use one or the other"))
+		}
+		do.ChainName = ""
+		util.IfExit(pkgs.RunPackage(do))
+		return
+	}
 	if do.ChainName == "" {
-		util.IfExit(fmt.Errorf("please provide the name of a running chain with --chain"))
+		util.IfExit(fmt.Errorf("please provide the name of a running chain with --chain or use --throwaway"))
 	}
 	if do.DefaultAddr == "" { // note that this is not strictly necessary since the addr can be set in the epm.yaml.
 		util.IfExit(fmt.Errorf("please provide the address to deploy from with --address"))
@@ -77,6 +236,97 @@ func PackagesDo(cmd *cobra.Command, args []string) {
 	util.IfExit(pkgs.RunPackage(do))
 }
 
+func TestPackages(cmd *cobra.Command, args []string) {
+	do.Operations.Args = args
+	if len(args) == 0 {
+		do.EPMConfigFile = "epm.yaml"
+	}
+	util.IfExit(pkgs.TestPackage(do))
+}
+
+// bundleDefaults points [eris pkgs do] to the installed bundle directory.
+func bundleDefaults(cmd *cobra.Command) {
+	bundle, err := pkgs.FindBundle(do.Bundle)
+	util.IfExit(err)
+
+	do.Bundle = bundle.Reference()
+	do.Path = bundle.Path()
+	if !cmd.Flags().Changed("file") {
+		do.EPMConfigFile = filepath.Join(do.Path, "epm.yaml")
+	}
+	if !cmd.Flags().Changed("contracts-path") {
+		do.PackagePath = do.Path
+	}
+	if !cmd.Flags().Changed("abi-path") {
+		do.ABIPath = filepath.Join(do.Path, "abi")
+	}
+}
+
+func PackPackage(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Path = args[0]
+	do.Destination = args[1]
+	util.IfExit(pkgs.PackBundle(do))
+}
+
+func InstallPackage(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "eq", cmd, args))
+	do.Name = args[0]
+	do.Hash = args[1]
+	util.IfExit(pkgs.InstallBundle(do))
+}
+
+func ListPackages(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	util.IfExit(pkgs.ListBundles(do))
+}
+
+func ListPackageVersions(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(pkgs.ListBundleVersions(do))
+}
+
+func RemovePackage(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(pkgs.RemoveBundle(do))
+}
+
+func ListPackageRuns(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	util.IfExit(pkgs.ListRuns(do))
+}
+
+func ShowPackageRun(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(pkgs.ShowRun(do))
+}
+
+// projectDefaults fills in [eris pkgs do] flags not given on the command
+// line with the values from the project-level definition file.
+func projectDefaults(cmd *cobra.Command, project *config.Project) {
+	if do.ChainName == "" {
+		do.ChainName, _ = util.GetProjectHead()
+	}
+	if !cmd.Flags().Changed("services") && len(project.Services) != 0 {
+		do.ServicesSlice = project.Services
+	}
+	if !cmd.Flags().Changed("contracts-path") && project.PackagePath != "" {
+		do.PackagePath = project.AbsolutePath(project.PackagePath)
+	}
+	if !cmd.Flags().Changed("gas") && project.DefaultGas != "" {
+		do.DefaultGas = project.DefaultGas
+	}
+	if !cmd.Flags().Changed("address") && project.DefaultAddr != "" {
+		do.DefaultAddr = project.DefaultAddr
+	}
+	if !cmd.Flags().Changed("compiler") && project.Compiler != "" {
+		do.Compiler = project.Compiler
+	}
+}
+
 func formCompilers() string {
 	verSplit := strings.Split(version.VERSION, ".")
 	maj, _ := strconv.Atoi(verSplit[0])
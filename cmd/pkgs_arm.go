@@ -69,13 +69,13 @@ func addPackagesFlags() {
 	packagesDo.Flags().BoolVarP(&do.OutputTable, "summary", "u", true, "output a table summarizing epm jobs")
 	packagesDo.Flags().StringVarP(&do.PackagePath, "contracts-path", "p", "./contracts", "path to the contracts EPM should use")
 	packagesDo.Flags().StringVarP(&do.ABIPath, "abi-path", "b", "./abi", "path to the abi directory EPM should use when saving ABIs after the compile process")
-	packagesDo.Flags().StringVarP(&do.DefaultGas, "gas", "g", "1111111111", "default gas to use; can be overridden for any single job")
+	packagesDo.Flags().StringVarP(&do.DefaultGas, "gas", "g", pkgs.DefaultGas, "default gas to use; can be overridden for any single job")
 	packagesDo.Flags().StringVarP(&do.Compiler, "compiler", "l", formCompilers(), "<ip:port> of compiler which EPM should use")
 	packagesDo.Flags().StringVarP(&do.DefaultAddr, "address", "a", "", "default address to use; operates the same way as the [account] job, only before the epm file is ran")
-	packagesDo.Flags().StringVarP(&do.DefaultFee, "fee", "w", "1234", "default fee to use")
-	packagesDo.Flags().StringVarP(&do.DefaultAmount, "amount", "y", "9999", "default amount to use")
-	packagesDo.Flags().StringVarP(&do.ChainPort, "chain-port", "", "46657", "chain rpc port")
-	packagesDo.Flags().StringVarP(&do.KeysPort, "keys-port", "", "4767", "port for keys server")
+	packagesDo.Flags().StringVarP(&do.DefaultFee, "fee", "w", pkgs.DefaultFee, "default fee to use")
+	packagesDo.Flags().StringVarP(&do.DefaultAmount, "amount", "y", pkgs.DefaultAmount, "default amount to use")
+	packagesDo.Flags().StringVarP(&do.ChainPort, "chain-port", "", pkgs.DefaultChainPort, "chain rpc port")
+	packagesDo.Flags().StringVarP(&do.KeysPort, "keys-port", "", pkgs.DefaultKeysPort, "port for keys server")
 	packagesDo.Flags().BoolVarP(&do.Overwrite, "overwrite", "t", true, "overwrite jobs of the same name")
 }
 
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/list"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var Ports = &cobra.Command{
+	Use:   "ports",
+	Short: "manage host port reservations",
+	Long: `manage host port reservations
+
+Host ports bound by chain and service containers are reserved in the
+` + util.Tilde(config.PortsFile) + ` file when the containers are created. Before
+a container is created, its host ports are checked against reservations
+of other existing containers, ports published by other running containers,
+and (with a local Docker daemon) ports used by other processes, so that
+port collisions are reported before Docker fails.
+
+The --allocate-ports flag of the [eris chains start] and [eris services start]
+commands hands out stable, non-conflicting host ports: the ports reserved
+for the container before, if they're still free, or the first free ports
+starting from the ones in the definition file.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+func buildPortsCommand() {
+	Ports.AddCommand(portsList)
+	portsList.Flags().BoolVarP(&do.JSON, "json", "", false, "machine readable output")
+}
+
+var portsList = &cobra.Command{
+	Use:   "ls",
+	Short: "list host port reservations",
+	Long: `list host port reservations
+
+Reservations of removed containers are marked as released.
+They don't block other containers from using the ports.`,
+	Run: ListPorts,
+}
+
+func ListPorts(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	if do.JSON {
+		util.IfExit(list.Ports("json"))
+		return
+	}
+	util.IfExit(list.Ports(""))
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/secrets"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var Secrets = &cobra.Command{
+	Use:   "secrets",
+	Short: "manage secrets used by services",
+	Long: `manage secrets used by services
+
+Secrets are kept encrypted in the ` + util.Tilde(config.SecretsPath) + ` directory
+and are injected into service containers at creation time. The encryption
+key is read from the $` + secrets.KeyEnvVar + ` environment variable (64 hex digits)
+or from the ` + util.Tilde(config.SecretsKeyFile) + ` file (created if missing,
+readable by its owner only; set $ERIS_SECRETS_KEY_FILE to keep it elsewhere).
+To use a
+secret, reference it in the [service.secrets] section of a service
+definition file, mapping an environment variable name to a secret name:
+
+  [service.secrets]
+  POSTGRES_PASSWORD = "db-password"
+
+Environment variables set from secrets are redacted in log
+output and in the [eris services inspect] command output.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+func buildSecretsCommand() {
+	Secrets.AddCommand(secretsSet)
+	Secrets.AddCommand(secretsGet)
+	Secrets.AddCommand(secretsList)
+	Secrets.AddCommand(secretsRemove)
+}
+
+var secretsSet = &cobra.Command{
+	Use:   "set NAME [VALUE]",
+	Short: "add or change a secret",
+	Long: `add or change a secret
+
+If VALUE is not given, it is read from the standard input
+(so it does not end up in the shell history).`,
+	Example: `$ eris secrets set db-password -- will read the secret value from the terminal
+$ cat password.txt | eris secrets set db-password -- will read the secret value from a file`,
+	Run: SetSecret,
+}
+
+var secretsGet = &cobra.Command{
+	Use:   "get NAME",
+	Short: "display a secret value",
+	Long:  `display a secret value`,
+	Run:   GetSecret,
+}
+
+var secretsList = &cobra.Command{
+	Use:   "ls",
+	Short: "list secret names",
+	Long:  `list secret names (values are not displayed)`,
+	Run:   ListSecrets,
+}
+
+var secretsRemove = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "remove a secret",
+	Long:  `remove a secret`,
+	Run:   RemoveSecret,
+}
+
+func SetSecret(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "ge", cmd, args))
+	do.Name = args[0]
+
+	if len(args) > 1 {
+		do.Value = args[1]
+	} else {
+		fmt.Fprintf(config.Global.ErrorWriter, "Value for the %q secret: ", do.Name)
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && value == "" {
+			util.IfExit(fmt.Errorf("Cannot read the secret value: %v", err))
+		}
+		do.Value = strings.TrimRight(value, "\r\n")
+	}
+
+	util.IfExit(secrets.SetSecret(do))
+}
+
+func GetSecret(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+
+	value, err := secrets.GetSecret(do)
+	util.IfExit(err)
+	fmt.Fprintln(config.Global.Writer, value)
+}
+
+func ListSecrets(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+
+	names, err := secrets.ListSecrets(do)
+	util.IfExit(err)
+	for _, name := range names {
+		fmt.Fprintln(config.Global.Writer, name)
+	}
+}
+
+func RemoveSecret(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(secrets.RemoveSecret(do))
+}
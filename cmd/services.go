@@ -36,6 +36,8 @@ func buildServicesCommand() {
 	Services.AddCommand(servicesInspect)
 	Services.AddCommand(servicesIP)
 	Services.AddCommand(servicesPorts)
+	Services.AddCommand(servicesForward)
+	Services.AddCommand(servicesDiff)
 	Services.AddCommand(servicesExec)
 	Services.AddCommand(servicesStop)
 	Services.AddCommand(servicesRename)
@@ -117,6 +119,23 @@ To view a service's logs use: [eris services logs NAME].
 
 You can redefine service ports accessible over the network with
 the --ports flag.
+
+Instead of an image, a service definition can have a build section
+to build the image from a local build context (relative paths are
+relative to the ` + util.Tilde(config.ServicesPath) + ` directory):
+
+  [service.build]
+  context = "~/src/myapp"
+  dockerfile = "docker/Dockerfile"   # relative to the context
+  target = "release"                 # multi-stage build target
+  [service.build.args]
+  VERSION = "1.2"
+
+The image is tagged with the hash of the context contents and only
+rebuilt (and the stopped service container recreated) when the
+context or the build parameters change. A running service container
+is left as it is; stop it first with [eris services stop NAME] to
+have it recreated from the rebuilt image.
 `,
 	Run: StartService,
 
@@ -158,6 +177,46 @@ $ eris services ports ipfs 4001 5001 -- will display specific IPFS ports`,
 	Run: PortsService,
 }
 
+var servicesForward = &cobra.Command{
+	Use:   "forward NAME [LOCAL:]REMOTE...",
+	Short: "forward local ports to service ports",
+	Long: `forward local ports to service ports
+
+The [eris services forward] command runs a TCP proxy from a stable local
+port to the REMOTE port of a running service container until interrupted
+with Ctrl-C. It is useful when ports are published to random host ports
+(see the [eris services start --publish] flag).
+
+The LOCAL port defaults to the REMOTE port number. The proxy listens on
+127.0.0.1 unless an address is given in the IP:LOCAL:REMOTE format.
+
+The host port the service port is published to is looked up for every new
+connection, so the proxy follows service restarts. The proxy also works
+when Docker runs on a remote host.`,
+	Example: `$ eris services forward ipfs 8080 -- localhost:8080 goes to the IPFS gateway
+$ eris services forward ipfs 9000:5001 -- localhost:9000 goes to the IPFS API port
+$ eris services forward ipfs 0.0.0.0:8080:8080 4001 -- forward several ports`,
+	Run: ForwardService,
+}
+
+var servicesDiff = &cobra.Command{
+	Use:   "diff NAME",
+	Short: "compare a service container with its definition",
+	Long: `compare a service container with its definition
+
+The [eris services diff] command compares the settings the service container
+would be created with from the service definition file with the ones of the
+existing container: the image, environment variables, ports, links, volumes,
+restart policy, and resource limits. Values of variables set from the secrets
+store are redacted.
+
+With the --apply flag the container is recreated from the definition if
+there are any differences.`,
+	Example: `$ eris services diff ipfs -- display the differences
+$ eris services diff ipfs --apply -- recreate the container if it drifted`,
+	Run: DiffService,
+}
+
 var servicesLogs = &cobra.Command{
 	Use:   "logs NAME",
 	Short: "display the logs of a running service",
@@ -243,8 +302,12 @@ func addServicesFlags() {
 	buildFlag(servicesRm, do, "rm-volumes", "service")
 	servicesRm.Flags().BoolVarP(&do.RmImage, "image", "", false, "remove the services' docker image")
 
+	servicesDiff.Flags().BoolVarP(&do.Apply, "apply", "", false, "recreate the container if it differs from the definition")
+	buildFlag(servicesDiff, do, "timeout", "service")
+
 	buildFlag(servicesStart, do, "publish", "service")
 	buildFlag(servicesStart, do, "ports", "service")
+	buildFlag(servicesStart, do, "allocate-ports", "service")
 	buildFlag(servicesStart, do, "env", "service")
 	buildFlag(servicesStart, do, "links", "service")
 	servicesStart.Flags().StringVarP(&do.ChainName, "chain", "c", "", "specify a chain the service depends on")
@@ -351,6 +414,19 @@ func PortsService(cmd *cobra.Command, args []string) {
 	util.IfExit(services.PortsService(do))
 }
 
+func ForwardService(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "ge", cmd, args))
+	do.Name = args[0]
+	do.Operations.Args = args[1:]
+	util.IfExit(services.ForwardService(do))
+}
+
+func DiffService(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	do.Name = args[0]
+	util.IfExit(services.DiffService(do))
+}
+
 func UpdateService(cmd *cobra.Command, args []string) {
 	util.IfExit(ArgCheck(1, "ge", cmd, args))
 	do.Name = args[0]
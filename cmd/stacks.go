@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/eris-ltd/eris-cli/stacks"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var Up = &cobra.Command{
+	Use:   "up [STACK_FILE]",
+	Short: "bring up a chain, services, and packages described by a stack file",
+	Long: `bring up a chain, services, and packages described by a stack file
+
+The stack file (stack.toml, stack.json, or stack.yaml in the current
+directory by default) describes a whole application environment:
+
+  name = "myapp"                  # defaults to the stack file directory name
+  services = [ "ipfs" ]           # services to start after the chain
+
+  [chain]
+  name = "mychain"
+  type = "simplechain"            # make the chain if it doesn't exist (optional)
+  init_dir = "mychain_full_000"   # same as [eris chains start --init-dir] (optional;
+                                  # a made chain starts from its first validator's files)
+
+  [[packages]]                    # packages to deploy after the services are up
+  path = "./contracts"            # relative to the stack file directory
+  address = "ADDRESS"             # address to deploy from
+  file = "epm.yaml"               # package file (optional)
+
+  [environment]                   # environment overrides for the chain and services
+  LOG_LEVEL = "debug"             # (not for services started as their dependencies)
+
+The chain is started first, then the services (connected to the chain),
+and then the packages are deployed. Containers created by [eris up] are
+labeled with the stack name and can be listed with [eris ls --stacks].`,
+	Example: `$ eris up -- bring up the stack described by ./stack.toml
+$ eris up ~/apps/myapp/stack.toml`,
+	Run: StackUp,
+}
+
+var Down = &cobra.Command{
+	Use:   "down [STACK_FILE]",
+	Short: "stop and remove containers brought up with [eris up]",
+	Long: `stop and remove containers brought up with [eris up]
+
+Services are removed first, then the chain. Data containers are removed
+as well, unless the --keep-data flag is given. Chains and services which
+were already running before [eris up] are not touched.`,
+	Example: `$ eris down -- tear down the stack described by ./stack.toml
+$ eris down --keep-data -- keep the stack data containers for the next [eris up]`,
+	Run: StackDown,
+}
+
+// Keep data containers on [eris down].
+var keepData bool
+
+func buildStacksCommand() {
+	Up.Flags().StringVarP(&do.Compiler, "compiler", "l", formCompilers(), "IP:PORT of compiler which Eris PM should use to deploy the stack packages")
+
+	Down.Flags().BoolVarP(&keepData, "keep-data", "k", false, "keep the stack data containers")
+	buildFlag(Down, do, "rm-volumes", "stack")
+	buildFlag(Down, do, "force", "stack")
+	buildFlag(Down, do, "timeout", "stack")
+}
+
+func StackUp(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "le", cmd, args))
+	do.Path = stackPath(args)
+	util.IfExit(stacks.Up(do))
+}
+
+func StackDown(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "le", cmd, args))
+	do.Path = stackPath(args)
+	do.RmD = !keepData
+	util.IfExit(stacks.Down(do))
+}
+
+func stackPath(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	pwd, err := os.Getwd()
+	util.IfExit(err)
+	return pwd
+}
@@ -24,6 +24,12 @@ type Config struct {
 	ErrorWriter            io.Writer
 	InteractiveWriter      io.Writer
 	InteractiveErrorWriter io.Writer
+
+	// Profile is the name of the profile the settings were loaded with.
+	Profile string
+	// Project is the project the current working directory belongs to
+	// (nil if none).
+	Project *Project
 	Settings
 }
 
@@ -38,12 +44,20 @@ type Settings struct {
 	DockerCertPath    string `json:"DockerCertPath,omitempty" yaml:"DockerCertPath,omitempty" toml:"DockerCertPath,omitempty"`
 	CrashReport       string `json:"CrashReport,omitempty" yaml:"CrashReport,omitempty" toml:"CrashReport,omitempty"`
 	ImagesPullTimeout string `json:"ImagesPullTimeout,omitempty" yaml:"ImagesPullTimeout,omitempty" toml:"ImagesPullTimeout,omitempty"`
+	ImagesLockPolicy  string `json:"ImagesLockPolicy,omitempty" yaml:"ImagesLockPolicy,omitempty" toml:"ImagesLockPolicy,omitempty"` // "warn" or "refuse"
 	Verbose           bool
 
 	// Image defaults.
 	DefaultRegistry string `json:"DefaultRegistry,omitempty" yaml:"DefaultRegistry,omitempty" toml:"DefaultRegistry,omitempty"`
 	BackupRegistry  string `json:"BackupRegistry,omitempty" yaml:"BackupRegistry,omitempty" toml:"BackupRegistry,omitempty"`
 
+	// Registries to pull images from, in order (see Registry).
+	Registries        []Registry `json:"Registries,omitempty" yaml:"Registries,omitempty" toml:"Registries,omitempty"`
+	ImagesPullRetries int        `json:"ImagesPullRetries,omitempty" yaml:"ImagesPullRetries,omitempty" toml:"ImagesPullRetries,omitzero"`
+
+	// Maximum number of images [eris init] pulls simultaneously.
+	ImagesPullConcurrency int `json:"ImagesPullConcurrency,omitempty" yaml:"ImagesPullConcurrency,omitempty" toml:"ImagesPullConcurrency,omitzero"`
+
 	ImageData      string `json:"ImageData,omitempty" yaml:"ImageData,omitempty" toml:"ImageData,omitempty"`
 	ImageKeys      string `json:"ImageKeys,omitempty" yaml:"ImageKeys,omitempty" toml:"ImageKeys,omitempty"`
 	ImageDB        string `json:"ImageDB,omitempty" yaml:"ImageDB,omitempty" toml:"ImageDB,omitempty"`
@@ -53,8 +67,19 @@ type Settings struct {
 	ImageCompilers string `json:"ImageCompilers,omitempty" yaml:"ImageCompilers,omitempty" toml:"ImageCompilers,omitempty"`
 }
 
+// Registry describes a Docker registry (or a registry mirror) images are
+// pulled from and the credentials to log in to it with, if required.
+type Registry struct {
+	Host     string `json:"Host" yaml:"Host" toml:"Host"`
+	Username string `json:"Username,omitempty" yaml:"Username,omitempty" toml:"Username,omitempty"`
+	Password string `json:"Password,omitempty" yaml:"Password,omitempty" toml:"Password,omitempty"`
+	Email    string `json:"Email,omitempty" yaml:"Email,omitempty" toml:"Email,omitempty"`
+}
+
 // New initializes the global configuration with default settings
-// or settings loaded from the "eris.toml" default location.
+// or settings loaded from the "eris.toml" default location, the active
+// profile (see ActiveProfile), and the project-level "eris.toml" file
+// (see FindProject), if any.
 // New also initialize default writer and errorWriter streams.
 // Viper or unmarshalling errors are returned on error.
 func New(writer, errorWriter io.Writer) (*Config, error) {
@@ -63,13 +88,23 @@ func New(writer, errorWriter io.Writer) (*Config, error) {
 		ErrorWriter:            errorWriter,
 		InteractiveWriter:      ioutil.Discard,
 		InteractiveErrorWriter: ioutil.Discard,
+		Profile:                ActiveProfile(),
 	}
 
-	v, err := Load()
+	v, err := LoadProfile(config.Profile)
 	if err != nil {
 		return config, err
 	}
 
+	project, projectViper, err := discoverProject()
+	if err != nil {
+		return config, err
+	}
+	if project != nil {
+		applyProject(v, projectViper)
+		config.Project = project
+	}
+
 	if err := v.Unmarshal(&config.Settings); err != nil {
 		return config, err
 	}
@@ -132,6 +167,9 @@ func SetDefaults() (*viper.Viper, error) {
 	config.SetDefault("IpfsPort", "8080")           // [csk] TODO: be less opinionated here...
 	config.SetDefault("CrashReport", "bugsnag")
 	config.SetDefault("ImagesPullTimeout", "15m")
+	config.SetDefault("ImagesLockPolicy", "warn")
+	config.SetDefault("ImagesPullRetries", 3)
+	config.SetDefault("ImagesPullConcurrency", 4)
 
 	// Compiler defaults.
 	config.SetDefault("CompilersHost", "https://compilers.monax.io")
@@ -25,15 +25,40 @@ var (
 	BundlesPath  = filepath.Join(ErisRoot, "bundles")
 	ChainsPath   = filepath.Join(ErisRoot, "chains")
 	KeysPath     = filepath.Join(ErisRoot, "keys")
+	ProfilesPath = filepath.Join(ErisRoot, "profiles")
 	RemotesPath  = filepath.Join(ErisRoot, "remotes")
 	ScratchPath  = filepath.Join(ErisRoot, "scratch")
+	SecretsPath  = filepath.Join(ErisRoot, "secrets")
 	ServicesPath = filepath.Join(ErisRoot, "services")
 
+	// Secrets store encryption key. Kept outside of the Eris root,
+	// so that copies of the root don't carry the key along.
+	SecretsKeyFile = ResolveSecretsKeyFile()
+
 	// Chains directories.
 	HEAD             = filepath.Join(ChainsPath, "HEAD")
 	AccountsTypePath = filepath.Join(ChainsPath, "account-types")
 	ChainTypePath    = filepath.Join(ChainsPath, "chain-types")
 
+	// Profiles directories.
+	ProfileHEAD = filepath.Join(ProfilesPath, "HEAD")
+
+	// Images lockfile.
+	ImagesLockFile = filepath.Join(ErisRoot, "images.lock")
+
+	// Host port reservations.
+	PortsFile = filepath.Join(ErisRoot, "ports.json")
+
+	// Installed contract bundles index.
+	BundlesIndexFile = filepath.Join(BundlesPath, "index.json")
+
+	// Per chain package deployment records.
+	LedgerPath = filepath.Join(ErisRoot, "ledger")
+
+	// Root layout schema version file and migration backups.
+	SchemaFile  = filepath.Join(ErisRoot, "SCHEMA")
+	BackupsPath = filepath.Join(ErisRoot, "backups")
+
 	// Keys directories.
 	KeysDataPath      = filepath.Join(KeysPath, "data")
 	KeysNamesPath     = filepath.Join(KeysPath, "names")
@@ -47,10 +72,6 @@ var (
 	SerpScratchPath      = filepath.Join(LanguagesScratchPath, "ser")
 )
 
-// DirsToMigrate is used by the `eris init` command to check
-// if old dirs exist to migrate them.
-var DirsToMigrate = map[string]string{}
-
 func HomeDir() string {
 	if runtime.GOOS == "windows" {
 		drive := os.Getenv("HOMEDRIVE")
@@ -74,11 +95,14 @@ func ChangeErisRoot(erisDir string) {
 	ErisRoot = erisDir
 
 	// Major directories.
-	AppsPath = filepath.Join(ErisRoot, "apps")     // previously "dapps"
+	AppsPath = filepath.Join(ErisRoot, "apps") // previously "dapps"
+	BundlesPath = filepath.Join(ErisRoot, "bundles")
 	ChainsPath = filepath.Join(ErisRoot, "chains") // previously "blockchains"
 	KeysPath = filepath.Join(ErisRoot, "keys")
+	ProfilesPath = filepath.Join(ErisRoot, "profiles")
 	RemotesPath = filepath.Join(ErisRoot, "remotes")
 	ScratchPath = filepath.Join(ErisRoot, "scratch")
+	SecretsPath = filepath.Join(ErisRoot, "secrets")
 	ServicesPath = filepath.Join(ErisRoot, "services")
 
 	// Chains Directories
@@ -86,6 +110,25 @@ func ChangeErisRoot(erisDir string) {
 	ChainTypePath = filepath.Join(ChainsPath, "chain-types")
 	HEAD = filepath.Join(ChainsPath, "HEAD")
 
+	// Profiles Directories
+	ProfileHEAD = filepath.Join(ProfilesPath, "HEAD")
+
+	// Images lockfile
+	ImagesLockFile = filepath.Join(ErisRoot, "images.lock")
+
+	// Host port reservations
+	PortsFile = filepath.Join(ErisRoot, "ports.json")
+
+	// Installed contract bundles index
+	BundlesIndexFile = filepath.Join(BundlesPath, "index.json")
+
+	// Per chain package deployment records
+	LedgerPath = filepath.Join(ErisRoot, "ledger")
+
+	// Schema version file and migration backups
+	SchemaFile = filepath.Join(ErisRoot, "SCHEMA")
+	BackupsPath = filepath.Join(ErisRoot, "backups")
+
 	// Keys Directories
 	KeysDataPath = filepath.Join(KeysPath, "data")
 	KeysNamesPath = filepath.Join(KeysPath, "names")
@@ -132,6 +175,16 @@ func ResolveErisRoot() string {
 	return eris
 }
 
+// ResolveSecretsKeyFile returns the location of the secrets store key
+// file: the $ERIS_SECRETS_KEY_FILE environment variable or the
+// .eris_secrets_key file in the user's home directory.
+func ResolveSecretsKeyFile() string {
+	if file := os.Getenv("ERIS_SECRETS_KEY_FILE"); file != "" {
+		return file
+	}
+	return filepath.Join(HomeDir(), ".eris_secrets_key")
+}
+
 // InitErisDir creates an Eris directory hierarchy under ErisRoot dir.
 func InitErisDir() (err error) {
 	for _, d := range []string{
@@ -144,6 +197,7 @@ func InitErisDir() (err error) {
 		KeysPath,
 		KeysDataPath,
 		KeysNamesPath,
+		ProfilesPath,
 		RemotesPath,
 		ScratchPath,
 		DataContainersPath,
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultProfile is the name of the implicit profile consisting
+	// of built-in defaults and the "eris.toml" definition file only.
+	DefaultProfile = "default"
+
+	// ProfileEnvVar is the environment variable which overrides
+	// the profile checked out with the [eris config use] command.
+	ProfileEnvVar = "ERIS_PROFILE"
+)
+
+// profileName matches the names allowed for profiles. Profile names
+// become file names in the ProfilesPath directory, so path separators
+// and glob characters are not allowed.
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// CheckProfileName returns an error if name cannot be used as
+// a profile name. Names consist of letters, digits, and the "_",
+// ".", and "-" characters; "." and ".." are not allowed.
+func CheckProfileName(name string) error {
+	if !profileName.MatchString(name) || name == "." || name == ".." {
+		return fmt.Errorf("Bad profile name %q. Use letters, digits, and the _ . - characters", name)
+	}
+	return nil
+}
+
+// SettingSource describes a single setting value and its origin:
+// the profile it was set by (DefaultProfile for built-in and "eris.toml"
+// values, empty for project values) and the file it was read from
+// (empty for built-in defaults).
+type SettingSource struct {
+	Key     string
+	Value   interface{}
+	Profile string
+	File    string
+}
+
+// ActiveProfile returns the name of the profile currently in effect.
+// The ERIS_PROFILE environment variable takes precedence over the profile
+// checked out with the [eris config use] command. DefaultProfile is
+// returned if neither is set.
+func ActiveProfile() string {
+	if profile := os.Getenv(ProfileEnvVar); profile != "" {
+		return profile
+	}
+
+	head, err := ioutil.ReadFile(ProfileHEAD)
+	if err != nil {
+		return DefaultProfile
+	}
+	if profile := strings.TrimSpace(string(head)); profile != "" {
+		return profile
+	}
+	return DefaultProfile
+}
+
+// UseProfile checks out the profile name, so that all subsequent
+// commands use its settings. Checking out DefaultProfile (or an empty
+// name) clears the previously checked out profile.
+func UseProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		if err := os.Remove(ProfileHEAD); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("Unable to find the %q profile: %v\n\nList available profiles with the [eris config ls] command", name, os.ErrNotExist)
+	}
+
+	return WriteFile(name, ProfileHEAD)
+}
+
+// ProfileExists returns true if a definition file for
+// the profile name exists in the ProfilesPath directory.
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	if CheckProfileName(name) != nil {
+		return false
+	}
+	matches, _ := filepath.Glob(filepath.Join(ProfilesPath, name+".*"))
+	return len(matches) != 0
+}
+
+// Profiles returns a sorted list of profile names
+// found in the ProfilesPath directory.
+func Profiles() []string {
+	profiles := []string{}
+	for _, ext := range []string{"*.json", "*.yaml", "*.toml"} {
+		matches, _ := filepath.Glob(filepath.Join(ProfilesPath, ext))
+		for _, match := range matches {
+			profiles = append(profiles, strings.TrimSuffix(filepath.Base(match), filepath.Ext(match)))
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// LoadProfile reads the "eris.toml" definition file from the default
+// location and layers the settings of the profile name on top of it.
+// DefaultProfile or an empty name loads "eris.toml" settings only.
+func LoadProfile(name string) (*viper.Viper, error) {
+	config, err := Load()
+	if err != nil {
+		return config, err
+	}
+
+	if name == "" || name == DefaultProfile {
+		return config, nil
+	}
+	if err := CheckProfileName(name); err != nil {
+		return config, err
+	}
+
+	profile, err := LoadViper(ProfilesPath, name)
+	if err != nil {
+		return config, err
+	}
+	for _, key := range profile.AllKeys() {
+		config.Set(key, profile.Get(key))
+	}
+
+	return config, nil
+}
+
+// LoadSettings returns settings from the "eris.toml" definition file
+// with the profile name layered on top of it.
+func LoadSettings(name string) (*Settings, error) {
+	v, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &Settings{}
+	if err := v.Unmarshal(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ApplyProfile reloads c settings with the profile name layered
+// on top of the "eris.toml" definition file. Project settings,
+// if any, still take precedence over the profile.
+func (c *Config) ApplyProfile(name string) error {
+	v, err := LoadProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if c.Project != nil {
+		_, projectViper, err := LoadProject(c.Project.Path)
+		if err != nil {
+			return err
+		}
+		applyProject(v, projectViper)
+	}
+
+	settings := Settings{}
+	if err := v.Unmarshal(&settings); err != nil {
+		return err
+	}
+
+	c.Profile = name
+	c.Settings = settings
+	return nil
+}
+
+// ProfileSources returns every setting loadable from definition files
+// (with the profile name and the project, if not nil, applied), along
+// with the profile and the file the setting value came from.
+func ProfileSources(name string, project *Project) ([]SettingSource, error) {
+	v, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	projectViper := viper.New()
+	if project != nil {
+		if _, projectViper, err = LoadProject(project.Path); err != nil {
+			return nil, err
+		}
+		applyProject(v, projectViper)
+	}
+
+	base := viper.New()
+	base.AddConfigPath(ErisRoot)
+	base.SetConfigName("eris")
+	baseFile := ""
+	if err := base.ReadInConfig(); err == nil {
+		baseFile = base.ConfigFileUsed()
+	}
+
+	profile := viper.New()
+	profileFile := ""
+	if name != "" && name != DefaultProfile {
+		profile.AddConfigPath(ProfilesPath)
+		profile.SetConfigName(name)
+		if err := profile.ReadInConfig(); err == nil {
+			profileFile = profile.ConfigFileUsed()
+		}
+	}
+
+	sources := []SettingSource{}
+	for _, key := range SettingsKeys() {
+		source := SettingSource{
+			Key:     key,
+			Value:   v.Get(key),
+			Profile: DefaultProfile,
+		}
+
+		switch {
+		case project != nil && projectViper.InConfig(strings.ToLower(key)):
+			source.Profile = ""
+			source.File = project.File
+		case profileFile != "" && profile.InConfig(strings.ToLower(key)):
+			source.Profile = name
+			source.File = profileFile
+		case baseFile != "" && base.InConfig(strings.ToLower(key)):
+			source.File = baseFile
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// SettingsKeys returns the names of settings loadable
+// from definition files in the order they are declared.
+func SettingsKeys() []string {
+	keys := []string{}
+
+	t := reflect.TypeOf(Settings{})
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		if key == "" {
+			key = t.Field(i).Name
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
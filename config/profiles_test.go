@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestActiveProfileDefault(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	os.MkdirAll(configErisDir, 0755)
+	defer removeErisDir()
+
+	if expected, returned := DefaultProfile, ActiveProfile(); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeProfile("ci", `DockerHost = "tcp://ci:2376"`)
+	defer removeErisDir()
+
+	if err := UseProfile("ci"); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected, returned := "ci", ActiveProfile(); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+
+	if err := UseProfile(""); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected, returned := DefaultProfile, ActiveProfile(); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+}
+
+func TestUseProfileNonExistent(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	os.MkdirAll(configErisDir, 0755)
+	defer removeErisDir()
+
+	if err := UseProfile("non-existent"); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+}
+
+func TestActiveProfileEnv(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeProfile("ci", ``)
+	placeProfile("local", ``)
+	defer removeErisDir()
+
+	if err := UseProfile("local"); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	os.Setenv(ProfileEnvVar, "ci")
+	defer os.Unsetenv(ProfileEnvVar)
+
+	if expected, returned := "ci", ActiveProfile(); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeProfile("staging-box", ``)
+	placeProfile("ci", ``)
+	defer removeErisDir()
+
+	if expected, returned := []string{"ci", "staging-box"}, Profiles(); reflect.DeepEqual(expected, returned) != true {
+		t.Fatalf("expected %v, got %v", expected, returned)
+	}
+}
+
+func TestLoadSettingsProfile(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeSettings(`
+IpfsHost = "foo"
+DockerHost = "bar"
+`)
+	placeProfile("ci", `DockerHost = "baz"`)
+	defer removeErisDir()
+
+	settings, err := LoadSettings("ci")
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected, returned := "foo", settings.IpfsHost; expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+	if expected, returned := "baz", settings.DockerHost; expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+
+	settings, err = LoadSettings(DefaultProfile)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected, returned := "bar", settings.DockerHost; expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+}
+
+func TestLoadSettingsProfileNonExistent(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeSettings(``)
+	defer removeErisDir()
+
+	if _, err := LoadSettings("non-existent"); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+}
+
+func TestProfileSources(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeSettings(`IpfsHost = "foo"`)
+	placeProfile("ci", `DockerHost = "baz"`)
+	defer removeErisDir()
+
+	sources, err := ProfileSources("ci", nil)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	expected := map[string]SettingSource{
+		"IpfsHost":    {Key: "IpfsHost", Value: "foo", Profile: DefaultProfile, File: filepath.Join(configErisDir, "eris.toml")},
+		"DockerHost":  {Key: "DockerHost", Value: "baz", Profile: "ci", File: filepath.Join(ProfilesPath, "ci.toml")},
+		"CrashReport": {Key: "CrashReport", Value: "bugsnag", Profile: DefaultProfile, File: ""},
+	}
+	for _, source := range sources {
+		if want, ok := expected[source.Key]; ok && reflect.DeepEqual(want, source) != true {
+			t.Fatalf("expected %#v, got %#v", want, source)
+		}
+	}
+}
+
+func placeProfile(name, definition string) {
+	os.MkdirAll(ProfilesPath, 0755)
+	fakeDefinitionFile(ProfilesPath, name, definition)
+}
+
+func TestCheckProfileName(t *testing.T) {
+	for _, name := range []string{"ci", "staging-box", "local_2", "v1.0"} {
+		if err := CheckProfileName(name); err != nil {
+			t.Fatalf("expected %q allowed, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../ci", "ci/local", "c*", "ci?", "[ci]"} {
+		if err := CheckProfileName(name); err == nil {
+			t.Fatalf("expected %q rejected", name)
+		}
+	}
+}
+
+func TestUseProfileBadName(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeProfile("ci", ``)
+	defer removeErisDir()
+
+	if err := UseProfile("c*"); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+	if ProfileExists("../profiles/ci") {
+		t.Fatal("expected path in the profile name rejected")
+	}
+}
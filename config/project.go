@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Project describes settings loadable from a project-level "eris.toml"
+// definition file. Besides the project specific fields below, the file
+// can carry any of the global settings (see Settings), which are then
+// layered on top of the "eris.toml" file in the Eris root directory and
+// the active profile.
+type Project struct {
+	// Path is the directory the project definition file was found in.
+	Path string `json:"-" yaml:"-" toml:"-"`
+	// File is the full path to the project definition file.
+	File string `json:"-" yaml:"-" toml:"-"`
+
+	Chain       string   `json:"Chain,omitempty" yaml:"Chain,omitempty" toml:"Chain,omitempty"`
+	Services    []string `json:"Services,omitempty" yaml:"Services,omitempty" toml:"Services,omitempty"`
+	PackagePath string   `json:"PackagePath,omitempty" yaml:"PackagePath,omitempty" toml:"PackagePath,omitempty"`
+
+	// [eris pkgs do] defaults.
+	DefaultGas  string `json:"DefaultGas,omitempty" yaml:"DefaultGas,omitempty" toml:"DefaultGas,omitempty"`
+	DefaultAddr string `json:"DefaultAddr,omitempty" yaml:"DefaultAddr,omitempty" toml:"DefaultAddr,omitempty"`
+	Compiler    string `json:"Compiler,omitempty" yaml:"Compiler,omitempty" toml:"Compiler,omitempty"`
+}
+
+// FindProject looks for a project-level "eris.toml" (or .json, .yaml)
+// definition file in the dir directory and upward through its parents.
+// The Eris root directory is skipped, since its "eris.toml" file holds
+// global settings. FindProject returns the directory the file was found
+// in or an empty string if there is no project.
+func FindProject(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if filepath.Clean(dir) != filepath.Clean(ErisRoot) {
+			if matches, _ := filepath.Glob(filepath.Join(dir, "eris.*")); len(matches) != 0 {
+				for _, match := range matches {
+					switch filepath.Ext(match) {
+					case ".toml", ".json", ".yaml":
+						return dir
+					}
+				}
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads the project definition file from the dir directory.
+// The returned Viper struct can be used to layer global settings defined
+// in the project file on top of other settings.
+func LoadProject(dir string) (*Project, *viper.Viper, error) {
+	v, err := LoadViper(dir, "eris")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	project := &Project{
+		Path: dir,
+		File: v.ConfigFileUsed(),
+	}
+	if err := v.Unmarshal(project); err != nil {
+		return nil, nil, err
+	}
+
+	return project, v, nil
+}
+
+// ProjectHEAD returns the path to the file holding the chain checked out
+// for the project. Projects keep their own checked out chains, so that
+// working on several projects on one machine doesn't interfere.
+func (p *Project) ProjectHEAD() string {
+	return filepath.Join(p.Path, ".eris", "HEAD")
+}
+
+// AbsolutePath resolves the path relative to the project directory.
+func (p *Project) AbsolutePath(path string) string {
+	return AbsolutePath(p.Path, path)
+}
+
+// applyProject layers global settings defined in the project
+// definition file on top of the v settings.
+func applyProject(v *viper.Viper, project *viper.Viper) {
+	for _, key := range SettingsKeys() {
+		if project.InConfig(strings.ToLower(key)) {
+			v.Set(key, project.Get(key))
+		}
+	}
+}
+
+// discoverProject loads the project the current working directory
+// belongs to, if any.
+func discoverProject() (*Project, *viper.Viper, error) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	dir := FindProject(pwd)
+	if dir == "" {
+		return nil, nil, nil
+	}
+
+	return LoadProject(dir)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindProject(t *testing.T) {
+	ChangeErisRoot(filepath.Join(configErisDir, "root"))
+	project := filepath.Join(configErisDir, "project")
+	nested := filepath.Join(project, "contracts", "nested")
+	os.MkdirAll(nested, 0755)
+	fakeDefinitionFile(project, "eris", `Chain = "mychain"`)
+	defer removeErisDir()
+
+	if expected, returned := project, FindProject(nested); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+	if expected, returned := project, FindProject(project); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+}
+
+func TestFindProjectSkipsErisRoot(t *testing.T) {
+	ChangeErisRoot(configErisDir)
+	placeSettings(`IpfsHost = "foo"`)
+	os.MkdirAll(filepath.Join(configErisDir, "apps"), 0755)
+	defer removeErisDir()
+
+	if returned := FindProject(filepath.Join(configErisDir, "apps")); returned == configErisDir {
+		t.Fatalf("expected Eris root skipped, got %q", returned)
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	ChangeErisRoot(filepath.Join(configErisDir, "root"))
+	os.MkdirAll(configErisDir, 0755)
+	fakeDefinitionFile(configErisDir, "eris", `
+Chain = "mychain"
+Services = ["ipfs", "keys"]
+PackagePath = "./contracts"
+DefaultGas = "1000"
+DefaultAddr = "ADDR"
+Compiler = "http://compilers:9099"
+DockerHost = "tcp://project:2376"
+`)
+	defer removeErisDir()
+
+	project, v, err := LoadProject(configErisDir)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	expected := &Project{
+		Path:        configErisDir,
+		File:        filepath.Join(configErisDir, "eris.toml"),
+		Chain:       "mychain",
+		Services:    []string{"ipfs", "keys"},
+		PackagePath: "./contracts",
+		DefaultGas:  "1000",
+		DefaultAddr: "ADDR",
+		Compiler:    "http://compilers:9099",
+	}
+	if reflect.DeepEqual(expected, project) != true {
+		t.Fatalf("expected %#v, got %#v", expected, project)
+	}
+
+	if expected, returned := filepath.Join(configErisDir, "contracts"), project.AbsolutePath(project.PackagePath); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+
+	settings, err := SetDefaults()
+	if err != nil {
+		t.Fatalf("expected defaults loaded, got error %v", err)
+	}
+	applyProject(settings, v)
+	if expected, returned := "tcp://project:2376", settings.GetString("DockerHost"); expected != returned {
+		t.Fatalf("expected %q, got %q", expected, returned)
+	}
+	if settings.IsSet("Chain") {
+		t.Fatalf("expected project fields not to leak into settings")
+	}
+}
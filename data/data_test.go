@@ -151,6 +151,39 @@ func TestRmData(t *testing.T) {
 	testExist(t, dataName, false)
 }
 
+func TestPruneData(t *testing.T) {
+	testCreateDataByImport(t, dataName)
+	defer testKillDataCont(t, dataName)
+
+	orphans, err := Orphans()
+	if err != nil {
+		t.Fatalf("expected orphans to be found, got %v", err)
+	}
+	found := false
+	for _, orphan := range orphans {
+		if orphan.Name == dataName {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %q to be an orphan", dataName)
+	}
+
+	do := definitions.NowDo()
+	do.DryRun = true
+	if err := PruneData(do); err != nil {
+		t.Fatalf("expected dry run to succeed, got %v", err)
+	}
+	testExist(t, dataName, true)
+
+	do = definitions.NowDo()
+	do.Yes = true
+	if err := PruneData(do); err != nil {
+		t.Fatalf("expected prune to succeed, got %v", err)
+	}
+	testExist(t, dataName, false)
+}
+
 //creates a new data container w/ dir to be used by a test
 //maybe give create opts? => paths, files, file contents, etc
 func testCreateDataByImport(t *testing.T, name string) {
@@ -0,0 +1,147 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Orphan describes an orphaned data container.
+type Orphan struct {
+	Name     string
+	ID       string
+	Size     int64 // -1 if unknown
+	LastUsed time.Time
+}
+
+// Orphans returns data containers with no chain or service they belong
+// to (see util.OrphanFilter), sorted by name.
+func Orphans() ([]*Orphan, error) {
+	sizes := make(map[string]int64)
+	containers, err := util.DockerClient.ListContainers(docker.ListContainersOptions{All: true, Size: true})
+	if err != nil {
+		return nil, util.DockerError(err)
+	}
+	for _, c := range containers {
+		sizes[c.ID] = c.SizeRw
+	}
+
+	isOrphan := util.OrphanFilter()
+
+	orphans := []*Orphan{}
+	util.ErisContainers(func(name string, details *util.Details) bool {
+		if details.Type != definitions.TypeData || !isOrphan(details) {
+			return false
+		}
+
+		orphan := &Orphan{
+			Name:     details.ShortName,
+			ID:       details.Info.ID,
+			Size:     sizes[details.Info.ID],
+			LastUsed: details.Info.Created,
+		}
+
+		// Data containers only run for imports, exports, and execs.
+		if details.Info.State.FinishedAt.After(orphan.LastUsed) {
+			orphan.LastUsed = details.Info.State.FinishedAt
+		}
+
+		// The data is kept in volumes.
+		for _, mount := range details.Info.Mounts {
+			size, err := util.DirSize(mount.Source)
+			if err != nil {
+				orphan.Size = -1
+				break
+			}
+			orphan.Size += size
+		}
+
+		orphans = append(orphans, orphan)
+		return true
+	}, false)
+
+	sort.Sort(byName(orphans))
+	return orphans, nil
+}
+
+// PruneData removes orphaned data containers (see Orphans)
+// along with their volumes after confirmation.
+//
+//  do.Yes    - don't ask for confirmation
+//  do.DryRun - only list the orphaned data containers
+//  do.RmHF   - remove the data containers' scratch directories as well
+//
+func PruneData(do *definitions.Do) error {
+	orphans, err := Orphans()
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		log.Warn("No orphaned data containers found")
+		return nil
+	}
+
+	if err := printOrphans(orphans); err != nil {
+		return err
+	}
+	if do.DryRun {
+		return nil
+	}
+
+	if !do.Yes && util.QueryYesOrNo(fmt.Sprintf("Remove %d orphaned data containers?", len(orphans))) != util.Yes {
+		log.Warn("Authorization not given, exiting")
+		return nil
+	}
+
+	for _, orphan := range orphans {
+		log.WithField("=>", orphan.Name).Warn("Removing data container")
+		if err := util.DockerClient.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            orphan.ID,
+			RemoveVolumes: true,
+			Force:         true,
+		}); err != nil {
+			return fmt.Errorf("Cannot remove data container %s: %v", orphan.Name, util.DockerError(err))
+		}
+
+		if do.RmHF {
+			log.WithField("=>", orphan.Name).Warn("Removing host directory")
+			if err := os.RemoveAll(filepath.Join(config.DataContainersPath, orphan.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func printOrphans(orphans []*Orphan) error {
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tLAST USED")
+	for _, orphan := range orphans {
+		size := "unknown"
+		if orphan.Size >= 0 {
+			size = units.HumanSize(float64(orphan.Size))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s ago\n", orphan.Name, size, units.HumanDuration(time.Since(orphan.LastUsed)))
+	}
+	return tw.Flush()
+}
+
+type byName []*Orphan
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
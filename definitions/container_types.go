@@ -5,6 +5,7 @@ const (
 
 	LabelEris      = Namespace + ":" + "ERIS"
 	LabelShortName = Namespace + ":" + "NAME"
+	LabelStack     = Namespace + ":" + "STACK"
 	LabelType      = Namespace + ":" + "TYPE"
 	LabelService   = Namespace + ":" + "SERVICE"
 	LabelSwarm     = Namespace + ":" + "SWARM"
@@ -13,8 +14,13 @@ const (
 	LabelID        = Namespace + ":" + "ID"
 	LabelTest      = Namespace + ":" + "TEST"
 	LabelTestID    = Namespace + ":" + "TEST_ID"
+	LabelSecrets   = Namespace + ":" + "SECRETS"
+	LabelThrowaway = Namespace + ":" + "THROWAWAY"
 
 	TypeChain   = "chain"
 	TypeService = "service"
 	TypeData    = "data"
 )
+
+// ThrowawayPrefix starts the names of throwaway chains.
+const ThrowawayPrefix = "throwaway-"
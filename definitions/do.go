@@ -28,6 +28,10 @@ type Do struct {
 	LocalCompiler bool     `mapstructure:"," json:"," yaml:"," toml:","`
 	Save          bool     `mapstructure:"," json:"," yaml:"," toml:","`
 	Wizard        bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	DryRun        bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	Apply         bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	Throwaway     bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	Restart       bool     `mapstructure:"," json:"," yaml:"," toml:","`
 	Lines         int      `mapstructure:"," json:"," yaml:"," toml:","`
 	Timeout       uint     `mapstructure:"," json:"," yaml:"," toml:","`
 	N             uint     `mapstructure:"," json:"," yaml:"," toml:","`
@@ -40,9 +44,12 @@ type Do struct {
 	ChainType     string   `mapstructure:"," json:"," yaml:"," toml:","`
 	GenesisFile   string   `mapstructure:"," json:"," yaml:"," toml:","`
 	Hash          string   `mapstructure:"," json:"," yaml:"," toml:","`
+	Bundle        string   `mapstructure:"," json:"," yaml:"," toml:","`
 	Gateway       string   `mapstructure:"," json:"," yaml:"," toml:","`
 	MachineName   string   `mapstructure:"," json:"," yaml:"," toml:","`
+	Profile       string   `mapstructure:"," json:"," yaml:"," toml:","`
 	Name          string   `mapstructure:"," json:"," yaml:"," toml:","`
+	Value         string   `mapstructure:"," json:"," yaml:"," toml:","`
 	Image         string   `mapstructure:"," json:"," yaml:"," toml:","`
 	Path          string   `mapstructure:"," json:"," yaml:"," toml:","`
 	CSV           string   `mapstructure:"," json:"," yaml:"," toml:","`
@@ -67,6 +74,7 @@ type Do struct {
 	ImagesSlice   []string `mapstructure:"," json:"," yaml:"," toml:","`
 	ConfigOpts    []string `mapstructure:"," json:"," yaml:"," toml:","`
 	AccountTypes  []string `mapstructure:"," json:"," yaml:"," toml:","`
+	Seeds         []string `mapstructure:"," json:"," yaml:"," toml:","`
 
 	//clean
 	Containers bool `mapstructure:"," json:"," yaml:"," toml:","`
@@ -76,6 +84,13 @@ type Do struct {
 	Uninstall  bool `mapstructure:"," json:"," yaml:"," toml:","`
 	Volumes    bool `mapstructure:"," json:"," yaml:"," toml:","`
 
+	//clean filters
+	Types     []string `mapstructure:"," json:"," yaml:"," toml:","`
+	Stopped   bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	Orphans   bool     `mapstructure:"," json:"," yaml:"," toml:","`
+	OlderThan string   `mapstructure:"," json:"," yaml:"," toml:","`
+	Stack     string   `mapstructure:"," json:"," yaml:"," toml:","`
+
 	//data import/export
 	Source      string `mapstructure:"," json:"," yaml:"," toml:","`
 	Destination string `mapstructure:"," json:"," yaml:"," toml:","`
@@ -84,6 +99,7 @@ type Do struct {
 	Known     bool `mapstructure:"," json:"," yaml:"," toml:","`
 	Running   bool `mapstructure:"," json:"," yaml:"," toml:","`
 	Existing  bool `mapstructure:"," json:"," yaml:"," toml:","`
+	Stacks    bool `mapstructure:"," json:"," yaml:"," toml:","`
 	Host      bool `mapstructure:"," json:"," yaml:"," toml:","` //keys ls
 	Container bool `mapstructure:"," json:"," yaml:"," toml:","` //keys ls
 
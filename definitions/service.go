@@ -3,8 +3,11 @@ package definitions
 type Service struct {
 	// name of the service
 	Name string `json:"name" yaml:"name" toml:"name"`
-	// docker image used by the service
+	// docker image used by the service (the repository to tag
+	// the built image with if the build section is given)
 	Image string `json:"image,omitempty" yaml:"image,omitempty" toml:"image,omitempty"`
+	// build the image from a local context instead of pulling it
+	Build *Build `mapstructure:"build" json:"build,omitempty" yaml:"build,omitempty" toml:"build,omitempty"`
 	// whether eris should automagically handle a data container for this service
 	AutoData bool `json:"data_container" yaml:"data_container" toml:"data_container"`
 	// restart policy: "always" or "max:<#attempts>"
@@ -23,6 +26,9 @@ type Service struct {
 	VolumesFrom []string `mapstructure:"volumes_from" json:"volumes_from,omitempty" yaml:"volumes_from,omitempty" toml:"volumes_from,omitempty"`
 	// maps directly to docker environment
 	Environment []string `json:"environment,omitempty" yaml:"environment,omitempty" toml:"environment,omitempty"`
+	// environment variables set from the secrets store ([eris secrets set]):
+	// variable name -> secret name
+	Secrets map[string]string `mapstructure:"secrets" json:"secrets,omitempty" yaml:"secrets,omitempty" toml:"secrets,omitempty"`
 	// maps directly to docker env-file
 	EnvFile []string `mapstructure:"env_file" json:"env_file,omitempty" yaml:"env_file,omitempty" toml:"env_file,omitempty"`
 	// maps directly to docker net
@@ -46,10 +52,23 @@ type Service struct {
 	// maps directly to docker cpu_shares
 	CPUShares int64 `mapstructure:"cpu_shares" json:"cpu_shares,omitempty,omitzero" yaml:"cpu_shares,omitempty" toml:"cpu_shares,omitempty,omitzero"`
 	// maps directly to docker mem_limit
-	MemLimit int64 `mapstructure:"mem_limit" json:"memory,omitempty,omitzero" yaml:"memory,omitempty" toml:"memory,omitempty,omitzero"`
+	MemLimit int64 `mapstructure:"mem_limit" json:"mem_limit,omitempty,omitzero" yaml:"mem_limit,omitempty" toml:"mem_limit,omitempty,omitzero"`
 
 	// an env variable to set for when we are running `eris exec` so we can find the main container
-	ExecHost string `mapstructure:"exec_host" json:"memory,omitempty,omitzero" yaml:"memory,omitempty" toml:"memory,omitempty,omitzero"`
+	ExecHost string `mapstructure:"exec_host" json:"exec_host,omitempty" yaml:"exec_host,omitempty" toml:"exec_host,omitempty"`
+}
+
+// Build describes how to build a service image from a local build context.
+type Build struct {
+	// build context directory; relative paths are relative
+	// to the service definition files directory
+	Context string `json:"context" yaml:"context" toml:"context"`
+	// Dockerfile path relative to the context ("Dockerfile" by default)
+	Dockerfile string `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty" toml:"dockerfile,omitempty"`
+	// values for the Dockerfile ARG instructions
+	Args map[string]string `mapstructure:"args" json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty"`
+	// multi-stage build stage to build (the last one by default)
+	Target string `json:"target,omitempty" yaml:"target,omitempty" toml:"target,omitempty"`
 }
 
 func BlankService() *Service {
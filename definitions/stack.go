@@ -0,0 +1,44 @@
+package definitions
+
+type Stack struct {
+	// name of the stack (defaults to the stack file directory name)
+	Name string `mapstructure:"name" json:"name" yaml:"name" toml:"name"`
+	// chain to start (and possibly make) first
+	Chain *StackChain `mapstructure:"chain" json:"chain,omitempty" yaml:"chain,omitempty" toml:"chain,omitempty"`
+	// services to start after the chain
+	Services []string `mapstructure:"services" json:"services,omitempty" yaml:"services,omitempty" toml:"services,omitempty"`
+	// packages to deploy to the chain after the services are started
+	Packages []*StackPackage `mapstructure:"packages" json:"packages,omitempty" yaml:"packages,omitempty" toml:"packages,omitempty"`
+	// environment variables overrides for the chain and services containers
+	// (services started as dependencies of the stack services don't get them)
+	Environment map[string]string `mapstructure:"environment" json:"environment,omitempty" yaml:"environment,omitempty" toml:"environment,omitempty"`
+
+	// directory of the stack file (relative paths are resolved against it)
+	Path string `mapstructure:"-" json:"-" yaml:"-" toml:"-"`
+}
+
+type StackChain struct {
+	// name of the chain
+	Name string `mapstructure:"name" json:"name" yaml:"name" toml:"name"`
+	// chain type to make the chain with if it doesn't exist yet
+	Type string `mapstructure:"type" json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty"`
+	// account types to make the chain with if it doesn't exist yet
+	AccountTypes []string `mapstructure:"account_types" json:"account_types,omitempty" yaml:"account_types,omitempty" toml:"account_types,omitempty"`
+	// chain directory to start the chain from (the same as [eris chains start --init-dir])
+	InitDir string `mapstructure:"init_dir" json:"init_dir,omitempty" yaml:"init_dir,omitempty" toml:"init_dir,omitempty"`
+}
+
+type StackPackage struct {
+	// root directory of the package
+	Path string `mapstructure:"path" json:"path" yaml:"path" toml:"path"`
+	// package file relative to the package root (defaults to epm.yaml)
+	File string `mapstructure:"file" json:"file,omitempty" yaml:"file,omitempty" toml:"file,omitempty"`
+	// address to deploy from
+	Address string `mapstructure:"address" json:"address,omitempty" yaml:"address,omitempty" toml:"address,omitempty"`
+}
+
+func BlankStack() *Stack {
+	return &Stack{
+		Environment: make(map[string]string),
+	}
+}
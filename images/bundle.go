@@ -0,0 +1,330 @@
+package images
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/loaders"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+	"github.com/eris-ltd/eris-cli/version"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// Bundle archive entries.
+	manifestEntry = "manifest.json"
+	imagesEntry   = "images.tar"
+	servicesEntry = "services"
+)
+
+// Manifest describes the contents of an images bundle.
+type Manifest struct {
+	// Eris version the bundle was made with.
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+
+	Images []BundleImage `json:"images"`
+
+	// Service definition files included in the bundle.
+	Services []string `json:"services,omitempty"`
+}
+
+// BundleImage is an image included in a bundle.
+type BundleImage struct {
+	// Default image name (see util.DefaultImageNames) or service name.
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	ID    string `json:"id"`
+}
+
+// SaveBundle writes images and service definition files into a single
+// gzipped tar archive (a bundle) to be loaded on machines with no access
+// to image registries with LoadBundle. Images missing locally are pulled
+// first.
+//
+//  do.ImagesSlice - default image names (see util.DefaultImageNames) or service names
+//                   to save (defaults to all default images)
+//  do.All         - save all default images and images of all known services
+//  do.Path        - archive file name (required)
+//
+func SaveBundle(do *definitions.Do) error {
+	names := do.ImagesSlice
+	if do.All || len(names) == 0 {
+		names = util.DefaultImageNames
+	}
+	if do.All {
+		names = append(names, util.GetGlobalLevelConfigFilesByType("services", false)...)
+	}
+
+	manifest := Manifest{
+		Version: version.VERSION,
+		Created: time.Now().UTC(),
+	}
+	saved := make(map[string]bool)
+	for _, name := range names {
+		image := util.DefaultImage(name)
+		if image == "" {
+			srv, err := loaders.LoadServiceDefinition(name)
+			if err != nil {
+				return err
+			}
+			if srv.Service.Build != nil {
+				log.WithField("=>", name).Warn("Skipping service built from a local context")
+				continue
+			}
+			image = srv.Service.Image
+			manifest.Services = append(manifest.Services, filepath.Base(util.GetFileByNameAndType("services", name)))
+		}
+		image = util.ImageReference(image)
+
+		if saved[image] {
+			continue
+		}
+		saved[image] = true
+
+		info, err := util.DockerClient.InspectImage(image)
+		if err != nil {
+			log.WithField("=>", image).Warn("Pulling image")
+			if err := util.PullImage(image, ioutil.Discard); err != nil {
+				return fmt.Errorf("Cannot pull image %s: %v", image, err)
+			}
+			if info, err = util.DockerClient.InspectImage(image); err != nil {
+				return util.DockerError(err)
+			}
+		}
+
+		manifest.Images = append(manifest.Images, BundleImage{
+			Name:  name,
+			Image: image,
+			ID:    info.ID,
+		})
+	}
+
+	return writeBundle(do.Path, &manifest)
+}
+
+// LoadBundle loads images from a bundle archive made with SaveBundle into
+// the Docker daemon and verifies them against the bundle manifest. Service
+// definition files from the bundle are copied to config.ServicesPath unless
+// files with the same names already exist there.
+//
+//  do.Path - archive file name (required)
+//
+func LoadBundle(do *definitions.Do) error {
+	file, err := os.Open(do.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("Cannot read bundle %s: %v", do.Path, err)
+	}
+	defer archive.Close()
+
+	var (
+		manifest *Manifest
+		loaded   bool
+	)
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Cannot read bundle %s: %v", do.Path, err)
+		}
+
+		switch {
+		case header.Name == manifestEntry:
+			manifest = new(Manifest)
+			if err := json.NewDecoder(reader).Decode(manifest); err != nil {
+				return fmt.Errorf("Cannot read bundle manifest: %v", err)
+			}
+		case header.Name == imagesEntry:
+			log.WithField("=>", do.Path).Warn("Loading images (this can take a while)")
+			if err := util.DockerClient.LoadImage(docker.LoadImageOptions{InputStream: reader}); err != nil {
+				return util.DockerError(err)
+			}
+			loaded = true
+		case path.Dir(header.Name) == servicesEntry && header.Typeflag == tar.TypeReg:
+			if err := loadServiceDefinition(path.Base(header.Name), reader); err != nil {
+				return err
+			}
+		default:
+			log.WithField("=>", header.Name).Warn("Skipping unknown bundle entry")
+		}
+	}
+
+	if manifest == nil || !loaded {
+		return fmt.Errorf("Bundle %s is incomplete: both %s and %s entries are required", do.Path, manifestEntry, imagesEntry)
+	}
+
+	for _, image := range manifest.Images {
+		info, err := util.DockerClient.InspectImage(image.Image)
+		if err != nil {
+			return fmt.Errorf("Image %s was not loaded from the bundle: %v", image.Image, util.DockerError(err))
+		}
+		if info.ID != image.ID {
+			return fmt.Errorf("Image %s loaded from the bundle has ID %s, expected %s", image.Image, info.ID, image.ID)
+		}
+	}
+
+	if manifest.Version != version.VERSION {
+		log.WithFields(log.Fields{
+			"bundle": manifest.Version,
+			"eris":   version.VERSION,
+		}).Warn("Bundle was made with a different Eris version")
+	}
+
+	return printManifest(manifest)
+}
+
+func writeBundle(fileName string, manifest *Manifest) error {
+	// Images tarball size has to be known in advance
+	// to write the tar header, so export into a temp file.
+	images, err := ioutil.TempFile("", "eris-images")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(images.Name())
+	defer images.Close()
+
+	names := []string{}
+	for _, image := range manifest.Images {
+		names = append(names, image.Image)
+	}
+
+	log.WithField("images", names).Warn("Exporting images (this can take a while)")
+	if err := util.DockerClient.ExportImages(docker.ExportImagesOptions{Names: names, OutputStream: images}); err != nil {
+		return util.DockerError(err)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := gzip.NewWriter(file)
+	writer := tar.NewWriter(archive)
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(writer, manifestEntry, int64(len(content)), bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	for _, service := range manifest.Services {
+		definition, err := os.Open(filepath.Join(config.ServicesPath, service))
+		if err != nil {
+			return err
+		}
+		info, err := definition.Stat()
+		if err != nil {
+			definition.Close()
+			return err
+		}
+		err = writeEntry(writer, path.Join(servicesEntry, service), info.Size(), definition)
+		definition.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := images.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := images.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := writeEntry(writer, imagesEntry, info.Size(), images); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	log.WithField("=>", fileName).Warn("Bundle saved")
+	return printManifest(manifest)
+}
+
+func writeEntry(writer *tar.Writer, name string, size int64, content io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(writer, content)
+	return err
+}
+
+func loadServiceDefinition(name string, content io.Reader) error {
+	file := filepath.Join(config.ServicesPath, name)
+	if util.DoesFileExist(file) {
+		log.WithField("=>", file).Info("Service definition file exists. Skipping")
+		return nil
+	}
+
+	log.WithField("=>", file).Info("Copying service definition file from the bundle")
+	if err := os.MkdirAll(config.ServicesPath, 0755); err != nil {
+		return err
+	}
+	writer, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	_, err = io.Copy(writer, content)
+	return err
+}
+
+func printManifest(manifest *Manifest) error {
+	images := manifest.Images
+	sort.Sort(byName(images))
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tIMAGE\tID")
+	for _, image := range images {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", image.Name, image.Image, strings.TrimPrefix(image.ID, "sha256:"))
+	}
+	return tw.Flush()
+}
+
+type byName []BundleImage
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
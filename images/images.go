@@ -0,0 +1,213 @@
+package images
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/loaders"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+	"github.com/eris-ltd/eris-cli/version"
+)
+
+// LockImages resolves images to their registry digests and adds them to
+// the images lockfile (config.ImagesLockFile). Images already present in
+// the lockfile are left untouched (use UpdateImages to refresh them).
+// Images missing locally are pulled first.
+//
+//  do.ImagesSlice - images to lock (defaults to all known images, see KnownImages)
+//
+func LockImages(do *definitions.Do) error {
+	images, err := imagesToProcess(do.ImagesSlice, false)
+	if err != nil {
+		return err
+	}
+
+	lock, err := util.LoadImagesLock()
+	if err != nil {
+		return err
+	}
+
+	status := make(map[string]string)
+	for _, image := range images {
+		if _, ok := lock[image]; ok {
+			status[image] = "locked"
+			continue
+		}
+
+		digest, err := resolve(image, false)
+		if err != nil {
+			return err
+		}
+		lock[image] = digest
+		status[image] = "added"
+	}
+
+	if err := util.SaveImagesLock(lock); err != nil {
+		return err
+	}
+	return printLock(lock, status)
+}
+
+// UpdateImages pulls images from the registry and records their new
+// digests in the images lockfile (config.ImagesLockFile).
+//
+//  do.ImagesSlice - images to update (defaults to all locked and known images)
+//
+func UpdateImages(do *definitions.Do) error {
+	images, err := imagesToProcess(do.ImagesSlice, true)
+	if err != nil {
+		return err
+	}
+
+	lock, err := util.LoadImagesLock()
+	if err != nil {
+		return err
+	}
+
+	status := make(map[string]string)
+	for _, image := range images {
+		digest, err := resolve(image, true)
+		if err != nil {
+			return err
+		}
+
+		switch locked, ok := lock[image]; {
+		case !ok:
+			status[image] = "added"
+		case locked != digest:
+			status[image] = "updated"
+		default:
+			status[image] = "unchanged"
+		}
+		lock[image] = digest
+	}
+
+	if err := util.SaveImagesLock(lock); err != nil {
+		return err
+	}
+	return printLock(lock, status)
+}
+
+// KnownImages returns a sorted list of images (in the REPOSITORY:TAG form)
+// used by Eris itself and by known services and chains.
+func KnownImages() []string {
+	unique := make(map[string]bool)
+	add := func(image string) {
+		if image != "" {
+			unique[util.ImageReference(image)] = true
+		}
+	}
+
+	for _, name := range util.DefaultImageNames {
+		add(util.DefaultImage(name))
+	}
+
+	// Chains without an image in the definition file use this one.
+	add(path.Join(version.DefaultRegistry, version.ImageDB))
+
+	// Known services.
+	for _, name := range util.GetGlobalLevelConfigFilesByType("services", false) {
+		srv, err := loaders.LoadServiceDefinition(name)
+		if err != nil {
+			log.WithField("=>", name).Warnf("Skipping service: %v", err)
+			continue
+		}
+		add(srv.Service.Image)
+	}
+
+	// Known chains (single node chains and chains made with [eris chains make]).
+	for _, pattern := range []string{
+		filepath.Join(config.ChainsPath, "*", "config.toml"),
+		filepath.Join(config.ChainsPath, "*", "*", "config.toml"),
+	} {
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			name := filepath.Base(filepath.Dir(file))
+			chain, err := loaders.LoadChainDefinition(name, strings.TrimSuffix(file, filepath.Ext(file)))
+			if err != nil {
+				log.WithField("=>", file).Warnf("Skipping chain: %v", err)
+				continue
+			}
+			add(chain.Service.Image)
+		}
+	}
+
+	images := []string{}
+	for image := range unique {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	return images
+}
+
+// imagesToProcess returns the images given in canonical form or, if none
+// given, all known images (and all locked images if withLocked is true).
+func imagesToProcess(given []string, withLocked bool) ([]string, error) {
+	if len(given) != 0 {
+		images := []string{}
+		for _, image := range given {
+			images = append(images, util.ImageReference(image))
+		}
+		return images, nil
+	}
+
+	images := KnownImages()
+	if withLocked {
+		lock, err := util.LoadImagesLock()
+		if err != nil {
+			return nil, err
+		}
+		for image := range lock {
+			if !util.Contains(images, image) {
+				images = append(images, image)
+			}
+		}
+		sort.Strings(images)
+	}
+
+	return images, nil
+}
+
+// resolve returns the registry digest of the image, pulling
+// the image first if pull is true or if it doesn't exist locally.
+func resolve(image string, pull bool) (string, error) {
+	if _, err := util.DockerClient.InspectImage(image); err != nil || pull {
+		log.WithField("=>", image).Warn("Pulling image")
+
+		writer := ioutil.Discard
+		if log.GetLevel() > 0 {
+			writer = os.Stdout
+		}
+		if err := util.PullImage(image, writer); err != nil {
+			return "", fmt.Errorf("Cannot pull image %s: %v", image, err)
+		}
+	}
+
+	return util.ImageDigest(image)
+}
+
+func printLock(lock util.ImagesLock, status map[string]string) error {
+	images := []string{}
+	for image := range status {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tDIGEST\tSTATUS")
+	for _, image := range images {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", image, lock[image], status[image])
+	}
+	return tw.Flush()
+}
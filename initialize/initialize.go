@@ -3,14 +3,14 @@ package initialize
 import (
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"runtime"
-	"strings"
+	"text/tabwriter"
 
 	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/migrate"
 	"github.com/eris-ltd/eris-cli/util"
 	"github.com/eris-ltd/eris-cli/version"
 )
@@ -31,10 +31,14 @@ func Initialize(do *definitions.Do) error {
 		}
 
 		log.Info("Checking if migration is required")
-		if err := checkIfMigrationRequired(do.Yes); err != nil {
-			return nil
+		if err := migrate.Run(do); err != nil {
+			return fmt.Errorf("Could not migrate the Eris root directory: %v", err)
+		}
+	} else {
+		// Nothing to migrate in a fresh root.
+		if err := migrate.SetSchemaVersion(migrate.Latest()); err != nil {
+			return err
 		}
-
 	}
 
 	if do.Pull {
@@ -134,59 +138,81 @@ func dropServiceDefaults(dir string, services []string) error {
 	return nil
 }
 
-func pullDefaultImages(images []string) error {
+func pullDefaultImages(names []string) error {
 	// Default images.
-	if len(images) == 0 {
-		images = []string{
-			"data",
-			"keys",
-			"ipfs",
-			"db",
-			"cm",
-			"pm",
-			"compilers",
-		}
+	if len(names) == 0 {
+		names = util.DefaultImageNames
 	}
 
 	// Rewrite with versioned image names (full names
-	// without a registry prefix).
-	versionedImageNames := map[string]string{
-		"data":      config.Global.ImageData,
-		"keys":      config.Global.ImageKeys,
-		"ipfs":      config.Global.ImageIPFS,
-		"db":        config.Global.ImageDB,
-		"cm":        config.Global.ImageCM,
-		"pm":        config.Global.ImagePM,
-		"compilers": config.Global.ImageCompilers,
+	// with a registry prefix), using the image digests
+	// from the images lockfile, if any.
+	tagNames := make([]string, len(names))
+	imageNames := make([]string, len(names))
+	for i, name := range names {
+		tagNames[i] = util.DefaultImage(name)
+		imageNames[i] = util.PinnedImage(tagNames[i])
 	}
 
-	for i, image := range images {
-		images[i] = versionedImageNames[image]
+	// Spacer.
+	log.Warn()
 
-		// Attach default registry prefix.
-		if !strings.HasPrefix(images[i], config.Global.DefaultRegistry) {
-			images[i] = path.Join(config.Global.DefaultRegistry, images[i])
+	log.WithField("simultaneously", config.Global.ImagesPullConcurrency).Warn("Pulling default Docker images from " + config.Global.DefaultRegistry)
+	summary := util.PullImages(imageNames, os.Stdout)
+
+	// Images pulled by digest are also used by tag (e.g. the data image).
+	for i, image := range imageNames {
+		if summary.Failed[image] != nil {
+			continue
+		}
+		if err := util.TagPinnedImage(tagNames[i], image); err != nil {
+			summary.Failed[image] = err
 		}
 	}
+	printPullSummary(imageNames, summary)
 
-	// Spacer.
-	log.Warn()
-
-	log.Warn("Pulling default Docker images from " + config.Global.DefaultRegistry)
-	for i, image := range images {
-		log.WithField("image", image).Warnf("Pulling image %d out of %d", i+1, len(images))
+	if len(summary.Failed) == 0 {
+		return nil
+	}
 
-		if err := util.PullImage(image, os.Stdout); err != nil {
-			if err == util.ErrImagePullTimeout {
+	for _, image := range imageNames {
+		if err, ok := summary.Failed[image]; ok {
+			log.Error(err)
+			if pullErr, ok := err.(*util.PullError); ok && pullErr.TimedOut() {
 				return fmt.Errorf(`
 It looks like marmots are taking too long to download the necessary images...
 Please, try restarting the [eris init] command one more time now or a bit later.
 This is likely a network performance issue with our Docker hosting provider`)
 			}
-			return err
 		}
 	}
-	return nil
+	return fmt.Errorf("Cannot pull %d out of %d default images", len(summary.Failed), len(imageNames))
+}
+
+func printPullSummary(images []string, summary *util.PullSummary) {
+	// Spacer.
+	log.Warn()
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tSTATUS")
+	for _, image := range images {
+		status := util.PullPulled
+		switch {
+		case summary.Failed[image] != nil:
+			status = util.PullFailed
+		case util.Contains(summary.UpToDate, image):
+			status = util.PullUpToDate
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", image, status)
+	}
+	tw.Flush()
+
+	log.WithFields(log.Fields{
+		"pulled":     len(summary.Pulled),
+		"up to date": len(summary.UpToDate),
+		"failed":     len(summary.Failed),
+	}).Warn("Finished pulling images")
 }
 
 func drops(files []string, typ, dir string) error {
@@ -254,13 +280,6 @@ func checkThenInitErisRoot(force bool) (bool, error) {
 	return newDir, nil
 }
 
-func checkIfMigrationRequired(doYes bool) error {
-	if err := util.MigrateDeprecatedDirs(config.DirsToMigrate, !doYes); err != nil {
-		return fmt.Errorf("Could not migrate directories.\n%s", err)
-	}
-	return nil
-}
-
 //func askToPull removed since it's basically a duplicate of this
 func checkIfCanOverwrite(doYes bool) error {
 	if doYes {
@@ -309,22 +328,41 @@ on local host machines. If you already have the images, they'll be updated.
 }
 
 func overwriteErisToml() error {
-	config.Global.DefaultRegistry = version.DefaultRegistry
-	config.Global.BackupRegistry = version.BackupRegistry
-	config.Global.ImageData = version.ImageData
-	config.Global.ImageKeys = version.ImageKeys
-	config.Global.ImageDB = version.ImageDB
-	config.Global.ImagePM = version.ImagePM
-	config.Global.ImageCM = version.ImageCM
-	config.Global.ImageIPFS = version.ImageIPFS
+	setImageDefaults(&config.Global.Settings)
+
+	// Keep the settings of the profile and the project
+	// in effect out of "eris.toml".
+	settings := &config.Global.Settings
+	if config.Global.Profile != config.DefaultProfile || config.Global.Project != nil {
+		base, err := config.LoadSettings(config.DefaultProfile)
+		if err != nil {
+			return err
+		}
+		setImageDefaults(base)
+		settings = base
+	}
 
 	// Ensure the directory the file being saved to exists.
 	if err := os.MkdirAll(config.ErisRoot, 0755); err != nil {
 		return err
 	}
 
-	if err := config.Save(&config.Global.Settings); err != nil {
+	if err := config.Save(settings); err != nil {
 		return err
 	}
 	return nil
 }
+
+func setImageDefaults(settings *config.Settings) {
+	settings.DefaultRegistry = version.DefaultRegistry
+	// Keep the user's backup registry.
+	if settings.BackupRegistry == "" {
+		settings.BackupRegistry = version.BackupRegistry
+	}
+	settings.ImageData = version.ImageData
+	settings.ImageKeys = version.ImageKeys
+	settings.ImageDB = version.ImageDB
+	settings.ImagePM = version.ImagePM
+	settings.ImageCM = version.ImageCM
+	settings.ImageIPFS = version.ImageIPFS
+}
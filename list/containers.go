@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -20,21 +21,28 @@ import (
 
 const (
 	// `eris ls` format.
-	standardTmplHeader = "{{toupper .}}\tON\tCONTAINER ID\tDATA CONTAINER"
-	standardTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{short (dependent .ShortName)}}"
+	standardTmplHeader = "{{toupper .}}\tON\tCONTAINER ID\tDATA CONTAINER\tORPHAN"
+	standardTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{short (dependent .ShortName)}}\t{{asterisk (orphan .)}}"
 
 	// `eris ls -a` format.
-	extendedTmplHeader = "{{toupper .}}\tON\tCONTAINER ID\tDATA CONTAINER\tIMAGE\tCOMMAND\tPORTS"
-	extendedTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{short (dependent .ShortName)}}\t{{.Info.Config.Image}}\t{{.Info.Config.Cmd}}\t{{ports .Info}}"
+	extendedTmplHeader = "{{toupper .}}\tON\tCONTAINER ID\tDATA CONTAINER\tORPHAN\tIMAGE\tCOMMAND\tPORTS"
+	extendedTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{short (dependent .ShortName)}}\t{{asterisk (orphan .)}}\t{{.Info.Config.Image}}\t{{.Info.Config.Cmd}}\t{{ports .Info}}"
 
 	// Data section.
-	dataTmplHeader = "{{toupper .}}\tON\tCONTAINER ID"
-	dataTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}"
+	dataTmplHeader = "{{toupper .}}\tON\tCONTAINER ID\tORPHAN"
+	dataTmpl       = "{{.ShortName}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{asterisk (orphan .)}}"
+
+	// `eris ls --stacks` format.
+	stackTmplHeader = "{{toupper .}}\tTYPE\tON\tCONTAINER ID\tDATA CONTAINER"
+	stackTmpl       = "{{.ShortName}}\t{{.Type}}\t{{asterisk .Info.State.Running}}\t{{short .Info.ID}}\t{{short (dependent .ShortName)}}"
 )
 
 var (
 	erisContainers = []*util.Details{}
 
+	// Reports containers which lost their owner (see util.OrphanFilter).
+	isOrphan func(details *util.Details) bool
+
 	// Template helpers to manipulate raw field values in the output.
 	helpers = map[string]interface{}{
 		"toupper": func(word string) string {
@@ -68,6 +76,10 @@ var (
 			}
 			return ""
 		},
+		// Show if a container has lost its chain or service.
+		"orphan": func(details *util.Details) bool {
+			return isOrphan != nil && isOrphan(details)
+		},
 		// Pretty-format Docker ports.
 		"ports": func(container *docker.Container) string {
 			return util.FormulatePortsOutput(container)
@@ -99,6 +111,7 @@ func Containers(t, format string, running bool) error {
 		erisContainers = append(erisContainers, details)
 		return true
 	}, false)
+	isOrphan = util.OrphanFilter()
 
 	// Keys for the parameter map.
 	const (
@@ -160,9 +173,16 @@ func Containers(t, format string, running bool) error {
 		return fmt.Errorf("Don't know the type %q to list containers for", t)
 	}
 
+	orphanData := false
+	for _, container := range erisContainers {
+		if container.Type == definitions.TypeData && isOrphan(container) {
+			orphanData = true
+		}
+	}
+
 	for _, p := range renderParams[t][key] {
 		// Skip the Data section altogether if there's nothing to show.
-		if p.DontShowData == true && isOrphanDataContainers() == false {
+		if p.DontShowData == true && orphanData == false {
 			continue
 		}
 		if err := render(buf, p.Type, p.DontShowData, p.Header, p.Template); err != nil {
@@ -178,28 +198,94 @@ func Containers(t, format string, running bool) error {
 	return nil
 }
 
-func isOrphanDataContainers() bool {
+// Stacks displays service and chain container information on the console
+// grouped by stacks the containers were brought up with (see [eris up]), one
+// section per stack. Containers not belonging to any stack are not shown.
+// The format parameter is the same as in Containers, with the exception of
+// "extended", which isn't supported.
+func Stacks(format string, running bool) error {
+	log.WithField("format", format).Debug("Listing stacks")
+
+	// Collect container information.
+	util.ErisContainers(func(name string, details *util.Details) bool {
+		if running == true && details.Info.State.Running == false && details.Type != definitions.TypeData {
+			return false
+		}
+		erisContainers = append(erisContainers, details)
+		return true
+	}, false)
+	isOrphan = util.OrphanFilter()
+
+	stacks := []string{}
+	members := make(map[string][]*util.Details)
 	for _, container := range erisContainers {
-		if container.Type == definitions.TypeData {
-			if isMasterContainer(container.ShortName) {
-				continue
-			}
-			return true
+		stack := container.Labels[definitions.LabelStack]
+		if stack == "" || container.Type == definitions.TypeData {
+			continue
+		}
+		if _, ok := members[stack]; !ok {
+			stacks = append(stacks, stack)
 		}
+		members[stack] = append(members[stack], container)
 	}
-	return false
-}
+	sort.Strings(stacks)
 
-func isMasterContainer(name string) bool {
-	// Found chain.
-	if _, err := util.Lookup(definitions.TypeChain, name); err == nil {
-		return true
+	if format == "json" {
+		b, err := json.Marshal(members)
+		if err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		json.Indent(&out, b, "", "  ")
+		out.WriteTo(os.Stdout)
+		io.WriteString(os.Stdout, "\n")
+		return nil
 	}
-	// Found service.
-	if _, err := util.Lookup(definitions.TypeService, name); err == nil {
-		return true
+
+	header, tmpl := stackTmplHeader, stackTmpl
+	if format != "" {
+		header, tmpl = "", format
+	}
+
+	r := strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+	tmplHeader, err := template.New("header").Funcs(helpers).Parse(r.Replace(header))
+	if err != nil {
+		return fmt.Errorf("Header template error: %v", err)
+	}
+	tmplTable, err := template.New("stacks").Funcs(helpers).Parse(r.Replace(tmpl))
+	if err != nil {
+		return fmt.Errorf("Listing template error: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	for _, stack := range stacks {
+		if header != "" {
+			if err := tmplHeader.Execute(buf, stack); err != nil {
+				return fmt.Errorf("Header template exec error: %v", err)
+			}
+			buf.WriteString("\n")
+		}
+
+		for _, container := range members[stack] {
+			if err := tmplTable.Execute(buf, container); err != nil {
+				return fmt.Errorf("Listing template exec error: %v\n", err)
+			}
+			buf.WriteString("\n")
+		}
+
+		if header != "" {
+			// Tabs are necessary so that the Tabwriter doesn't break
+			// on a newline (1 tab per column).
+			buf.WriteString("\t\t\t\t\t\n")
+		}
 	}
-	return false
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(os.Stdout, 6, 1, 5, ' ', 0)
+	buf.WriteTo(tw)
+	tw.Flush()
+
+	return nil
 }
 
 func render(buf *bytes.Buffer, t string, truncate bool, header, format string) error {
@@ -228,7 +314,7 @@ func render(buf *bytes.Buffer, t string, truncate bool, header, format string) e
 
 		// Display only orphaned data containers in `eris ls` or `eris ls -a` mode.
 		if truncate {
-			if isMasterContainer(container.ShortName) {
+			if !isOrphan(container) {
 				continue
 			}
 		}
@@ -0,0 +1,65 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Ports displays host port reservations (see util.ReservePorts) along
+// with the state of the containers holding them. Reservations of removed
+// containers don't block other containers from using the ports and are
+// marked as "released". The format parameter can be "json" or empty
+// (default table output).
+func Ports(format string) error {
+	reservations, err := util.LoadReservations()
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		content, err := json.MarshalIndent(reservations, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(config.Global.Writer, string(content))
+		return nil
+	}
+
+	states := make(map[string]string)
+	containers, err := util.DockerClient.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return util.DockerError(err)
+	}
+	for _, container := range containers {
+		if len(container.Names) == 0 {
+			continue
+		}
+		state := "stopped"
+		if strings.HasPrefix(container.Status, "Up") {
+			state = "running"
+		}
+		states[strings.TrimPrefix(container.Names[0], "/")] = state
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "HOST PORT\tCONTAINER PORT\tTYPE\tNAME\tSTATE\tRESERVED")
+	for _, r := range reservations {
+		state, ok := states[r.Container]
+		if !ok {
+			state = "released"
+		}
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\t%s\t%s ago\n", r.Port, r.Protocol(), r.Exposed, r.Type, r.Name, state,
+			units.HumanDuration(time.Since(r.Created)))
+	}
+	return tw.Flush()
+}
@@ -540,6 +540,67 @@ image = "test image"
 	}
 }
 
+func TestLoadServiceDefinitionBuild(t *testing.T) {
+	const (
+		name       = "test"
+		definition = `
+[service]
+data_container = true
+
+[service.build]
+context = "../app"
+dockerfile = "docker/Dockerfile"
+target = "release"
+
+[service.build.args]
+VERSION = "1.2"
+`
+	)
+
+	if err := testutil.FakeDefinitionFile(config.ServicesPath, name, definition); err != nil {
+		t.Fatalf("cannot place a definition file")
+	}
+
+	d, err := LoadServiceDefinition(name)
+	if err != nil {
+		t.Fatalf("expected definition to load, got %v", err)
+	}
+
+	for _, entry := range []ab{
+		{`Name`, d.Name, name},
+		{`Service.Name`, d.Service.Name, name},
+		{`Service.Image`, d.Service.Image, ""},
+		{`Service.Build`, d.Service.Build, &definitions.Build{
+			Context:    "../app",
+			Dockerfile: "docker/Dockerfile",
+			Target:     "release",
+			Args:       map[string]string{"VERSION": "1.2"},
+		}},
+	} {
+		if !reflect.DeepEqual(entry.a, entry.b) {
+			t.Fatalf("definition expected %s = %#v, got %#v", entry.name, entry.b, entry.a)
+		}
+	}
+}
+
+func TestLoadServiceDefinitionBuildNoContext(t *testing.T) {
+	const (
+		name       = "test"
+		definition = `
+[service.build]
+dockerfile = "Dockerfile"
+`
+	)
+
+	if err := testutil.FakeDefinitionFile(config.ServicesPath, name, definition); err != nil {
+		t.Fatalf("cannot place a definition file")
+	}
+
+	if _, err := LoadServiceDefinition(name); err == nil {
+		t.Fatalf("expected definition fail to load")
+	}
+}
+
 func TestLoadServiceDefinitionEmpty(t *testing.T) {
 	const (
 		name = "test"
@@ -696,3 +757,62 @@ func TestServiceFinalizeLoadBlankAllTheThings(t *testing.T) {
 
 	t.Fatalf("expected finalize to panic")
 }
+
+func TestLoadStack(t *testing.T) {
+	const (
+		definition = `
+name = "myapp"
+services = [ "ipfs", "keys" ]
+
+[chain]
+name = "mychain"
+type = "simplechain"
+
+[[packages]]
+path = "contracts"
+address = "ADDR"
+
+[environment]
+LOG_LEVEL = "debug"
+`
+	)
+
+	dir := filepath.Join(config.ErisRoot, "apps", "myapp")
+	if err := testutil.FakeDefinitionFile(dir, StackFile, definition); err != nil {
+		t.Fatalf("cannot place a definition file")
+	}
+	defer os.RemoveAll(filepath.Join(config.ErisRoot, "apps"))
+
+	for _, path := range []string{dir, filepath.Join(dir, StackFile+".toml")} {
+		d, err := LoadStack(path)
+		if err != nil {
+			t.Fatalf("expected to load stack definition, got %v", err)
+		}
+
+		for _, entry := range []ab{
+			{`Name`, d.Name, "myapp"},
+			{`Path`, d.Path, dir},
+			{`Services`, d.Services, []string{"ipfs", "keys"}},
+			{`Chain`, d.Chain, &definitions.StackChain{Name: "mychain", Type: "simplechain"}},
+			{`Packages`, d.Packages, []*definitions.StackPackage{{Path: filepath.Join(dir, "contracts"), Address: "ADDR"}}},
+			{`Environment`, d.Environment, map[string]string{"LOG_LEVEL": "debug"}},
+		} {
+			if !reflect.DeepEqual(entry.a, entry.b) {
+				t.Fatalf("definition expected %s = %#v, got %#v", entry.name, entry.b, entry.a)
+			}
+		}
+	}
+}
+
+func TestLoadStackBadChain(t *testing.T) {
+	dir := filepath.Join(config.ErisRoot, "apps", "myapp")
+	if err := testutil.FakeDefinitionFile(dir, StackFile, `[chain]
+type = "simplechain"`); err != nil {
+		t.Fatalf("cannot place a definition file")
+	}
+	defer os.RemoveAll(filepath.Join(config.ErisRoot, "apps"))
+
+	if _, err := LoadStack(dir); err == nil {
+		t.Fatalf("expected failure, got nil")
+	}
+}
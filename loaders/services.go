@@ -37,6 +37,11 @@ func LoadServiceDefinition(servName string) (*definitions.ServiceDefinition, err
 		return nil, err
 	}
 
+	// Services built from a local context have no image to be named after.
+	if srv.Service.Build != nil && srv.Name == "" && srv.Service.Name == "" {
+		srv.Name = servName
+	}
+
 	addDependencyVolumesAndLinks(srv.Dependencies, srv.Service, srv.Operations)
 
 	ServiceFinalizeLoad(srv)
@@ -135,9 +140,17 @@ func loadServiceDefinition(servName string) (*viper.Viper, error) {
 }
 
 func checkImage(srv *definitions.Service) error {
+	// Services built from a local context get the image at start time.
+	if srv.Build != nil {
+		if srv.Build.Context == "" {
+			return fmt.Errorf(`A "context" field is required in the build section of the service definition file`)
+		}
+		return nil
+	}
+
 	// Services must be given an image. Flame out if they do not.
 	if srv.Image == "" {
-		return fmt.Errorf(`An "image" field is required in the service definition file`)
+		return fmt.Errorf(`An "image" field or a build section is required in the service definition file`)
 	}
 
 	return nil
@@ -0,0 +1,61 @@
+package loaders
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+)
+
+// StackFile is the default stack definition file name (without extension).
+const StackFile = "stack"
+
+// LoadStack reads a stack definition specified by the directory or filename
+// path and returns a stack definition structure. If path is a directory, the
+// stack.{toml,json,yaml} file is read from it. LoadStack returns missing file,
+// definition file bad format, and missing fields errors.
+func LoadStack(path string) (*definitions.Stack, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, name := path, StackFile
+	if f, err := os.Stat(path); err == nil && !f.IsDir() {
+		dir = filepath.Dir(path)
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	log.WithField("=>", filepath.Join(dir, name)).Debug("Loading stack definition")
+	stackConf, err := config.LoadViper(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := definitions.BlankStack()
+	if err := stackConf.Unmarshal(stack); err != nil {
+		return nil, fmt.Errorf("Sorry, the marmots could not figure that stack definition out: %v", err)
+	}
+
+	stack.Path = dir
+	if stack.Name == "" {
+		stack.Name = filepath.Base(dir)
+	}
+	if stack.Chain != nil && stack.Chain.Name == "" {
+		return nil, fmt.Errorf("A chain %q field is required in the stack definition file", "name")
+	}
+	for _, pkg := range stack.Packages {
+		if pkg.Path == "" {
+			return nil, fmt.Errorf("A package %q field is required in the stack definition file", "path")
+		}
+		if !filepath.IsAbs(pkg.Path) {
+			pkg.Path = filepath.Join(dir, pkg.Path)
+		}
+	}
+
+	return stack, nil
+}
@@ -0,0 +1,208 @@
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+// Migration upgrades the Eris root directory layout
+// from the schema version Version-1 to Version.
+type Migration struct {
+	Version     int
+	Description string
+
+	// Paths (relative to config.ErisRoot) the migration may change.
+	// Existing paths are backed up to config.BackupsPath before the
+	// migration is applied.
+	Paths []string
+
+	// Plan returns the list of changes Up would make
+	// (empty if there's nothing to change).
+	Plan func() ([]string, error)
+
+	// Up applies the migration.
+	Up func() error
+}
+
+// SchemaVersion returns the schema version the Eris root directory is
+// at (as recorded in config.SchemaFile). Roots which predate versioning
+// are at version 0.
+func SchemaVersion() (int, error) {
+	content, err := ioutil.ReadFile(config.SchemaFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, fmt.Errorf("Cannot read the schema version file %s: %v", config.SchemaFile, err)
+	}
+	return version, nil
+}
+
+// SetSchemaVersion records the schema version
+// of the Eris root directory.
+func SetSchemaVersion(version int) error {
+	return ioutil.WriteFile(config.SchemaFile, []byte(strconv.Itoa(version)+"\n"), 0644)
+}
+
+// Latest returns the latest schema version.
+func Latest() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// Pending returns migrations not yet applied to the Eris root directory.
+func Pending() ([]*Migration, error) {
+	current, err := SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current > Latest() {
+		return nil, fmt.Errorf("The Eris root directory schema version %d is newer than this version of Eris supports (%d)", current, Latest())
+	}
+
+	pending := []*Migration{}
+	for _, migration := range Migrations {
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Run applies pending migrations in order, backing up the paths each
+// migration changes first and recording the new schema version after each
+// migration succeeds.
+//
+//  do.DryRun - only show the changes pending migrations would make
+//  do.Yes    - don't ask for confirmation
+//
+func Run(do *definitions.Do) error {
+	pending, err := Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		log.Info("Nothing to migrate")
+		return nil
+	}
+
+	plans := make(map[int][]string)
+	changes := 0
+	for _, migration := range pending {
+		plan, err := migration.Plan()
+		if err != nil {
+			return fmt.Errorf("Cannot plan migration %d (%s): %v", migration.Version, migration.Description, err)
+		}
+		plans[migration.Version] = plan
+		changes += len(plan)
+	}
+
+	if do.DryRun {
+		printPlans(pending, plans)
+		return nil
+	}
+
+	if changes != 0 && !do.Yes {
+		printPlans(pending, plans)
+		log.WithField("backups", config.BackupsPath).Warn("Permission to migrate the Eris root directory required")
+		if util.QueryYesOrNo("Would you like to continue?") != util.Yes {
+			return fmt.Errorf("Permission to migrate not given")
+		}
+	}
+
+	for _, migration := range pending {
+		if len(plans[migration.Version]) != 0 {
+			log.WithField("=>", migration.Description).Warnf("Applying migration %d", migration.Version)
+
+			if err := backup(migration); err != nil {
+				return fmt.Errorf("Cannot back up before migration %d: %v", migration.Version, err)
+			}
+			if err := migration.Up(); err != nil {
+				return fmt.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+			}
+		}
+
+		if err := SetSchemaVersion(migration.Version); err != nil {
+			return err
+		}
+	}
+
+	log.WithField("version", Latest()).Warn("Eris root directory is up to date")
+	return nil
+}
+
+// Status displays the list of migrations and their status.
+func Status() error {
+	current, err := SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tDESCRIPTION\tSTATUS")
+	for _, migration := range Migrations {
+		status := "pending"
+		if migration.Version <= current {
+			status = "applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", migration.Version, migration.Description, status)
+	}
+	return tw.Flush()
+}
+
+func printPlans(pending []*Migration, plans map[int][]string) {
+	for _, migration := range pending {
+		fmt.Fprintf(config.Global.Writer, "%d. %s\n", migration.Version, migration.Description)
+
+		plan := plans[migration.Version]
+		if len(plan) == 0 {
+			fmt.Fprintln(config.Global.Writer, "   nothing to change")
+		}
+		for _, change := range plan {
+			fmt.Fprintf(config.Global.Writer, "   %s\n", change)
+		}
+	}
+}
+
+// backup copies the existing migration paths to
+// config.BackupsPath/VERSION-TIMESTAMP.
+func backup(migration *Migration) error {
+	dir := filepath.Join(config.BackupsPath, fmt.Sprintf("%d-%s", migration.Version, time.Now().Format("20060102150405")))
+
+	for _, path := range migration.Paths {
+		src := filepath.Join(config.ErisRoot, path)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+
+		dst := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"from": src,
+			"to":   dst,
+		}).Info("Backing up")
+		if err := config.Copy(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+)
+
+var erisDir = filepath.Join(os.TempDir(), "eris-migrate")
+
+func TestMain(m *testing.M) {
+	log.SetLevel(log.ErrorLevel)
+	// log.SetLevel(log.InfoLevel)
+	// log.SetLevel(log.DebugLevel)
+
+	config.ChangeErisRoot(erisDir)
+	config.Global, _ = config.New(ioutil.Discard, ioutil.Discard)
+
+	exitCode := m.Run()
+	os.RemoveAll(erisDir)
+	os.Exit(exitCode)
+}
+
+func setup(t *testing.T, files map[string]string) {
+	os.RemoveAll(erisDir)
+	for name, content := range files {
+		file := filepath.Join(erisDir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("expected directory created, got %v", err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("expected file written, got %v", err)
+		}
+	}
+}
+
+func read(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile(filepath.Join(erisDir, name))
+	if err != nil {
+		t.Fatalf("expected file %s to exist, got %v", name, err)
+	}
+	return string(content)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	setup(t, nil)
+	defer os.RemoveAll(erisDir)
+
+	if version, err := SchemaVersion(); err != nil || version != 0 {
+		t.Fatalf("expected version 0 with no schema file, got %d, %v", version, err)
+	}
+
+	os.MkdirAll(erisDir, 0755)
+	if err := SetSchemaVersion(2); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if version, err := SchemaVersion(); err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d, %v", version, err)
+	}
+
+	pending, err := Pending()
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(pending) != Latest()-2 || (len(pending) > 0 && pending[0].Version != 3) {
+		t.Fatalf("expected migrations from version 3 pending, got %v", pending)
+	}
+
+	SetSchemaVersion(Latest() + 1)
+	if _, err := Pending(); err == nil {
+		t.Fatalf("expected an error for a schema version newer than supported")
+	}
+}
+
+func TestRun(t *testing.T) {
+	setup(t, map[string]string{
+		"blockchains/mychain/config.toml":        "moniker = \"mychain\"\n",
+		"services/ipfs.toml":                     "[service]\nimage = \"quay.io/eris/ipfs\"\nmemory = 1024\n\n[maintainer]\nmemory = 1\n",
+		"chains/HEAD":                            "mychain\nmychain\n\nother\nmychain\n",
+		"bundles/com/example/idi/1.0.0/epm.yaml": "jobs:\n",
+	})
+	defer os.RemoveAll(erisDir)
+
+	do := definitions.NowDo()
+	do.Yes = true
+	if err := Run(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	if version, _ := SchemaVersion(); version != Latest() {
+		t.Fatalf("expected version %d, got %d", Latest(), version)
+	}
+
+	// Version 1.
+	if _, err := os.Stat(filepath.Join(erisDir, "blockchains")); !os.IsNotExist(err) {
+		t.Fatalf("expected blockchains directory moved")
+	}
+	if content := read(t, "chains/mychain/config.toml"); content != "moniker = \"mychain\"\n" {
+		t.Fatalf("expected chain config moved, got %q", content)
+	}
+
+	// Version 2.
+	if content, expected := read(t, "services/ipfs.toml"), "[service]\nimage = \"quay.io/eris/ipfs\"\nmem_limit = 1024\n\n[maintainer]\nmemory = 1\n"; content != expected {
+		t.Fatalf("expected %q, got %q", expected, content)
+	}
+
+	// Version 3.
+	if content, expected := read(t, "chains/HEAD"), "mychain\nother\n"; content != expected {
+		t.Fatalf("expected %q, got %q", expected, content)
+	}
+
+	// Version 4.
+	if content := read(t, "bundles/com.example/idi/1.0.0/epm.yaml"); content != "jobs:\n" {
+		t.Fatalf("expected bundle moved, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(erisDir, "bundles", "com")); !os.IsNotExist(err) {
+		t.Fatalf("expected old group directory removed")
+	}
+	if content := read(t, "bundles/index.json"); !strings.Contains(content, `"group": "com.example"`) || !strings.Contains(content, `"bundle": "idi"`) {
+		t.Fatalf("expected bundle added to the index, got %q", content)
+	}
+
+	// Backups.
+	backups, _ := filepath.Glob(filepath.Join(config.BackupsPath, "2-*", "services", "ipfs.toml"))
+	if len(backups) != 1 {
+		t.Fatalf("expected service definition backed up, got %v", backups)
+	}
+	if content, _ := ioutil.ReadFile(backups[0]); !strings.Contains(string(content), "memory = 1024") {
+		t.Fatalf("expected original content backed up, got %q", content)
+	}
+}
+
+func TestRenameMemory(t *testing.T) {
+	for _, test := range []struct {
+		content, expected string
+		renamed           int
+	}{
+		{"[service]\nmemory = 1024\n", "[service]\nmem_limit = 1024\n", 1},
+		{"[service]\nmemory = \"tcp://10.0.0.1:2376\"\n", "[service]\nexec_host = \"tcp://10.0.0.1:2376\"\n", 1},
+		{"[service]\n  memory='host'\n", "[service]\n  exec_host='host'\n", 1},
+		{"[maintainer]\nmemory = 1\n", "[maintainer]\nmemory = 1\n", 0},
+	} {
+		if content, renamed := renameMemory(test.content); content != test.expected || renamed != test.renamed {
+			t.Fatalf("expected %q with %d renamed, got %q with %d", test.expected, test.renamed, content, renamed)
+		}
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	setup(t, map[string]string{
+		"services/ipfs.toml": "[service]\nmemory = 1024\n",
+	})
+	defer os.RemoveAll(erisDir)
+
+	var buf bytes.Buffer
+	config.Global.Writer = &buf
+	defer func() { config.Global.Writer = ioutil.Discard }()
+
+	do := definitions.NowDo()
+	do.DryRun = true
+	if err := Run(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "rename memory to mem_limit") {
+		t.Fatalf("expected planned change listed, got %q", buf.String())
+	}
+	if content := read(t, "services/ipfs.toml"); content != "[service]\nmemory = 1024\n" {
+		t.Fatalf("expected no changes on dry run, got %q", content)
+	}
+	if version, _ := SchemaVersion(); version != 0 {
+		t.Fatalf("expected schema version unchanged, got %d", version)
+	}
+}
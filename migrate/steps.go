@@ -0,0 +1,384 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+// Migrations is the ordered list of the Eris root directory layout
+// migrations. New migrations are appended with the next version number;
+// released migrations are never changed or removed.
+var Migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "move deprecated directories",
+		Paths:       []string{"blockchains", "dapps", "languages", "chains", "apps", "scratch"},
+		Plan:        planDirs,
+		Up:          upDirs,
+	},
+	{
+		Version:     2,
+		Description: "rename the memory service definition field to mem_limit or exec_host",
+		Paths:       []string{"services"},
+		Plan:        planMemLimit,
+		Up:          upMemLimit,
+	},
+	{
+		Version:     3,
+		Description: "drop blank and repeated entries from the chains HEAD file",
+		Paths:       []string{filepath.Join("chains", "HEAD")},
+		Plan:        planHead,
+		Up:          upHead,
+	},
+	{
+		Version:     4,
+		Description: "move contract bundles installed by the agent into the bundles index layout",
+		Paths:       []string{"bundles"},
+		Plan:        planBundles,
+		Up:          upBundles,
+	},
+}
+
+// Version 1.
+
+// Deprecated directories (relative to config.ErisRoot)
+// and the directories they were renamed to.
+var deprecatedDirs = [][2]string{
+	{"blockchains", "chains"},
+	{"dapps", "apps"},
+	{"languages", filepath.Join("scratch", "languages")},
+}
+
+func planDirs() ([]string, error) {
+	plan := []string{}
+	for _, dirs := range deprecatedDirs {
+		old, renamed := filepath.Join(config.ErisRoot, dirs[0]), filepath.Join(config.ErisRoot, dirs[1])
+		switch {
+		case !util.DoesDirExist(old):
+			continue
+		case util.DoesDirExist(renamed):
+			plan = append(plan, fmt.Sprintf("merge %s into %s", dirs[0], dirs[1]))
+		default:
+			plan = append(plan, fmt.Sprintf("move %s to %s", dirs[0], dirs[1]))
+		}
+	}
+	return plan, nil
+}
+
+func upDirs() error {
+	moves := make(map[string]string)
+	for _, dirs := range deprecatedDirs {
+		old := filepath.Join(config.ErisRoot, dirs[0])
+		if util.DoesDirExist(old) {
+			moves[old] = filepath.Join(config.ErisRoot, dirs[1])
+		}
+	}
+	return util.Migrate(moves)
+}
+
+// Version 2.
+
+var memoryField = regexp.MustCompile(`^(\s*)memory(\s*=\s*)(\S?)`)
+
+// renameMemory returns the service definition content with the memory
+// field of the [service] table renamed and the number of fields renamed.
+// The field held either the memory limit (an integer, now mem_limit)
+// or the exec host (a string, now exec_host).
+func renameMemory(content string) (string, int) {
+	lines := strings.Split(content, "\n")
+
+	table, renamed := "", 0
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") {
+			table = strings.Trim(trimmed, "[] ")
+			continue
+		}
+		if table != "service" {
+			continue
+		}
+		match := memoryField.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		field := "mem_limit"
+		if match[3] == `"` || match[3] == "'" {
+			field = "exec_host"
+		}
+		lines[i] = memoryField.ReplaceAllString(line, "${1}"+field+"${2}${3}")
+		renamed++
+	}
+
+	return strings.Join(lines, "\n"), renamed
+}
+
+func serviceFiles() ([]string, error) {
+	return filepath.Glob(filepath.Join(config.ServicesPath, "*.toml"))
+}
+
+func planMemLimit() ([]string, error) {
+	files, err := serviceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := []string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, renamed := renameMemory(string(content)); renamed != 0 {
+			plan = append(plan, fmt.Sprintf("rename memory to mem_limit or exec_host in %s", util.Tilde(file)))
+		}
+	}
+	return plan, nil
+}
+
+func upMemLimit() error {
+	files, err := serviceFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if updated, renamed := renameMemory(string(content)); renamed != 0 {
+			if err := ioutil.WriteFile(file, []byte(updated), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Version 3.
+
+// The global chains HEAD file.
+func headFile() string {
+	return filepath.Join(config.ChainsPath, "HEAD")
+}
+
+// cleanHead returns the HEAD file entries with blank (except for the
+// first one, meaning no chain is checked out) and repeated entries
+// removed and the number of entries removed.
+func cleanHead(content string) ([]string, int) {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	entries := []string{lines[0]}
+	seen := map[string]bool{lines[0]: true}
+	for _, line := range lines[1:] {
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		entries = append(entries, line)
+	}
+
+	if len(entries) > util.MaxHead {
+		entries = entries[:util.MaxHead]
+	}
+	return entries, len(lines) - len(entries)
+}
+
+func planHead() ([]string, error) {
+	content, err := ioutil.ReadFile(headFile())
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, removed := cleanHead(string(content)); removed != 0 {
+		return []string{fmt.Sprintf("drop %d entries from %s", removed, util.Tilde(headFile()))}, nil
+	}
+	return []string{}, nil
+}
+
+func upHead() error {
+	content, err := ioutil.ReadFile(headFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, _ := cleanHead(string(content))
+	return ioutil.WriteFile(headFile(), []byte(strings.Join(entries, "\n")+"\n"), 0666)
+}
+
+// Version 4.
+
+// bundle is a bundles index (config.BundlesIndexFile) entry as of
+// version 4. It mirrors pkgs.Bundle, which can't be imported here.
+type bundle struct {
+	Group     string    `json:"group"`
+	Bundle    string    `json:"bundle"`
+	Version   string    `json:"version"`
+	Source    string    `json:"source"`
+	Digest    string    `json:"digest"`
+	Installed time.Time `json:"installed"`
+}
+
+func (b *bundle) path() string {
+	return filepath.Join(config.BundlesPath, b.Group, b.Bundle, b.Version)
+}
+
+// legacyBundle is a contract bundle the agent installed before the
+// bundles index: the group was split at its first dot (com.example
+// into com/example) and the bundle wasn't indexed.
+type legacyBundle struct {
+	dir    string
+	bundle *bundle
+}
+
+func loadBundlesIndex() ([]*bundle, error) {
+	bundles := []*bundle{}
+
+	content, err := ioutil.ReadFile(config.BundlesIndexFile)
+	if os.IsNotExist(err) {
+		return bundles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &bundles); err != nil {
+		return nil, fmt.Errorf("Cannot read the bundles index file %s: %v", config.BundlesIndexFile, err)
+	}
+	return bundles, nil
+}
+
+// legacyBundles returns bundle directories (those with an epm.yaml file)
+// under config.BundlesPath which are not in the bundles index.
+func legacyBundles() ([]*legacyBundle, error) {
+	legacy := []*legacyBundle{}
+	if !util.DoesDirExist(config.BundlesPath) {
+		return legacy, nil
+	}
+
+	bundles, err := loadBundlesIndex()
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[string]bool)
+	for _, b := range bundles {
+		indexed[b.path()] = true
+	}
+
+	err = filepath.Walk(config.BundlesPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		// Indexed bundles and unfinished installs.
+		if indexed[path] || strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !util.DoesFileExist(filepath.Join(path, "epm.yaml")) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(config.BundlesPath, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		var group string
+		switch len(parts) {
+		case 3:
+			group = parts[0]
+		case 4:
+			group = parts[0] + "." + parts[1]
+		default:
+			return filepath.SkipDir
+		}
+
+		legacy = append(legacy, &legacyBundle{path, &bundle{
+			Group:     group,
+			Bundle:    parts[len(parts)-2],
+			Version:   parts[len(parts)-1],
+			Installed: info.ModTime(),
+		}})
+		return filepath.SkipDir
+	})
+	return legacy, err
+}
+
+func planBundles() ([]string, error) {
+	legacy, err := legacyBundles()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := []string{}
+	for _, l := range legacy {
+		reference := l.bundle.Group + "/" + l.bundle.Bundle + "@" + l.bundle.Version
+		switch {
+		case l.dir == l.bundle.path():
+			plan = append(plan, fmt.Sprintf("add %s to the bundles index", reference))
+		case util.DoesDirExist(l.bundle.path()):
+			plan = append(plan, fmt.Sprintf("leave %s, %s is installed in %s", util.Tilde(l.dir), reference, util.Tilde(l.bundle.path())))
+		default:
+			plan = append(plan, fmt.Sprintf("move %s to %s and add it to the bundles index", util.Tilde(l.dir), util.Tilde(l.bundle.path())))
+		}
+	}
+	return plan, nil
+}
+
+func upBundles() error {
+	legacy, err := legacyBundles()
+	if err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	bundles, err := loadBundlesIndex()
+	if err != nil {
+		return err
+	}
+	for _, l := range legacy {
+		if l.dir != l.bundle.path() {
+			// Installed again since with the new layout.
+			if util.DoesDirExist(l.bundle.path()) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(l.bundle.path()), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(l.dir, l.bundle.path()); err != nil {
+				return err
+			}
+			// Remove the emptied old group and bundle directories.
+			for dir := filepath.Dir(l.dir); dir != config.BundlesPath; dir = filepath.Dir(dir) {
+				if os.Remove(dir) != nil {
+					break
+				}
+			}
+		}
+		bundles = append(bundles, l.bundle)
+	}
+
+	content, err := json.MarshalIndent(bundles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.BundlesIndexFile, append(content, '\n'), 0644)
+}
@@ -0,0 +1,232 @@
+package perform
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/secrets"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Difference is a container setting which differs from
+// the one derived from the definition file.
+type Difference struct {
+	Field      string
+	Definition string
+	Container  string
+}
+
+// DockerDiff compares the container options derived from the definition
+// (the ones DockerRunService would create the container with) with the
+// existing ops.SrvContainerName container. It returns the differences in
+// the image, environment, ports, links, volumes, restart policy, and
+// resource limits. Values of variables set from the secrets store are
+// redacted.
+//
+// See parameter description for DockerRunService.
+func DockerDiff(srv *definitions.Service, ops *definitions.Operation) ([]*Difference, error) {
+	container, err := util.DockerClient.InspectContainer(ops.SrvContainerName)
+	if err != nil {
+		return nil, util.DockerError(err)
+	}
+
+	// Container configuration shouldn't modify the definition.
+	desired := *srv
+	if srv.Build != nil {
+		if desired.Image, err = buildImageName(srv); err != nil {
+			return nil, err
+		}
+	}
+	opts := configureServiceContainer(&desired, ops)
+	if err := configureSecrets(&desired, &opts); err != nil {
+		return nil, err
+	}
+	if desired.AutoData {
+		opts.HostConfig.VolumesFrom = append(opts.HostConfig.VolumesFrom, ops.DataContainerName)
+	}
+
+	// Variables coming from the image aren't set by the definition.
+	var imageEnv []string
+	if image, err := util.DockerClient.InspectImage(container.Image); err == nil && image.Config != nil {
+		imageEnv = image.Config.Env
+	}
+
+	diffs := []*Difference{}
+	add := func(field, definition, container string) {
+		if definition != container {
+			diffs = append(diffs, &Difference{field, definition, container})
+		}
+	}
+
+	add("image", util.ImageReference(opts.Config.Image), util.ImageReference(container.Config.Image))
+	diffs = append(diffs, diffEnvironment(opts.Config.Env, container.Config.Env, imageEnv, opts.Config.Labels)...)
+
+	add("publish all ports", strconv.FormatBool(opts.HostConfig.PublishAllPorts), strconv.FormatBool(container.HostConfig.PublishAllPorts))
+	if !opts.HostConfig.PublishAllPorts {
+		add("ports", formatPorts(opts.HostConfig.PortBindings), formatPorts(container.HostConfig.PortBindings))
+	}
+
+	add("links", formatLinks(opts.HostConfig.Links), formatLinks(container.HostConfig.Links))
+	add("volumes", formatList(opts.HostConfig.Binds), formatList(container.HostConfig.Binds))
+	add("volumes from", formatList(opts.HostConfig.VolumesFrom), formatList(container.HostConfig.VolumesFrom))
+	add("restart", formatRestart(opts.HostConfig.RestartPolicy), formatRestart(container.HostConfig.RestartPolicy))
+
+	memory, cpu := container.HostConfig.Memory, container.HostConfig.CPUShares
+	if memory == 0 {
+		memory = container.Config.Memory
+	}
+	if cpu == 0 {
+		cpu = container.Config.CPUShares
+	}
+	add("memory limit", formatMemory(opts.Config.Memory), formatMemory(memory))
+	add("cpu shares", formatCPU(opts.Config.CPUShares), formatCPU(cpu))
+
+	return diffs, nil
+}
+
+// PrintDifferences displays differences returned by DockerDiff.
+func PrintDifferences(diffs []*Difference) error {
+	if len(diffs) == 0 {
+		fmt.Fprintln(config.Global.Writer, "The container matches the definition")
+		return nil
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tDEFINITION\tCONTAINER")
+	for _, diff := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", diff.Field, diff.Definition, diff.Container)
+	}
+	return tw.Flush()
+}
+
+// diffEnvironment compares environment variables set by the definition
+// with the container ones, ignoring the variables coming from the image.
+func diffEnvironment(definition, container, image []string, labels map[string]string) []*Difference {
+	desired, actual, inherited := envMap(definition), envMap(container), envMap(image)
+
+	secret := make(map[string]bool)
+	for _, name := range strings.Split(labels[definitions.LabelSecrets], ",") {
+		secret[name] = true
+	}
+	show := func(name, value string, ok bool) string {
+		switch {
+		case !ok:
+			return "-"
+		case secret[name]:
+			return secrets.Redacted
+		}
+		return value
+	}
+
+	names := []string{}
+	for name := range desired {
+		names = append(names, name)
+	}
+	for name, value := range actual {
+		if _, ok := desired[name]; !ok {
+			if inherited[name] != value {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	diffs := []*Difference{}
+	for _, name := range names {
+		want, wanted := desired[name]
+		have, has := actual[name]
+		if wanted && has && want == have {
+			continue
+		}
+		diffs = append(diffs, &Difference{"env " + name, show(name, want, wanted), show(name, have, has)})
+	}
+	return diffs
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string)
+	for _, variable := range env {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) == 2 {
+			m[parts[0]] = parts[1]
+		} else {
+			m[parts[0]] = ""
+		}
+	}
+	return m
+}
+
+func formatPorts(bindings map[docker.Port][]docker.PortBinding) string {
+	ports := []string{}
+	for exposed, list := range bindings {
+		for _, binding := range list {
+			ip := binding.HostIP
+			if ip == "0.0.0.0" {
+				ip = ""
+			}
+			if ip != "" {
+				ip += ":"
+			}
+			ports = append(ports, fmt.Sprintf("%s%s->%s", ip, binding.HostPort, exposed))
+		}
+	}
+	return formatList(ports)
+}
+
+// formatLinks normalizes links to the CONTAINER:ALIAS format
+// (Docker reports them as /CONTAINER:/LINKING_CONTAINER/ALIAS).
+func formatLinks(links []string) string {
+	normalized := []string{}
+	for _, link := range links {
+		parts := strings.SplitN(strings.TrimPrefix(link, "/"), ":", 2)
+		alias := parts[0]
+		if len(parts) == 2 {
+			alias = path.Base(parts[1])
+		}
+		normalized = append(normalized, parts[0]+":"+alias)
+	}
+	return formatList(normalized)
+}
+
+func formatList(list []string) string {
+	if len(list) == 0 {
+		return "-"
+	}
+	sorted := append([]string{}, list...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+func formatRestart(policy docker.RestartPolicy) string {
+	switch {
+	case policy.Name == "" || policy.Name == "no":
+		return "never"
+	case policy.MaximumRetryCount > 0:
+		return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+	}
+	return policy.Name
+}
+
+func formatMemory(memory int64) string {
+	if memory == 0 {
+		return "unlimited"
+	}
+	return units.BytesSize(float64(memory))
+}
+
+func formatCPU(shares int64) string {
+	if shares == 0 {
+		return "default"
+	}
+	return strconv.FormatInt(shares, 10)
+}
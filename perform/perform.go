@@ -18,6 +18,7 @@ import (
 	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/secrets"
 	"github.com/eris-ltd/eris-cli/util"
 
 	"github.com/docker/docker/pkg/jsonmessage"
@@ -187,6 +188,8 @@ func DockerExecData(ops *definitions.Operation, service *definitions.Service) (b
 // Container parameters:
 //
 //  ops.PublishAllPorts   - if true, publish exposed ports to random ports
+//  ops.AllocatePorts     - if true, publish exposed ports to stable host
+//                          ports not used by other chains and services
 //  ops.CapAdd            - add linux capabilities (similar to `docker run --cap-add=[]`)
 //  ops.CapDrop           - add linux capabilities (similar to `docker run --cap-drop=[]`)
 //  ops.Privileged        - if true, give extended privileges
@@ -200,7 +203,31 @@ func DockerRunService(srv *definitions.Service, ops *definitions.Operation) erro
 		return nil
 	}
 
+	if err := DockerBuildService(srv); err != nil {
+		return err
+	}
+	if err := removeOutdatedContainer(srv, ops); err != nil {
+		return err
+	}
+
+	create := !ContainerExists(ops.SrvContainerName)
+	if create {
+		if err := allocatePorts(srv, ops); err != nil {
+			return err
+		}
+	}
+
 	optsServ := configureServiceContainer(srv, ops)
+	if create {
+		// Secrets are only read from the store for new containers;
+		// existing ones keep the values they were created with.
+		if err := configureSecrets(srv, &optsServ); err != nil {
+			return err
+		}
+		if err := checkPorts(ops, optsServ); err != nil {
+			return err
+		}
+	}
 
 	// Setup data container.
 	log.WithField("autodata", srv.AutoData).Info("Manage data containers?")
@@ -222,7 +249,7 @@ func DockerRunService(srv *definitions.Service, ops *definitions.Operation) erro
 	}
 
 	// Check existence || create the container.
-	if exists := ContainerExists(ops.SrvContainerName); exists {
+	if !create {
 		log.Debug("Container already exists. Not creating")
 	} else {
 		log.WithField("image", srv.Image).Debug("Container does not exist. Creating")
@@ -231,6 +258,10 @@ func DockerRunService(srv *definitions.Service, ops *definitions.Operation) erro
 		if err != nil {
 			return err
 		}
+		if err := reservePorts(srv, ops, optsServ); err != nil {
+			removeContainer(ops.SrvContainerName, false, false)
+			return err
+		}
 	}
 
 	// Start the container.
@@ -240,7 +271,7 @@ func DockerRunService(srv *definitions.Service, ops *definitions.Operation) erro
 		"entrypoint":      optsServ.Config.Entrypoint,
 		"cmd":             optsServ.Config.Cmd,
 		"published ports": optsServ.HostConfig.PublishAllPorts,
-		"environment":     optsServ.Config.Env,
+		"environment":     secrets.RedactEnvironment(optsServ.Config.Env, optsServ.Config.Labels),
 		"image":           optsServ.Config.Image,
 	}).Info("Starting container")
 	if err := startContainer(optsServ); err != nil {
@@ -262,7 +293,13 @@ func DockerRunService(srv *definitions.Service, ops *definitions.Operation) erro
 func DockerExecService(srv *definitions.Service, ops *definitions.Operation) (buf *bytes.Buffer, err error) {
 	log.WithField("=>", ops.SrvContainerName).Info("Executing container")
 
+	if err := DockerBuildService(srv); err != nil {
+		return nil, err
+	}
 	optsServ := configureInteractiveContainer(srv, ops)
+	if err := configureSecrets(srv, &optsServ); err != nil {
+		return nil, err
+	}
 
 	// Setup data container.
 	log.WithField("autodata", srv.AutoData).Info("Manage data containers?")
@@ -318,7 +355,7 @@ func DockerExecService(srv *definitions.Service, ops *definitions.Operation) (bu
 		"workdir":         optsServ.Config.WorkingDir,
 		"cmd":             optsServ.Config.Cmd,
 		"ports published": optsServ.HostConfig.PublishAllPorts,
-		"environment":     optsServ.Config.Env,
+		"environment":     secrets.RedactEnvironment(optsServ.Config.Env, optsServ.Config.Labels),
 		"image":           optsServ.Config.Image,
 		"user":            optsServ.Config.User,
 		"vols":            optsServ.HostConfig.Binds,
@@ -365,21 +402,37 @@ func DockerRebuild(srv *definitions.Service, ops *definitions.Operation, pullIma
 		return nil
 	}
 
-	if pullImage {
+	if pullImage && srv.Build == nil {
 		log.WithField("image", srv.Image).Info("Pulling image")
 		err := DockerPull(srv, ops)
 		if err != nil {
 			return err
 		}
 	}
+	if err := DockerBuildService(srv); err != nil {
+		return err
+	}
 
+	if err := allocatePorts(srv, ops); err != nil {
+		return err
+	}
 	opts := configureServiceContainer(srv, ops)
+	if err := configureSecrets(srv, &opts); err != nil {
+		return err
+	}
+	if err := checkPorts(ops, opts); err != nil {
+		return err
+	}
 
 	log.WithField("=>", ops.SrvContainerName).Info("Recreating container")
 	_, err := createContainer(opts)
 	if err != nil {
 		return err
 	}
+	if err := reservePorts(srv, ops, opts); err != nil {
+		removeContainer(ops.SrvContainerName, false, false)
+		return err
+	}
 
 	if wasRunning {
 		log.WithField("=>", opts.Name).Info("Restarting container")
@@ -432,6 +485,10 @@ func DockerPull(srv *definitions.Service, ops *definitions.Operation) error {
 		}
 	}
 
+	if err := util.CheckImageDigest(srv.Image); err != nil {
+		return err
+	}
+
 	if wasRunning {
 		if err := DockerRunService(srv, ops); err != nil {
 			return err
@@ -630,31 +687,95 @@ func DockerBuild(image, dockerfile string) error {
 	tr.Write([]byte(dockerfile))
 	tr.Close()
 
-	r, w := io.Pipe()
-	imgOpts := docker.BuildImageOptions{
-		Name:                image,
-		RmTmpContainer:      true,
-		ForceRmTmpContainer: true,
-		InputStream:         inputbuf,
-		OutputStream:        w,
-		RawJSONStream:       true,
+	return buildImage(docker.BuildImageOptions{
+		Name:        image,
+		InputStream: inputbuf,
+	})
+}
+
+// DockerBuildService builds the service image from the srv.Build context
+// and points srv.Image to it. The image is tagged with the hash of the
+// context (see util.BuildContextHash), so it is only rebuilt if the context
+// or the build parameters change. The image repository is taken from
+// srv.Image if given. DockerBuildService does nothing if srv.Build is nil.
+func DockerBuildService(srv *definitions.Service) error {
+	if srv.Build == nil {
+		return nil
+	}
+
+	image, err := buildImageName(srv)
+	if err != nil {
+		return err
+	}
+	srv.Image = image
+
+	if ok, err := checkImageExists(srv.Image); err != nil {
+		return err
+	} else if ok {
+		log.WithField("image", srv.Image).Info("Build context unchanged. Not building")
+		return nil
+	}
+
+	context, err := util.BuildContextTar(srv.Build)
+	if err != nil {
+		return err
+	}
+	defer context.Close()
+
+	log.WithFields(log.Fields{
+		"=>":      srv.Name,
+		"context": util.Tilde(util.BuildContextDir(srv.Build)),
+		"image":   srv.Image,
+	}).Warn("Building image")
+	return buildImage(docker.BuildImageOptions{
+		Name:        srv.Image,
+		Dockerfile:  util.BuildDockerfile(srv.Build),
+		InputStream: context,
+	})
+}
+
+// buildImageName returns the name of the image built from the
+// srv.Build context in the REPOSITORY:HASH format.
+func buildImageName(srv *definitions.Service) (string, error) {
+	hash, err := util.BuildContextHash(srv.Build)
+	if err != nil {
+		return "", fmt.Errorf("Cannot read the build context of %s: %v", srv.Name, err)
 	}
 
+	repository := "eris-build/" + strings.ToLower(srv.Name)
+	if srv.Image != "" {
+		repository, _ = util.ParseImage(srv.Image)
+	}
+	return repository + ":" + hash[:12], nil
+}
+
+// buildImage builds the opts.Name image from the opts.InputStream
+// context, displaying the build output.
+func buildImage(opts docker.BuildImageOptions) error {
+	r, w := io.Pipe()
+	opts.RmTmpContainer = true
+	opts.ForceRmTmpContainer = true
+	opts.OutputStream = w
+	opts.RawJSONStream = true
+
 	ch := make(chan error, 1)
 	go func() {
 		defer w.Close()
 		defer close(ch)
 
-		if err := util.DockerClient.BuildImage(imgOpts); err != nil {
+		if err := util.DockerClient.BuildImage(opts); err != nil {
 			ch <- err
 		}
 	}()
-	jsonmessage.DisplayJSONMessagesStream(r, os.Stdout, os.Stdout.Fd(), term.IsTerminal(os.Stdout.Fd()), nil)
+	display := jsonmessage.DisplayJSONMessagesStream(r, os.Stdout, os.Stdout.Fd(), term.IsTerminal(os.Stdout.Fd()), nil)
 	if err, ok := <-ch; ok {
 		return util.DockerError(err)
 	}
+	if display != nil {
+		return fmt.Errorf("Cannot build image %s: %v", opts.Name, display)
+	}
 
-	ok, err := checkImageExists(image)
+	ok, err := checkImageExists(opts.Name)
 	if err != nil {
 		return err
 	}
@@ -678,19 +799,11 @@ func ContainerRunning(name string) bool {
 }
 
 func pullImage(name string, writer io.Writer) error {
-	var tag string = "latest"
 	var reg string = ""
 
-	nameSplit := strings.Split(name, ":")
-	if len(nameSplit) == 2 {
-		tag = nameSplit[1]
-	}
-	if len(nameSplit) == 3 {
-		tag = nameSplit[2]
-	}
-	name = nameSplit[0]
+	name, tag := util.ParseImage(name)
 
-	repoSplit := strings.Split(nameSplit[0], "/")
+	repoSplit := strings.Split(name, "/")
 	if len(repoSplit) > 2 {
 		reg = repoSplit[0]
 	}
@@ -972,6 +1085,8 @@ func configureInteractiveContainer(srv *definitions.Service, ops *definitions.Op
 	return opts
 }
 
+// configureServiceContainer pins the service image to the images lockfile
+// digest, if any (data and utility containers aren't pinned).
 func configureServiceContainer(srv *definitions.Service, ops *definitions.Operation) docker.CreateContainerOptions {
 	opts := docker.CreateContainerOptions{
 		Name: ops.SrvContainerName,
@@ -988,7 +1103,7 @@ func configureServiceContainer(srv *definitions.Service, ops *definitions.Operat
 			OpenStdin:       false,
 			Env:             srv.Environment,
 			Labels:          ops.Labels,
-			Image:           srv.Image,
+			Image:           util.PinnedImage(srv.Image),
 			NetworkDisabled: false,
 		},
 		HostConfig: &docker.HostConfig{
@@ -1061,6 +1176,94 @@ func configureServiceContainer(srv *definitions.Service, ops *definitions.Operat
 	return opts
 }
 
+// removeOutdatedContainer removes the stopped service container if it was
+// created from an image built from a previous version of the build context.
+func removeOutdatedContainer(srv *definitions.Service, ops *definitions.Operation) error {
+	if srv.Build == nil || !ContainerExists(ops.SrvContainerName) {
+		return nil
+	}
+
+	info, err := util.DockerClient.InspectContainer(ops.SrvContainerName)
+	if err != nil {
+		return util.DockerError(err)
+	}
+	if info.Config == nil || info.Config.Image == srv.Image {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"=>":    ops.SrvContainerName,
+		"image": srv.Image,
+	}).Warn("Build context changed. Recreating container")
+	return removeContainer(ops.SrvContainerName, false, false)
+}
+
+// allocatePorts reassigns the container ports to stable host ports
+// not used by other chains and services (see util.AllocatePorts)
+// if ops.AllocatePorts is true.
+func allocatePorts(srv *definitions.Service, ops *definitions.Operation) error {
+	if !ops.AllocatePorts || ops.PublishAllPorts || len(srv.Ports) == 0 {
+		return nil
+	}
+
+	assignments, err := util.AllocatePorts(ops.SrvContainerName, srv.Ports)
+	if err != nil {
+		return err
+	}
+	ops.Ports = strings.Join(assignments, ",")
+	return nil
+}
+
+// checkPorts fails early if the host ports the container
+// is about to bind are taken (see util.CheckPorts).
+func checkPorts(ops *definitions.Operation, opts docker.CreateContainerOptions) error {
+	if ops.PublishAllPorts {
+		return nil
+	}
+	return util.CheckPorts(ops.SrvContainerName, opts.HostConfig.PortBindings)
+}
+
+// reservePorts records the host ports the container binds
+// in the port reservations registry. The caller removes the created
+// container if the ports were taken by another container meanwhile.
+func reservePorts(srv *definitions.Service, ops *definitions.Operation, opts docker.CreateContainerOptions) error {
+	if ops.PublishAllPorts {
+		return nil
+	}
+
+	name := ops.Labels[definitions.LabelShortName]
+	if name == "" {
+		name = srv.Name
+	}
+	return util.ReservePorts(ops.SrvContainerName, ops.ContainerType, name, opts.HostConfig.PortBindings)
+}
+
+// configureSecrets resolves the srv.Secrets references to the secrets store
+// into container environment variables and records the variable names in
+// the definitions.LabelSecrets label, so that their values can be redacted.
+func configureSecrets(srv *definitions.Service, opts *docker.CreateContainerOptions) error {
+	if len(srv.Secrets) == 0 {
+		return nil
+	}
+
+	env, err := secrets.Environment(srv.Secrets)
+	if err != nil {
+		return err
+	}
+
+	// Don't modify the srv.Environment slice and ops.Labels map
+	// the options were configured with.
+	opts.Config.Env = append(append([]string{}, opts.Config.Env...), env...)
+
+	labels := make(map[string]string)
+	for k, v := range opts.Config.Labels {
+		labels[k] = v
+	}
+	opts.Config.Labels = util.SetLabel(labels, definitions.LabelSecrets, secrets.EnvironmentLabel(srv.Secrets))
+
+	return nil
+}
+
 func configureVolumesFromContainer(ops *definitions.Operation, service *definitions.Service) docker.CreateContainerOptions {
 	// Set the defaults.
 	opts := docker.CreateContainerOptions{
@@ -12,6 +12,7 @@ import (
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/loaders"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/secrets"
 	"github.com/eris-ltd/eris-cli/testutil"
 	"github.com/eris-ltd/eris-cli/util"
 )
@@ -2240,3 +2241,88 @@ func TestRemoveImageBadName(t *testing.T) {
 		t.Fatalf("expected remove image to fail")
 	}
 }
+
+func TestDiffServiceNoDifferences(t *testing.T) {
+	const (
+		name = "ipfs"
+	)
+
+	defer testutil.RemoveAllContainers()
+
+	srv, err := loaders.LoadServiceDefinition(name)
+	if err != nil {
+		t.Fatalf("could not load service definition %v", err)
+	}
+
+	if err := DockerRunService(srv.Service, srv.Operations); err != nil {
+		t.Fatalf("expected service container created, got %v", err)
+	}
+
+	diffs, err := DockerDiff(srv.Service, srv.Operations)
+	if err != nil {
+		t.Fatalf("expected diff to succeed, got %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("expected no differences, got %v (first %q)", len(diffs), diffs[0].Field)
+	}
+}
+
+func TestDiffServiceEnvironment(t *testing.T) {
+	const (
+		name = "ipfs"
+	)
+
+	defer testutil.RemoveAllContainers()
+
+	srv, err := loaders.LoadServiceDefinition(name)
+	if err != nil {
+		t.Fatalf("could not load service definition %v", err)
+	}
+
+	if err := DockerRunService(srv.Service, srv.Operations); err != nil {
+		t.Fatalf("expected service container created, got %v", err)
+	}
+
+	srv.Service.Environment = append(srv.Service.Environment, "DIFF_TEST=1")
+	diffs, err := DockerDiff(srv.Service, srv.Operations)
+	if err != nil {
+		t.Fatalf("expected diff to succeed, got %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 difference, got %v", len(diffs))
+	}
+	if diff := diffs[0]; diff.Field != "env DIFF_TEST" || diff.Definition != "1" || diff.Container != "-" {
+		t.Fatalf("expected env DIFF_TEST difference, got %v", *diff)
+	}
+}
+
+func TestDiffEnvironment(t *testing.T) {
+	diffs := diffEnvironment(
+		[]string{"A=1", "B=2", "SECRET=new"},
+		[]string{"A=1", "B=3", "PATH=/bin", "EXTRA=x", "SECRET=old"},
+		[]string{"PATH=/bin"},
+		map[string]string{definitions.LabelSecrets: "SECRET"},
+	)
+
+	expected := []Difference{
+		{"env B", "2", "3"},
+		{"env EXTRA", "-", "x"},
+		{"env SECRET", secrets.Redacted, secrets.Redacted},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %v differences, got %v", len(expected), len(diffs))
+	}
+	for i, diff := range diffs {
+		if *diff != expected[i] {
+			t.Fatalf("expected %v, got %v", expected[i], *diff)
+		}
+	}
+}
+
+func TestFormatLinks(t *testing.T) {
+	definition := formatLinks([]string{"eris_service_keys_1:keys"})
+	container := formatLinks([]string{"/eris_service_keys_1:/eris_service_ipfs_1/keys"})
+	if definition != container {
+		t.Fatalf("expected %q, got %q", definition, container)
+	}
+}
@@ -0,0 +1,325 @@
+package pkgs
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+	"github.com/eris-ltd/eris-cli/version"
+
+	"github.com/docker/go-units"
+)
+
+// Run is a successful package run recorded in the chain ledger
+// (a file per chain in the config.LedgerPath directory).
+type Run struct {
+	ID    string `json:"id"`
+	Chain string `json:"chain"`
+
+	// Package name, the SHA-256 digest of its contracts,
+	// and the installed bundle the package comes from.
+	Package     string `json:"package"`
+	PackageHash string `json:"package_hash"`
+	Bundle      string `json:"bundle,omitempty"`
+
+	// SHA-256 digest of the epm.yaml file.
+	EPMHash string `json:"epm_hash"`
+
+	// Deploying account and contract addresses by the Eris PM job names.
+	Account   string            `json:"account"`
+	Contracts map[string]string `json:"contracts,omitempty"`
+
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+}
+
+// newRun fills in the package run record fields
+// which need to be computed before the run.
+func newRun(do *definitions.Do, pkg *definitions.Package) (*Run, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	run := &Run{
+		ID:      hex.EncodeToString(id),
+		Package: pkg.Name,
+		Bundle:  do.Bundle,
+		Account: do.DefaultAddr,
+		Version: version.VERSION,
+	}
+
+	contracts := do.PackagePath
+	if !util.DoesDirExist(contracts) {
+		contracts = do.Path
+	}
+	var err error
+	if util.DoesDirExist(contracts) {
+		if run.PackageHash, err = packageDigest(contracts); err != nil {
+			return nil, err
+		}
+	}
+
+	if do.EPMConfigFile != "" && util.DoesFileExist(do.EPMConfigFile) {
+		if run.EPMHash, err = util.FileDigest(do.EPMConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// recordRun adds the run to the ledger of the chain the package
+// was run against. Contract addresses are read from the Eris PM
+// output file (epm.json) in the do.Path directory.
+func recordRun(run *Run, do *definitions.Do) error {
+	run.Chain = do.ChainName
+	if run.Chain == "" || run.Chain == "$chain" {
+		run.Chain, _ = util.GetProjectHead()
+	}
+	if run.Chain == "" {
+		return fmt.Errorf("Cannot record the package run: unknown chain")
+	}
+	if err := checkChainName(run.Chain); err != nil {
+		return err
+	}
+	run.Contracts = deployedContracts(do.Path)
+	run.Time = time.Now()
+
+	runs, err := LoadRuns(run.Chain)
+	if err != nil {
+		return err
+	}
+	runs = append(runs, run)
+
+	content, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.LedgerPath, 0755); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"chain": run.Chain,
+		"run":   run.ID,
+	}).Warn("Recording the package run")
+	return ioutil.WriteFile(ledgerFile(run.Chain), append(content, '\n'), 0644)
+}
+
+// LoadRuns returns package runs recorded in the chain ledger, the oldest
+// first. If the chain is empty, runs recorded for all chains are returned.
+func LoadRuns(chain string) ([]*Run, error) {
+	var files []string
+	if chain == "" {
+		var err error
+		if files, err = filepath.Glob(filepath.Join(config.LedgerPath, "*.json")); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := checkChainName(chain); err != nil {
+			return nil, err
+		}
+		files = []string{ledgerFile(chain)}
+	}
+
+	runs := []*Run{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var recorded []*Run
+		if err := json.Unmarshal(content, &recorded); err != nil {
+			return nil, fmt.Errorf("Cannot read the ledger file %s: %v", file, err)
+		}
+		runs = append(runs, recorded...)
+	}
+
+	sort.Stable(byRunTime(runs))
+	return runs, nil
+}
+
+// FindRun returns the recorded package run by its ID
+// or a unique ID prefix.
+func FindRun(id string) (*Run, error) {
+	runs, err := LoadRuns("")
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Run
+	for _, run := range runs {
+		if !strings.HasPrefix(run.ID, id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("Run ID %q is ambiguous", id)
+		}
+		found = run
+	}
+	if found == nil || id == "" {
+		return nil, fmt.Errorf("Run %q is not recorded. See [eris pkgs history]", id)
+	}
+	return found, nil
+}
+
+// chainNameRegexp matches the characters allowed in Docker container
+// names, so a chain name can't lead outside of config.LedgerPath.
+var chainNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func checkChainName(chain string) error {
+	if !chainNameRegexp.MatchString(chain) {
+		return fmt.Errorf("Bad chain name %q", chain)
+	}
+	return nil
+}
+
+// ledgerFile returns the ledger file name of the
+// chain checked with checkChainName.
+func ledgerFile(chain string) string {
+	return filepath.Join(config.LedgerPath, chain+".json")
+}
+
+// packageDigest returns the hex encoded SHA-256 digest of the package
+// files in the dir directory (their names and contents). Eris PM outputs
+// (epm.json, epm.csv, and the abi directory) are left out.
+func packageDigest(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case rel == "abi" && info.IsDir():
+			return filepath.SkipDir
+		case rel == "epm.json" || rel == "epm.csv" || info.IsDir():
+			return nil
+		}
+
+		digest, err := util.FileDigest(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", rel, digest)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListRuns displays package runs recorded in chain ledgers, the latest first.
+//
+//  do.ChainName - display runs against this chain only (optional)
+//  do.JSON      - machine readable output
+//
+func ListRuns(do *definitions.Do) error {
+	runs, err := LoadRuns(do.ChainName)
+	if err != nil {
+		return err
+	}
+
+	latest := []*Run{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		latest = append(latest, runs[i])
+	}
+
+	if do.JSON {
+		return printJSON(latest)
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tCHAIN\tPACKAGE\tPACKAGE HASH\tCONTRACTS\tACCOUNT\tCREATED")
+	for _, run := range latest {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s ago\n", run.ID, run.Chain, runName(run), shortDigest(run.PackageHash),
+			len(run.Contracts), run.Account, units.HumanDuration(time.Since(run.Time)))
+	}
+	return tw.Flush()
+}
+
+// ShowRun displays a package run recorded in a chain ledger.
+//
+//  do.Name - run ID or its unique prefix (required)
+//  do.JSON - machine readable output
+//
+func ShowRun(do *definitions.Do) error {
+	run, err := FindRun(do.Name)
+	if err != nil {
+		return err
+	}
+
+	if do.JSON {
+		return printJSON(run)
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"Run", run.ID},
+		{"Chain", run.Chain},
+		{"Package", runName(run)},
+		{"Package hash", run.PackageHash},
+		{"epm.yaml hash", run.EPMHash},
+		{"Account", run.Account},
+		{"Time", run.Time.Format(time.RFC1123)},
+		{"Eris version", run.Version},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", field.name, field.value)
+	}
+
+	if len(run.Contracts) != 0 {
+		jobs := []string{}
+		for job := range run.Contracts {
+			jobs = append(jobs, job)
+		}
+		sort.Strings(jobs)
+
+		fmt.Fprintln(tw, "\nJOB\tCONTRACT")
+		for _, job := range jobs {
+			fmt.Fprintf(tw, "%s\t%s\n", job, run.Contracts[job])
+		}
+	}
+	return tw.Flush()
+}
+
+func runName(run *Run) string {
+	if run.Bundle != "" {
+		return run.Bundle
+	}
+	return run.Package
+}
+
+type byRunTime []*Run
+
+func (b byRunTime) Len() int           { return len(b) }
+func (b byRunTime) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byRunTime) Less(i, j int) bool { return b[i].Time.Before(b[j].Time) }
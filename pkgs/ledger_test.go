@@ -0,0 +1,134 @@
+package pkgs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/version"
+)
+
+func TestPackageDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-package-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "idi.sol"), []byte("contract IdisContractsFTW {}"), 0644)
+	before, err := packageDigest(dir)
+	if err != nil {
+		t.Fatalf("expected digest, got %v", err)
+	}
+
+	// Eris PM outputs don't count.
+	os.MkdirAll(filepath.Join(dir, "abi"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "abi", "IdisContractsFTW"), []byte("[]"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "epm.json"), []byte("{}"), 0644)
+	if after, _ := packageDigest(dir); after != before {
+		t.Fatalf("expected digest unchanged by outputs, got %v and %v", before, after)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "idi.sol"), []byte("contract IdisContractsFTW { uint x; }"), 0644)
+	if after, _ := packageDigest(dir); after == before {
+		t.Fatalf("expected digest to change with the contents")
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-ledger-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path string) { config.LedgerPath = path }(config.LedgerPath)
+	config.LedgerPath = filepath.Join(dir, "ledger")
+
+	pkgDir := filepath.Join(dir, "idi")
+	os.MkdirAll(pkgDir, 0755)
+	ioutil.WriteFile(filepath.Join(pkgDir, "idi.sol"), []byte("contract IdisContractsFTW {}"), 0644)
+	ioutil.WriteFile(filepath.Join(pkgDir, "epm.yaml"), []byte("jobs:"), 0644)
+
+	do := definitions.NowDo()
+	do.Path = pkgDir
+	do.PackagePath = filepath.Join(pkgDir, "contracts")
+	do.EPMConfigFile = filepath.Join(pkgDir, "epm.yaml")
+	do.DefaultAddr = "ADDR"
+
+	ids := []string{}
+	for _, chain := range []string{"simplechain", "simplechain", "otherchain"} {
+		run, err := newRun(do, &definitions.Package{Name: "idi"})
+		if err != nil {
+			t.Fatalf("expected run, got %v", err)
+		}
+		if run.PackageHash == "" || run.EPMHash == "" {
+			t.Fatalf("expected package and epm.yaml digests, got %q, %q", run.PackageHash, run.EPMHash)
+		}
+
+		ioutil.WriteFile(filepath.Join(pkgDir, "epm.json"), []byte(`{"deployStorageK": "C7A4F01D58FC60429A3330CED519BBE14563FFA4"}`), 0644)
+		do.ChainName = chain
+		if err := recordRun(run, do); err != nil {
+			t.Fatalf("expected run recorded, got %v", err)
+		}
+		ids = append(ids, run.ID)
+	}
+
+	runs, err := LoadRuns("simplechain")
+	if err != nil {
+		t.Fatalf("expected runs loaded, got %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != ids[0] || runs[1].ID != ids[1] {
+		t.Fatalf("expected two simplechain runs in order, got %v", len(runs))
+	}
+	if runs, _ := LoadRuns(""); len(runs) != 3 {
+		t.Fatalf("expected three runs in total, got %v", len(runs))
+	}
+
+	run, err := FindRun(ids[2][:6])
+	if err != nil {
+		t.Fatalf("expected run found by prefix, got %v", err)
+	}
+	if run.Chain != "otherchain" || run.Account != "ADDR" || run.Version != version.VERSION {
+		t.Fatalf("expected otherchain run by ADDR, got %v, %v, %v", run.Chain, run.Account, run.Version)
+	}
+	if run.Contracts["deployStorageK"] != "C7A4F01D58FC60429A3330CED519BBE14563FFA4" {
+		t.Fatalf("expected contract address recorded, got %v", run.Contracts)
+	}
+
+	if _, err := FindRun(""); err == nil {
+		t.Fatalf("expected empty run ID to fail")
+	}
+	if _, err := FindRun("nonexistent"); err == nil {
+		t.Fatalf("expected unknown run ID to fail")
+	}
+}
+
+func TestRecordRunBadChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-ledger-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path string) { config.LedgerPath = path }(config.LedgerPath)
+	config.LedgerPath = filepath.Join(dir, "ledger")
+
+	for _, chain := range []string{"../outside", "a/b", `a\b`, "..", ".hidden"} {
+		do := definitions.NowDo()
+		do.Path = dir
+		do.ChainName = chain
+		if err := recordRun(&Run{ID: "1"}, do); err == nil {
+			t.Fatalf("expected chain %q to be refused", chain)
+		}
+		if _, err := LoadRuns(chain); err == nil {
+			t.Fatalf("expected chain %q to be refused", chain)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Fatalf("expected nothing written outside of the ledger, got %v", files)
+	}
+}
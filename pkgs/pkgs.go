@@ -3,6 +3,7 @@ package pkgs
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
@@ -21,18 +22,67 @@ import (
 	"github.com/eris-ltd/eris-cli/util"
 )
 
+// Defaults of the [eris pkgs do] parameters.
+const (
+	DefaultGas       = "1111111111"
+	DefaultFee       = "1234"
+	DefaultAmount    = "9999"
+	DefaultChainPort = "46657"
+	DefaultKeysPort  = "4767"
+)
+
 var pwd string
 
 // RunPackage runs a package pointed to by the do.Path directory. It first loads
 // and populates the pkg struct. Then boots the dependent services and chains.
 // Then builds the appropriate pkg service to be ran in docker and properly
 // connected to all other containers. Then runs the service and finally operates
-// a cleanup.
+// a cleanup. Successful runs are recorded in the chain ledger (see ListRuns).
 //
-//  do.Path      - root directory of the pkg
-//  do.ChainName - name of the chain to run the pkgs do against
+//  do.Path        - root directory of the pkg
+//  do.ChainName   - name of the chain to run the pkgs do against
+//  do.Bundle      - installed bundle reference (GROUP/BUNDLE@VERSION) the
+//                   pkg in do.Path comes from; the pkg is run from a copy
+//                   of the bundle directory and do.Result is set to the
+//                   job results (epm.json) of the run (optional)
+//  do.DefaultAddr - deploying account recorded in the ledger
+//  do.Throwaway   - run against a throwaway chain made from do.ChainType
+//                   (see chains.ThrowawayChain) if do.ChainName is empty;
+//                   the validator is the default do.DefaultAddr; the run
+//                   is not recorded
 //
 func RunPackage(do *definitions.Do) error {
+	if do.Bundle != "" {
+		dir, err := bundleWorkDir(do)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		// The job results go away with the copy.
+		defer func() {
+			if content, err := ioutil.ReadFile(filepath.Join(dir, "epm.json")); err == nil {
+				do.Result = string(content)
+			}
+		}()
+	}
+
+	if do.Throwaway && do.ChainName == "" {
+		return chains.ThrowawayChain(do, func(name, address string) error {
+			do.ChainName = name
+			do.Operations.Labels = chains.ThrowawayLabels(do.Operations.Labels, name)
+			if do.DefaultAddr == "" {
+				do.DefaultAddr = address
+			}
+			return runPackage(do, false)
+		})
+	}
+	return runPackage(do, true)
+}
+
+// runPackage runs the package and, if record is true,
+// records the run in the chain ledger.
+func runPackage(do *definitions.Do, record bool) error {
 	log.Warn("Performing action. This can sometimes take a wee while")
 	var err error
 	pwd, err = os.Getwd()
@@ -49,6 +99,11 @@ func RunPackage(do *definitions.Do) error {
 		return err
 	}
 
+	run, err := newRun(do, pkg)
+	if err != nil {
+		return err
+	}
+
 	if err := BootServicesAndChain(do, pkg); err != nil {
 		CleanUp(do, pkg)
 		return fmt.Errorf("Could not boot chain or services: %v", err)
@@ -64,7 +119,20 @@ func RunPackage(do *definitions.Do) error {
 		return fmt.Errorf("Could not perform pkg action service: %v", err)
 	}
 
-	return CleanUp(do, pkg)
+	if err := CleanUp(do, pkg); err != nil {
+		return err
+	}
+
+	if !record {
+		return nil
+	}
+
+	// The package is deployed by now; failing to record
+	// the run shouldn't make the deployment look failed.
+	if err := recordRun(run, do); err != nil {
+		log.WithField("=>", do.ChainName).Warnf("Cannot record the package run: %v", err)
+	}
+	return nil
 }
 
 // BootServicesAndChain ensures that dependent services are started and that
@@ -105,6 +173,15 @@ func BootServicesAndChain(do *definitions.Do, pkg *definitions.Package) error {
 		srvs = append(srvs, t...)
 	}
 
+	// Compilers started for a throwaway chain run are removed with the chain.
+	if throwaway := do.Operations.Labels[definitions.LabelThrowaway]; throwaway != "" {
+		for _, srv := range srvs {
+			if srv.Operations.Labels[definitions.LabelShortName] == "compilers" {
+				srv.Operations.Labels = chains.ThrowawayLabels(srv.Operations.Labels, throwaway)
+			}
+		}
+	}
+
 	// boot the services
 	if len(srvs) >= 1 {
 		if err := services.StartGroup(srvs); err != nil {
@@ -120,13 +197,12 @@ func BootServicesAndChain(do *definitions.Do, pkg *definitions.Package) error {
 	// boot the chain
 	switch do.ChainName { // switch on the flag
 	case "", "$chain":
-		head, _ := util.GetHead() // checks the checkedout chain
-		if head != "" {           // used checked out chain
+		head, _ := util.GetProjectHead() // checks the checkedout chain
+		if head != "" {                  // used checked out chain
 			log.WithField("=>", head).Info("No chain flag or in package file. Booting chain from checked out chain")
 			err = bootChain(head, do)
-		} else { // if no chain is checked out and no --chain given, default to a throwaway
-			log.Warn("No chain was given, please start a chain")
-			err = fmt.Errorf("no more throwaway chains")
+		} else {
+			err = fmt.Errorf("No chain was given. Use the --chain flag, check out a chain, or use the --throwaway flag")
 		}
 	default:
 		log.WithField("=>", do.ChainName).Info("No chain flag used. Booting chain from package file")
@@ -0,0 +1,585 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/files"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	"github.com/docker/go-units"
+)
+
+// Bundle is a contract bundle installed in the local registry
+// (config.BundlesPath, indexed in config.BundlesIndexFile).
+type Bundle struct {
+	Group   string `json:"group"`
+	Bundle  string `json:"bundle"`
+	Version string `json:"version"`
+
+	// IPFS hash or tarball file name the bundle was installed from
+	// and the SHA-256 digest of the bundle tarball.
+	Source string `json:"source"`
+	Digest string `json:"digest"`
+
+	Installed time.Time `json:"installed"`
+}
+
+// Reference returns the GROUP/BUNDLE@VERSION bundle reference.
+func (b *Bundle) Reference() string {
+	return b.Group + "/" + b.Bundle + "@" + b.Version
+}
+
+// Path returns the bundle installation directory.
+func (b *Bundle) Path() string {
+	return BundlePath(b.Group, b.Bundle, b.Version)
+}
+
+// Chains returns the names of chains the bundle was deployed
+// to according to the package runs recorded in chain ledgers.
+func (b *Bundle) Chains(runs []*Run) []string {
+	seen := make(map[string]bool)
+	chains := []string{}
+	for _, run := range runs {
+		if run.Bundle == b.Reference() && !seen[run.Chain] {
+			seen[run.Chain] = true
+			chains = append(chains, run.Chain)
+		}
+	}
+	sort.Strings(chains)
+	return chains
+}
+
+// BundlePath returns the installation directory of the bundle version,
+// one directory per reference component: GROUP/BUNDLE/VERSION. The
+// components are expected to be checked with ValidateBundle.
+func BundlePath(group, bundle, version string) string {
+	return filepath.Join(config.BundlesPath, group, bundle, version)
+}
+
+// ValidateBundle returns an error if any of the bundle name components
+// could lead outside of config.BundlesPath.
+func ValidateBundle(group, bundle, version string) error {
+	for _, component := range []struct {
+		name, value string
+	}{
+		{"group", group},
+		{"bundle", bundle},
+		{"version", version},
+	} {
+		value := component.value
+		if value == "" || value == "." || strings.Contains(value, "..") || strings.ContainsAny(value, `/\@`+string(filepath.Separator)) {
+			return fmt.Errorf("Bad bundle %s %q", component.name, value)
+		}
+	}
+	return nil
+}
+
+// ParseBundleReference splits the GROUP/BUNDLE[@VERSION] bundle reference.
+// The version is empty if not given.
+func ParseBundleReference(reference string) (group, bundle, version string, err error) {
+	name := reference
+	if i := strings.LastIndex(reference, "@"); i != -1 {
+		name, version = reference[:i], reference[i+1:]
+		if version == "" {
+			return "", "", "", fmt.Errorf("Bundle reference %q has an empty version", reference)
+		}
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return "", "", "", fmt.Errorf("Bundle reference %q is not in the GROUP/BUNDLE[@VERSION] format", reference)
+	}
+	group, bundle = parts[0], parts[1]
+
+	checked := version
+	if checked == "" {
+		checked = "latest"
+	}
+	if err := ValidateBundle(group, bundle, checked); err != nil {
+		return "", "", "", err
+	}
+	return group, bundle, version, nil
+}
+
+// LoadBundles reads the installed bundles index. It returns
+// an empty list if there are no bundles installed.
+func LoadBundles() ([]*Bundle, error) {
+	bundles := []*Bundle{}
+
+	content, err := ioutil.ReadFile(config.BundlesIndexFile)
+	if os.IsNotExist(err) {
+		return bundles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &bundles); err != nil {
+		return nil, fmt.Errorf("Cannot read the bundles index file %s: %v", config.BundlesIndexFile, err)
+	}
+	return bundles, nil
+}
+
+func saveBundles(bundles []*Bundle) error {
+	sort.Sort(byBundleVersion(bundles))
+
+	content, err := json.MarshalIndent(bundles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.BundlesIndexFile), 0755); err != nil {
+		return err
+	}
+	log.WithField("=>", config.BundlesIndexFile).Debug("Writing bundles index")
+	return ioutil.WriteFile(config.BundlesIndexFile, append(content, '\n'), 0644)
+}
+
+// FindBundle returns the installed bundle matching the GROUP/BUNDLE[@VERSION]
+// reference. The latest installed version is returned if no version is given.
+func FindBundle(reference string) (*Bundle, error) {
+	group, name, version, err := ParseBundleReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := LoadBundles()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Bundle
+	for _, bundle := range bundles {
+		if bundle.Group != group || bundle.Bundle != name {
+			continue
+		}
+		if version != "" && bundle.Version != version {
+			continue
+		}
+		if found == nil || compareVersions(bundle.Version, found.Version) > 0 {
+			found = bundle
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("Bundle %s is not installed. Install it with [eris pkgs install]", reference)
+	}
+	return found, nil
+}
+
+// AddBundle unpacks the bundle tarball, verifies its contents against the
+// bundle manifest (see util.VerifyBundle), and only then installs it in
+// the registry, replacing the same bundle version if it was installed.
+// The source parameter is recorded in the index.
+func AddBundle(group, name, version, source, tarBallPath string) (*Bundle, error) {
+	if err := ValidateBundle(group, name, version); err != nil {
+		return nil, err
+	}
+
+	digest, err := util.FileDigest(tarBallPath)
+	if err != nil {
+		return nil, err
+	}
+
+	installPath := BundlePath(group, name, version)
+	if err := os.MkdirAll(filepath.Dir(installPath), 0755); err != nil {
+		return nil, err
+	}
+	tempDir, err := ioutil.TempDir(filepath.Dir(installPath), "."+filepath.Base(installPath)+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := util.UnpackTarball(tarBallPath, tempDir); err != nil {
+		return nil, err
+	}
+	if err := util.VerifyBundle(tempDir); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(installPath); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tempDir, installPath); err != nil {
+		return nil, err
+	}
+
+	bundles, err := LoadBundles()
+	if err != nil {
+		return nil, err
+	}
+	added := &Bundle{
+		Group:     group,
+		Bundle:    name,
+		Version:   version,
+		Source:    source,
+		Digest:    digest,
+		Installed: time.Now(),
+	}
+	kept := []*Bundle{}
+	for _, bundle := range bundles {
+		if bundle.Group == group && bundle.Bundle == name && bundle.Version == version {
+			continue
+		}
+		kept = append(kept, bundle)
+	}
+	if err := saveBundles(append(kept, added)); err != nil {
+		return nil, err
+	}
+
+	log.WithField("=>", added.Reference()).Warn("Bundle installed")
+	return added, nil
+}
+
+// bundleWorkDir copies the installed bundle (do.Path) to a temporary
+// directory and points do.Path and the do.EPMConfigFile, do.PackagePath,
+// and do.ABIPath paths inside the bundle to the copy, so that the Eris PM
+// outputs don't end up in the registry. It returns the temporary directory.
+func bundleWorkDir(do *definitions.Do) (string, error) {
+	if err := os.MkdirAll(config.ScratchPath, 0755); err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir(config.ScratchPath, "bundle-")
+	if err != nil {
+		return "", err
+	}
+	if err := util.CopyTree(do.Path, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	rebase := func(path string) string {
+		rel, err := filepath.Rel(do.Path, path)
+		if path == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return path
+		}
+		return filepath.Join(dir, rel)
+	}
+	do.EPMConfigFile = rebase(do.EPMConfigFile)
+	do.PackagePath = rebase(do.PackagePath)
+	do.ABIPath = rebase(do.ABIPath)
+	do.Path = dir
+
+	log.WithField("=>", dir).Debug("Copied the bundle to a working directory")
+	return dir, nil
+}
+
+var addressRegexp = regexp.MustCompile(`^(0x)?[0-9A-Fa-f]{40}$`)
+
+// deployedContracts returns job results which look like
+// contract addresses from the dir/epm.json file.
+func deployedContracts(dir string) map[string]string {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "epm.json"))
+	if err != nil {
+		return nil
+	}
+
+	results := make(map[string]interface{})
+	if err := json.Unmarshal(content, &results); err != nil {
+		log.WithField("=>", filepath.Join(dir, "epm.json")).Debugf("Cannot read job results: %v", err)
+		return nil
+	}
+
+	contracts := make(map[string]string)
+	for job, result := range results {
+		if address, ok := result.(string); ok && addressRegexp.MatchString(address) {
+			contracts[job] = address
+		}
+	}
+	if len(contracts) == 0 {
+		return nil
+	}
+	return contracts
+}
+
+// PackBundle packs the contract bundle directory into a tarball ready to
+// be installed with InstallBundle. The bundle manifest is (re)written to
+// the directory first (see util.PackBundle).
+//
+//  do.Path        - bundle directory (required)
+//  do.Destination - tarball file name (required)
+//
+func PackBundle(do *definitions.Do) error {
+	dir, err := filepath.Abs(do.Path)
+	if err != nil {
+		return err
+	}
+	if !util.DoesDirExist(dir) {
+		return fmt.Errorf("Bundle directory %s does not exist", do.Path)
+	}
+	tarball, err := filepath.Abs(do.Destination)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(dir, tarball); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("Cannot write the bundle tarball %s into the bundle directory", do.Destination)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(tarball), "."+filepath.Base(tarball)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = util.PackBundle(dir, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), tarball); err != nil {
+		return err
+	}
+
+	log.WithField("=>", do.Destination).Warn("Bundle packed")
+	return nil
+}
+
+// InstallBundle installs a contract bundle into the local registry from
+// a tarball or IPFS. The bundle is recognized as a tarball if do.Hash is
+// an existing file; its contents are checked against the bundle manifest
+// before installation.
+//
+//  do.Name - bundle reference in the GROUP/BUNDLE@VERSION format (required)
+//  do.Hash - tarball file name or IPFS hash of the bundle (required)
+//
+func InstallBundle(do *definitions.Do) error {
+	group, name, version, err := ParseBundleReference(do.Name)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return fmt.Errorf("Please specify the bundle version in the GROUP/BUNDLE@VERSION format")
+	}
+
+	if info, err := os.Stat(do.Hash); err == nil && info.Mode().IsRegular() {
+		_, err := AddBundle(group, name, version, filepath.Base(do.Hash), do.Hash)
+		return err
+	}
+
+	if !util.IsIPFSHash(do.Hash) {
+		return fmt.Errorf("%q is neither a tarball file nor an IPFS hash", do.Hash)
+	}
+
+	tempDir, err := ioutil.TempDir("", "eris-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	doGet := definitions.NowDo()
+	doGet.Hash = do.Hash
+	doGet.Path = filepath.Join(tempDir, "bundle.tar.gz")
+	if err := files.GetFiles(doGet); err != nil {
+		return err
+	}
+
+	_, err = AddBundle(group, name, version, do.Hash, doGet.Path)
+	return err
+}
+
+// ListBundles displays bundles installed in the local registry.
+//
+//  do.JSON - machine readable output
+//
+func ListBundles(do *definitions.Do) error {
+	bundles, err := LoadBundles()
+	if err != nil {
+		return err
+	}
+	runs, err := LoadRuns("")
+	if err != nil {
+		return err
+	}
+
+	if do.JSON {
+		return printJSON(bundles)
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "BUNDLE\tVERSION\tSOURCE\tINSTALLED\tDEPLOYED TO")
+	for _, bundle := range bundles {
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s ago\t%s\n", bundle.Group, bundle.Bundle, bundle.Version, bundle.Source,
+			units.HumanDuration(time.Since(bundle.Installed)), formatChains(bundle.Chains(runs)))
+	}
+	return tw.Flush()
+}
+
+// ListBundleVersions displays installed versions of a bundle,
+// the latest one last.
+//
+//  do.Name - bundle reference in the GROUP/BUNDLE format (required)
+//  do.JSON - machine readable output
+//
+func ListBundleVersions(do *definitions.Do) error {
+	group, name, _, err := ParseBundleReference(do.Name)
+	if err != nil {
+		return err
+	}
+	bundles, err := LoadBundles()
+	if err != nil {
+		return err
+	}
+	runs, err := LoadRuns("")
+	if err != nil {
+		return err
+	}
+
+	versions := []*Bundle{}
+	for _, bundle := range bundles {
+		if bundle.Group == group && bundle.Bundle == name {
+			versions = append(versions, bundle)
+		}
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("Bundle %s/%s is not installed", group, name)
+	}
+
+	if do.JSON {
+		return printJSON(versions)
+	}
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(config.Global.Writer, 6, 1, 5, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSOURCE\tDIGEST\tINSTALLED\tDEPLOYED TO")
+	for _, bundle := range versions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s ago\t%s\n", bundle.Version, bundle.Source, shortDigest(bundle.Digest),
+			units.HumanDuration(time.Since(bundle.Installed)), formatChains(bundle.Chains(runs)))
+	}
+	return tw.Flush()
+}
+
+// RemoveBundle removes bundle versions from the local registry.
+//
+//  do.Name - bundle reference in the GROUP/BUNDLE[@VERSION] format (required);
+//            all installed versions are removed if no version is given
+//
+func RemoveBundle(do *definitions.Do) error {
+	group, name, version, err := ParseBundleReference(do.Name)
+	if err != nil {
+		return err
+	}
+	bundles, err := LoadBundles()
+	if err != nil {
+		return err
+	}
+
+	kept := []*Bundle{}
+	removed := 0
+	for _, bundle := range bundles {
+		if bundle.Group != group || bundle.Bundle != name || (version != "" && bundle.Version != version) {
+			kept = append(kept, bundle)
+			continue
+		}
+
+		log.WithField("=>", bundle.Reference()).Warn("Removing bundle")
+		if err := os.RemoveAll(bundle.Path()); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(bundle.Path()))
+		removed++
+	}
+	if removed == 0 {
+		return fmt.Errorf("Bundle %s is not installed", do.Name)
+	}
+
+	return saveBundles(kept)
+}
+
+// removeEmptyDirs removes dir and its parents up to
+// config.BundlesPath as long as they are empty.
+func removeEmptyDirs(dir string) {
+	for dir != config.BundlesPath && strings.HasPrefix(dir, config.BundlesPath) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func printJSON(v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(config.Global.Writer, string(content))
+	return nil
+}
+
+func formatChains(chains []string) string {
+	if len(chains) == 0 {
+		return "-"
+	}
+	return strings.Join(chains, ", ")
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// compareVersions compares dot or dash separated versions component by
+// component, numerically if both components are numbers. It returns -1,
+// 0, or 1 if a is lower, equal, or greater than b respectively.
+func compareVersions(a, b string) int {
+	split := func(r rune) bool { return r == '.' || r == '-' }
+	as, bs := strings.FieldsFunc(a, split), strings.FieldsFunc(b, split)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		n, errA := strconv.Atoi(as[i])
+		m, errB := strconv.Atoi(bs[i])
+		if errA != nil || errB != nil {
+			n, m = strings.Compare(as[i], bs[i]), 0
+		}
+		switch {
+		case n < m:
+			return -1
+		case n > m:
+			return 1
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+type byBundleVersion []*Bundle
+
+func (b byBundleVersion) Len() int      { return len(b) }
+func (b byBundleVersion) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byBundleVersion) Less(i, j int) bool {
+	if b[i].Group != b[j].Group {
+		return b[i].Group < b[j].Group
+	}
+	if b[i].Bundle != b[j].Bundle {
+		return b[i].Bundle < b[j].Bundle
+	}
+	return compareVersions(b[i].Version, b[j].Version) < 0
+}
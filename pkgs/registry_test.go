@@ -0,0 +1,292 @@
+package pkgs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+func TestParseBundleReference(t *testing.T) {
+	for _, test := range []struct {
+		reference, group, bundle, version string
+		fail                              bool
+	}{
+		{reference: "io.monax/idi@1.0.0", group: "io.monax", bundle: "idi", version: "1.0.0"},
+		{reference: "io.monax/idi", group: "io.monax", bundle: "idi"},
+		{reference: "idi@1.0.0", fail: true},
+		{reference: "io.monax/idi@", fail: true},
+		{reference: "io.monax/../idi@1.0.0", fail: true},
+		{reference: "io.monax/idi@../../etc", fail: true},
+		{reference: "./idi@1.0.0", fail: true},
+	} {
+		group, bundle, version, err := ParseBundleReference(test.reference)
+		if test.fail {
+			if err == nil {
+				t.Fatalf("expected %q to fail", test.reference)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", test.reference, err)
+		}
+		if group != test.group || bundle != test.bundle || version != test.version {
+			t.Fatalf("expected %q, %q, %q, got %q, %q, %q", test.group, test.bundle, test.version, group, bundle, version)
+		}
+	}
+}
+
+func TestBundlePath(t *testing.T) {
+	if path, expected := BundlePath("io.monax.x", "idi", "1.0.0"), filepath.Join(config.BundlesPath, "io.monax.x", "idi", "1.0.0"); path != expected {
+		t.Fatalf("expected %v, got %v", expected, path)
+	}
+	for _, group := range []string{"io/monax", `io\monax`, "io..monax", "."} {
+		if err := ValidateBundle(group, "idi", "1.0.0"); err == nil {
+			t.Fatalf("expected group %q to be refused", group)
+		}
+	}
+}
+
+func TestBundleWorkDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-bundles-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path string) { config.ScratchPath = path }(config.ScratchPath)
+	config.ScratchPath = filepath.Join(dir, "scratch")
+
+	installed := filepath.Join(dir, "bundles", "io.monax", "idi", "1.0.0")
+	os.MkdirAll(installed, 0755)
+	ioutil.WriteFile(filepath.Join(installed, "epm.yaml"), []byte("jobs:"), 0644)
+
+	do := definitions.NowDo()
+	do.Path = installed
+	do.EPMConfigFile = filepath.Join(installed, "epm.yaml")
+	do.PackagePath = installed
+	do.ABIPath = filepath.Join(dir, "abi")
+
+	work, err := bundleWorkDir(do)
+	if err != nil {
+		t.Fatalf("expected a working directory, got %v", err)
+	}
+	if do.Path != work || do.PackagePath != work || do.EPMConfigFile != filepath.Join(work, "epm.yaml") {
+		t.Fatalf("expected paths pointed to the working directory, got %v, %v, %v", do.Path, do.PackagePath, do.EPMConfigFile)
+	}
+	if do.ABIPath != filepath.Join(dir, "abi") {
+		t.Fatalf("expected paths outside of the bundle untouched, got %v", do.ABIPath)
+	}
+	if !util.DoesFileExist(filepath.Join(work, "epm.yaml")) {
+		t.Fatalf("expected the bundle copied")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0", "1.0.1", -1},
+		{"2.0.0-beta", "2.0.0-alpha", 1},
+		{"01", "1", 0},
+	} {
+		if result := compareVersions(test.a, test.b); result != test.expected {
+			t.Fatalf("expected %d comparing %q and %q, got %d", test.expected, test.a, test.b, result)
+		}
+	}
+}
+
+func TestBundleRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-bundles-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path, index, ledger string) {
+		config.BundlesPath, config.BundlesIndexFile, config.LedgerPath = path, index, ledger
+	}(config.BundlesPath, config.BundlesIndexFile, config.LedgerPath)
+	config.BundlesPath = filepath.Join(dir, "bundles")
+	config.BundlesIndexFile = filepath.Join(config.BundlesPath, "index.json")
+	config.LedgerPath = filepath.Join(dir, "ledger")
+
+	tarball := writeBundleTarball(t, dir, map[string]string{
+		"epm.yaml": "jobs:",
+		"idi.sol":  "contract IdisContractsFTW {}",
+	})
+
+	for _, version := range []string{"1.0.0", "1.10.0", "1.9.0"} {
+		if _, err := AddBundle("io.monax", "idi", version, "idi.tar.gz", tarball); err != nil {
+			t.Fatalf("expected bundle %s installed, got %v", version, err)
+		}
+	}
+
+	bundle, err := FindBundle("io.monax/idi")
+	if err != nil {
+		t.Fatalf("expected bundle found, got %v", err)
+	}
+	if bundle.Version != "1.10.0" {
+		t.Fatalf("expected the latest version 1.10.0, got %v", bundle.Version)
+	}
+	if !util.DoesFileExist(filepath.Join(config.BundlesPath, "io.monax", "idi", "1.10.0", "idi.sol")) {
+		t.Fatalf("expected bundle files installed")
+	}
+
+	ioutil.WriteFile(filepath.Join(bundle.Path(), "epm.json"), []byte(`{"setStorageBase": "5", "deployStorageK": "C7A4F01D58FC60429A3330CED519BBE14563FFA4"}`), 0644)
+	doRun := definitions.NowDo()
+	doRun.Path, doRun.ChainName, doRun.Bundle = bundle.Path(), "simplechain", bundle.Reference()
+	run, err := newRun(doRun, &definitions.Package{Name: bundle.Bundle})
+	if err != nil {
+		t.Fatalf("expected a run, got %v", err)
+	}
+	if err := recordRun(run, doRun); err != nil {
+		t.Fatalf("expected deployment recorded, got %v", err)
+	}
+	runs, err := LoadRuns("simplechain")
+	if err != nil {
+		t.Fatalf("expected the ledger read, got %v", err)
+	}
+	if len(runs) != 1 || runs[0].Bundle != "io.monax/idi@1.10.0" || len(runs[0].Contracts) != 1 {
+		t.Fatalf("expected one bundle run with one contract, got %v", runs)
+	}
+	if chains := bundle.Chains(runs); len(chains) != 1 || chains[0] != "simplechain" {
+		t.Fatalf("expected the bundle deployed to simplechain, got %v", chains)
+	}
+
+	do := definitions.NowDo()
+	do.Name = "io.monax/idi@1.10.0"
+	if err := RemoveBundle(do); err != nil {
+		t.Fatalf("expected bundle removed, got %v", err)
+	}
+	if bundle, _ := FindBundle("io.monax/idi"); bundle == nil || bundle.Version != "1.9.0" {
+		t.Fatalf("expected 1.9.0 to be the latest version left, got %v", bundle)
+	}
+
+	do.Name = "io.monax/idi"
+	if err := RemoveBundle(do); err != nil {
+		t.Fatalf("expected all bundle versions removed, got %v", err)
+	}
+	if bundles, _ := LoadBundles(); len(bundles) != 0 {
+		t.Fatalf("expected no bundles left, got %v", len(bundles))
+	}
+	if util.DoesDirExist(filepath.Join(config.BundlesPath, "io.monax")) {
+		t.Fatalf("expected empty bundle directories removed")
+	}
+}
+
+func TestAddBundleTampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-bundles-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path, index string) {
+		config.BundlesPath, config.BundlesIndexFile = path, index
+	}(config.BundlesPath, config.BundlesIndexFile)
+	config.BundlesPath = filepath.Join(dir, "bundles")
+	config.BundlesIndexFile = filepath.Join(config.BundlesPath, "index.json")
+
+	tarball := writeBundleTarball(t, dir, map[string]string{
+		"epm.yaml":      "jobs:",
+		"manifest.json": `{"files": {"epm.yaml": "0000"}}`,
+	})
+
+	if _, err := AddBundle("io.monax", "idi", "1.0.0", "idi.tar.gz", tarball); err == nil {
+		t.Fatalf("expected tampered bundle to be refused")
+	}
+	if _, err := FindBundle("io.monax/idi"); err == nil {
+		t.Fatalf("expected tampered bundle not indexed")
+	}
+}
+
+func TestPackBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-bundles-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path, index string) {
+		config.BundlesPath, config.BundlesIndexFile = path, index
+	}(config.BundlesPath, config.BundlesIndexFile)
+	config.BundlesPath = filepath.Join(dir, "bundles")
+	config.BundlesIndexFile = filepath.Join(config.BundlesPath, "index.json")
+
+	source := filepath.Join(dir, "idi")
+	for name, content := range map[string]string{
+		"epm.yaml":          "jobs:",
+		"contracts/idi.sol": "contract idi {}",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(source, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(source, name), []byte(content), 0644); err != nil {
+			t.Fatalf("expected file written, got %v", err)
+		}
+	}
+
+	do := definitions.NowDo()
+	do.Path = source
+	do.Destination = filepath.Join(source, "idi.tar.gz")
+	if err := PackBundle(do); err == nil {
+		t.Fatalf("expected a tarball inside the bundle directory to be refused")
+	}
+
+	do.Destination = filepath.Join(dir, "idi.tar.gz")
+	if err := PackBundle(do); err != nil {
+		t.Fatalf("expected bundle packed, got %v", err)
+	}
+	if _, err := AddBundle("io.monax", "idi", "1.0.0", "idi.tar.gz", do.Destination); err != nil {
+		t.Fatalf("expected packed bundle installed, got %v", err)
+	}
+	if !util.DoesFileExist(filepath.Join(BundlePath("io.monax", "idi", "1.0.0"), "contracts", "idi.sol")) {
+		t.Fatalf("expected bundle files installed")
+	}
+}
+
+// writeBundleTarball writes a bundle tarball with the files. The manifest
+// is generated unless there's one among the files.
+func writeBundleTarball(t *testing.T, dir string, files map[string]string) string {
+	source, err := ioutil.TempDir(dir, "source-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(source, name), []byte(content), 0644); err != nil {
+			t.Fatalf("expected file written, got %v", err)
+		}
+	}
+	if _, ok := files[util.BundleManifestFile]; !ok {
+		if err := util.WriteBundleManifest(source); err != nil {
+			t.Fatalf("expected manifest written, got %v", err)
+		}
+	}
+
+	tarball := filepath.Join(dir, "bundle.tar.gz")
+	file, err := os.Create(tarball)
+	if err != nil {
+		t.Fatalf("expected tarball created, got %v", err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	defer gz.Close()
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	entries, _ := ioutil.ReadDir(source)
+	for _, entry := range entries {
+		content, _ := ioutil.ReadFile(filepath.Join(source, entry.Name()))
+		tw.WriteHeader(&tar.Header{Name: entry.Name(), Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write(content)
+	}
+	return tarball
+}
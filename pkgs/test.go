@@ -0,0 +1,416 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/chains"
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/util"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Test report formats.
+const (
+	FormatTAP   = "tap"
+	FormatJUnit = "junit"
+)
+
+// assertion is an Eris PM assert job read from a test file.
+type assertion struct {
+	Job      string
+	Key      string
+	Relation string
+	Value    string
+}
+
+// testCase is an evaluated assertion (or a failed test file run).
+type testCase struct {
+	Name    string
+	Failure string
+}
+
+// testSuite holds test cases of one test file.
+type testSuite struct {
+	File  string
+	Cases []testCase
+	Time  time.Duration
+}
+
+func (s *testSuite) failures() (n int) {
+	for _, c := range s.Cases {
+		if c.Failure != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// TestPackage runs Eris PM test files against a throwaway chain (or the
+// given chain) and evaluates assert jobs in each file against the job
+// results. The report is written to config.Global.Writer. TestPackage
+// returns an error if any assertion fails.
+//
+//  do.Operations.Args - test files (defaults to do.EPMConfigFile)
+//  do.Format          - report format: tap (default) or junit
+//  do.ChainName       - run against this chain instead of a throwaway one
+//  do.ChainType       - chain type to make the throwaway chain from
+//                       (defaults to simplechain)
+//  do.DefaultAddr     - deploying account (defaults to the throwaway chain
+//                       validator)
+//
+// Other fields are passed to RunPackage for each test file. Test runs
+// are not recorded in chain ledgers.
+func TestPackage(do *definitions.Do) error {
+	switch do.Format {
+	case "":
+		do.Format = FormatTAP
+	case FormatTAP, FormatJUnit:
+	default:
+		return fmt.Errorf("Unknown report format %q. Use %q or %q", do.Format, FormatTAP, FormatJUnit)
+	}
+
+	files := do.Operations.Args
+	if len(files) == 0 {
+		files = []string{do.EPMConfigFile}
+	}
+	for i, file := range files {
+		var err error
+		if files[i], err = filepath.Abs(file); err != nil {
+			return err
+		}
+		if !util.DoesFileExist(files[i]) {
+			return fmt.Errorf("Test file %s does not exist", file)
+		}
+	}
+
+	// Chain and Eris PM output goes to stderr to keep the report clean.
+	report := config.Global.Writer
+	defer func() { config.Global.Writer = report }()
+	config.Global.Writer = config.Global.ErrorWriter
+
+	if do.ChainName != "" {
+		return runTests(do, files, report)
+	}
+
+	return chains.ThrowawayChain(do, func(name, address string) error {
+		do.ChainName = name
+		do.Operations.Labels = chains.ThrowawayLabels(do.Operations.Labels, name)
+		if do.DefaultAddr == "" {
+			do.DefaultAddr = address
+		}
+		return runTests(do, files, report)
+	})
+}
+
+// runTests runs the test files and writes the report to w.
+func runTests(do *definitions.Do, files []string, w io.Writer) error {
+	suites := []*testSuite{}
+	for _, file := range files {
+		suites = append(suites, runTestFile(do, file))
+	}
+
+	var err error
+	switch do.Format {
+	case FormatTAP:
+		err = writeTAP(w, suites)
+	case FormatJUnit:
+		err = writeJUnit(w, suites)
+	}
+	if err != nil {
+		return err
+	}
+
+	var total, failed int
+	for _, suite := range suites {
+		total += len(suite.Cases)
+		failed += suite.failures()
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, total)
+	}
+	return nil
+}
+
+// runTestFile runs the file with Eris PM and evaluates its assertions.
+func runTestFile(do *definitions.Do, file string) *testSuite {
+	suite := &testSuite{File: file}
+	fail := func(err error) *testSuite {
+		suite.Cases = append(suite.Cases, testCase{Name: filepath.Base(file), Failure: err.Error()})
+		return suite
+	}
+
+	assertions, err := readAssertions(file)
+	if err != nil {
+		return fail(err)
+	}
+
+	// runPackage changes the definitions, so every file gets a fresh copy.
+	run := *do
+	run.ChainDefinition = definitions.BlankChainDefinition()
+	run.Operations = definitions.BlankOperation()
+	if throwaway := do.Operations.Labels[definitions.LabelThrowaway]; throwaway != "" {
+		run.Operations.Labels = chains.ThrowawayLabels(nil, throwaway)
+	}
+	run.Service = definitions.BlankService()
+	run.ServiceDefinition = definitions.BlankServiceDefinition()
+	run.ServicesSlice = append([]string{}, do.ServicesSlice...)
+	run.Path = filepath.Dir(file)
+	run.EPMConfigFile = file
+	run.PackagePath = filepath.Join(run.Path, "contracts")
+	if !util.DoesDirExist(run.PackagePath) {
+		run.PackagePath = run.Path
+	}
+	run.ABIPath = filepath.Join(run.Path, "abi")
+
+	// Test runs are not recorded in chain ledgers.
+	log.WithField("=>", file).Warn("Running the test file")
+	started := time.Now()
+	err = runPackage(&run, false)
+	suite.Time = time.Since(started)
+	if err != nil {
+		return fail(err)
+	}
+
+	results, err := readResults(file, started)
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, a := range assertions {
+		c := testCase{Name: a.Job}
+		if err := a.evaluate(results); err != nil {
+			c.Failure = err.Error()
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	return suite
+}
+
+// readAssertions returns assert jobs from the Eris PM file in order.
+func readAssertions(file string) ([]assertion, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var epm struct {
+		Jobs []struct {
+			Name string
+			Job  struct {
+				Assert *struct {
+					Key      string
+					Relation string
+					Val      string
+				}
+			}
+		}
+	}
+	if err := yaml.Unmarshal(content, &epm); err != nil {
+		return nil, fmt.Errorf("Cannot read the test file %s: %v", file, err)
+	}
+
+	assertions := []assertion{}
+	for _, job := range epm.Jobs {
+		if job.Job.Assert == nil {
+			continue
+		}
+		assertions = append(assertions, assertion{
+			Job:      job.Name,
+			Key:      job.Job.Assert.Key,
+			Relation: job.Job.Assert.Relation,
+			Value:    job.Job.Assert.Val,
+		})
+	}
+	if len(assertions) == 0 {
+		return nil, fmt.Errorf("No assert jobs found in %s", file)
+	}
+	return assertions, nil
+}
+
+// readResults reads job results Eris PM wrote for the test file (to
+// either a file named after the test file or to epm.json). Results
+// older than the run are ignored.
+func readResults(file string, since time.Time) (map[string]string, error) {
+	dir := filepath.Dir(file)
+	candidates := []string{
+		filepath.Join(dir, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))+".json"),
+		filepath.Join(dir, "epm.json"),
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.ModTime().Before(since.Truncate(time.Second)) {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(candidate)
+		if err != nil {
+			return nil, err
+		}
+		raw := make(map[string]interface{})
+		if err := json.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("Cannot read job results %s: %v", candidate, err)
+		}
+
+		results := make(map[string]string)
+		for job, result := range raw {
+			results[job] = fmt.Sprint(result)
+		}
+		return results, nil
+	}
+	return nil, fmt.Errorf("No job results written for %s", filepath.Base(file))
+}
+
+// evaluate checks the assertion against job results. Job names
+// prefixed with $ in the key and the value are replaced with their
+// results. Values are compared as numbers if both are numbers.
+func (a assertion) evaluate(results map[string]string) error {
+	key, err := resolveResult(a.Key, results)
+	if err != nil {
+		return err
+	}
+	value, err := resolveResult(a.Value, results)
+	if err != nil {
+		return err
+	}
+
+	cmp := strings.Compare(key, value)
+	if x, err := strconv.ParseFloat(key, 64); err == nil {
+		if y, err := strconv.ParseFloat(value, 64); err == nil {
+			switch {
+			case x < y:
+				cmp = -1
+			case x > y:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+
+	var ok bool
+	switch a.Relation {
+	case "eq", "==":
+		ok = cmp == 0
+	case "ne", "!=":
+		ok = cmp != 0
+	case "gt", ">":
+		ok = cmp > 0
+	case "ge", ">=":
+		ok = cmp >= 0
+	case "lt", "<":
+		ok = cmp < 0
+	case "le", "<=":
+		ok = cmp <= 0
+	default:
+		return fmt.Errorf("Unknown relation %q", a.Relation)
+	}
+	if !ok {
+		return fmt.Errorf("Expected %q %s %q", key, a.Relation, value)
+	}
+	return nil
+}
+
+func resolveResult(value string, results map[string]string) (string, error) {
+	if !strings.HasPrefix(value, "$") {
+		return value, nil
+	}
+	result, ok := results[strings.TrimPrefix(value, "$")]
+	if !ok {
+		return "", fmt.Errorf("No result for the job %s", strings.TrimPrefix(value, "$"))
+	}
+	return result, nil
+}
+
+// writeTAP writes the report in the Test Anything Protocol format.
+func writeTAP(w io.Writer, suites []*testSuite) error {
+	var total int
+	for _, suite := range suites {
+		total += len(suite.Cases)
+	}
+
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", total)
+
+	n := 0
+	for _, suite := range suites {
+		fmt.Fprintf(w, "# %s\n", suite.File)
+		for _, c := range suite.Cases {
+			n++
+			if c.Failure == "" {
+				fmt.Fprintf(w, "ok %d - %s\n", n, c.Name)
+				continue
+			}
+			fmt.Fprintf(w, "not ok %d - %s\n", n, c.Name)
+			fmt.Fprintf(w, "  ---\n  message: %s\n  ...\n", strconv.Quote(c.Failure))
+		}
+	}
+	return nil
+}
+
+// writeJUnit writes the report in the JUnit XML format.
+func writeJUnit(w io.Writer, suites []*testSuite) error {
+	type failure struct {
+		Message string `xml:"message,attr"`
+	}
+	type junitCase struct {
+		Name      string   `xml:"name,attr"`
+		ClassName string   `xml:"classname,attr"`
+		Failure   *failure `xml:"failure,omitempty"`
+	}
+	type junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Time     string      `xml:"time,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+	type junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+
+	report := junitSuites{}
+	for _, suite := range suites {
+		s := junitSuite{
+			Name:     filepath.Base(suite.File),
+			Tests:    len(suite.Cases),
+			Failures: suite.failures(),
+			Time:     fmt.Sprintf("%.3f", suite.Time.Seconds()),
+		}
+		for _, c := range suite.Cases {
+			jc := junitCase{Name: c.Name, ClassName: s.Name}
+			if c.Failure != "" {
+				jc.Failure = &failure{Message: c.Failure}
+			}
+			s.Cases = append(s.Cases, jc)
+		}
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Suites = append(report.Suites, s)
+	}
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", content)
+	return err
+}
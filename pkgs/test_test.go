@@ -0,0 +1,191 @@
+package pkgs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAssertions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-test-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "epm.yaml")
+	ioutil.WriteFile(file, []byte(`jobs:
+- name: setStorageBase
+  job:
+    set:
+      val: 5
+- name: queryStorage
+  job:
+    query-contract:
+      destination: $deployStorageK
+      data: get
+- name: assertStorage
+  job:
+    assert:
+      key: $queryStorage
+      relation: eq
+      val: 5
+`), 0644)
+
+	assertions, err := readAssertions(file)
+	if err != nil {
+		t.Fatalf("expected assertions read, got %v", err)
+	}
+	if len(assertions) != 1 {
+		t.Fatalf("expected one assertion, got %v", len(assertions))
+	}
+	if a := assertions[0]; a.Job != "assertStorage" || a.Key != "$queryStorage" || a.Relation != "eq" || a.Value != "5" {
+		t.Fatalf("expected assertStorage assertion, got %v", a)
+	}
+
+	ioutil.WriteFile(file, []byte("jobs:\n- name: setStorageBase\n  job:\n    set:\n      val: 5\n"), 0644)
+	if _, err := readAssertions(file); err == nil {
+		t.Fatalf("expected a file without assert jobs to fail")
+	}
+}
+
+func TestReadResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-test-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "storage.yaml")
+	ioutil.WriteFile(filepath.Join(dir, "storage.json"), []byte(`{"queryStorage": "5", "count": 3}`), 0644)
+
+	results, err := readResults(file, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("expected results read, got %v", err)
+	}
+	if results["queryStorage"] != "5" || results["count"] != "3" {
+		t.Fatalf("expected results, got %v", results)
+	}
+
+	// Results left over from earlier runs don't count.
+	if _, err := readResults(file, time.Now().Add(time.Minute)); err == nil {
+		t.Fatalf("expected stale results to be ignored")
+	}
+}
+
+func TestAssertionEvaluate(t *testing.T) {
+	results := map[string]string{
+		"queryStorage": "5",
+		"queryName":    "marmot",
+		"queryBig":     "10",
+	}
+
+	for _, test := range []struct {
+		key, relation, value string
+		fail                 bool
+	}{
+		{key: "$queryStorage", relation: "eq", value: "5"},
+		{key: "$queryStorage", relation: "==", value: "5.0"},
+		{key: "$queryStorage", relation: "ne", value: "6"},
+		{key: "$queryBig", relation: "gt", value: "$queryStorage"},
+		{key: "$queryBig", relation: ">=", value: "10"},
+		{key: "$queryStorage", relation: "lt", value: "$queryBig"},
+		{key: "$queryStorage", relation: "<=", value: "4", fail: true},
+		{key: "$queryName", relation: "eq", value: "marmot"},
+		{key: "$queryName", relation: "eq", value: "5", fail: true},
+		{key: "$queryMissing", relation: "eq", value: "5", fail: true},
+		{key: "$queryStorage", relation: "approximately", value: "5", fail: true},
+	} {
+		err := assertion{Key: test.key, Relation: test.relation, Value: test.value}.evaluate(results)
+		if test.fail && err == nil {
+			t.Fatalf("expected %s %s %s to fail", test.key, test.relation, test.value)
+		}
+		if !test.fail && err != nil {
+			t.Fatalf("expected %s %s %s to pass, got %v", test.key, test.relation, test.value, err)
+		}
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := writeTAP(buf, testSuites()); err != nil {
+		t.Fatalf("expected report written, got %v", err)
+	}
+
+	expected := `TAP version 13
+1..3
+# /tmp/storage.yaml
+ok 1 - assertStorage
+not ok 2 - assertName
+  ---
+  message: "Expected \"marmot\" eq \"monax\""
+  ...
+# /tmp/broken.yaml
+not ok 3 - broken.yaml
+  ---
+  message: "Could not perform pkg action"
+  ...
+`
+	if buf.String() != expected {
+		t.Fatalf("expected report %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := writeJUnit(buf, testSuites()); err != nil {
+		t.Fatalf("expected report written, got %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Fatalf("expected XML header, got %q", buf.String())
+	}
+
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Time  string `xml:"time,attr"`
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("expected valid XML, got %v", err)
+	}
+	if report.Tests != 3 || report.Failures != 2 || len(report.Suites) != 2 {
+		t.Fatalf("expected 3 tests, 2 failures in 2 suites, got %v, %v, %v", report.Tests, report.Failures, len(report.Suites))
+	}
+	if suite := report.Suites[0]; suite.Name != "storage.yaml" || suite.Time != "1.500" || suite.Cases[0].Failure != nil {
+		t.Fatalf("expected passing storage.yaml case, got %v", suite)
+	}
+	if failure := report.Suites[0].Cases[1].Failure; failure == nil || failure.Message != `Expected "marmot" eq "monax"` {
+		t.Fatalf("expected failure message, got %v", failure)
+	}
+}
+
+func testSuites() []*testSuite {
+	return []*testSuite{
+		{
+			File: "/tmp/storage.yaml",
+			Time: 1500 * time.Millisecond,
+			Cases: []testCase{
+				{Name: "assertStorage"},
+				{Name: "assertName", Failure: `Expected "marmot" eq "monax"`},
+			},
+		},
+		{
+			File:  "/tmp/broken.yaml",
+			Cases: []testCase{{Name: "broken.yaml", Failure: "Could not perform pkg action"}},
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+)
+
+type redactHook struct{}
+
+// Hook returns a hook for the Eris logging library which masks
+// the values of secrets read from the store in log messages and tags.
+func Hook() log.Hook {
+	return redactHook{}
+}
+
+func (redactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (redactHook) Fire(entry *log.Entry) error {
+	revealed.RLock()
+	defer revealed.RUnlock()
+
+	if len(revealed.values) == 0 {
+		return nil
+	}
+
+	entry.Message = redact(entry.Message)
+
+	// Entries share their data with parent entries,
+	// so don't modify them in place.
+	data := make(log.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		text := fmt.Sprintf("%v", value)
+		if masked := redact(text); masked != text {
+			data[key] = masked
+		} else {
+			data[key] = value
+		}
+	}
+	entry.Data = data
+
+	return nil
+}
+
+// reveal registers the value to be masked in subsequent log entries.
+func reveal(value string) {
+	if value == "" {
+		return
+	}
+
+	revealed.Lock()
+	revealed.values[value] = struct{}{}
+	revealed.Unlock()
+}
+
+// redact must be called with the revealed lock held.
+func redact(text string) string {
+	for value := range revealed.values {
+		text = strings.Replace(text, value, Redacted, -1)
+	}
+	return text
+}
+
+// RedactEnvironment returns a copy of the container environment env with
+// the values of variables set from the secrets store (as recorded in the
+// definitions.LabelSecrets container label) replaced by Redacted.
+func RedactEnvironment(env []string, labels map[string]string) []string {
+	if labels[definitions.LabelSecrets] == "" {
+		return env
+	}
+
+	secret := make(map[string]bool)
+	for _, variable := range strings.Split(labels[definitions.LabelSecrets], ",") {
+		secret[variable] = true
+	}
+
+	redacted := make([]string, len(env))
+	for i, pair := range env {
+		redacted[i] = pair
+		if variable := strings.SplitN(pair, "=", 2)[0]; secret[variable] {
+			redacted[i] = variable + "=" + Redacted
+		}
+	}
+	return redacted
+}
+
+// EnvironmentLabel returns the definitions.LabelSecrets label value
+// for the environment variables set from references.
+func EnvironmentLabel(references map[string]string) string {
+	variables := []string{}
+	for variable := range references {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	return strings.Join(variables, ",")
+}
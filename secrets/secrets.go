@@ -0,0 +1,296 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+)
+
+const (
+	// Redacted replaces secret values in log and inspect output.
+	Redacted = "[REDACTED]"
+
+	// Secret files extension.
+	extension = ".secret"
+
+	// AES-256 key length.
+	keyLength = 32
+
+	// KeyEnvVar is the environment variable holding the hex encoded
+	// secrets store key (used instead of the key file if set).
+	KeyEnvVar = "ERIS_SECRETS_KEY"
+)
+
+var (
+	// ErrSecretNotFound is returned if a secret doesn't exist in the store.
+	ErrSecretNotFound = fmt.Errorf("secret not found")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+	// Values of secrets read from the store during this run
+	// (to be masked in log entries).
+	revealed = struct {
+		sync.RWMutex
+		values map[string]struct{}
+	}{values: make(map[string]struct{})}
+)
+
+// SetSecret encrypts and saves a secret into the local secrets store
+// in the config.SecretsPath directory. An existing secret is overwritten.
+//
+//  do.Name  - secret name
+//  do.Value - secret value
+//
+func SetSecret(do *definitions.Do) error {
+	if err := checkName(do.Name); err != nil {
+		return err
+	}
+
+	key, err := storeKey(true)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(key, do.Name, []byte(do.Value))
+	if err != nil {
+		return err
+	}
+
+	log.WithField("=>", do.Name).Info("Saving secret")
+	if err := os.MkdirAll(config.SecretsPath, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(secretFile(do.Name), sealed, 0600)
+}
+
+// GetSecret decrypts and returns a secret value from the local secrets
+// store. It returns ErrSecretNotFound if the secret doesn't exist.
+//
+//  do.Name - secret name
+//
+func GetSecret(do *definitions.Do) (string, error) {
+	if err := checkName(do.Name); err != nil {
+		return "", err
+	}
+
+	return get(do.Name)
+}
+
+// ListSecrets returns a sorted list of secret names in the local secrets
+// store. Secret values are not decrypted.
+func ListSecrets(do *definitions.Do) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(config.SecretsPath, "*"+extension))
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), extension))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// RemoveSecret deletes a secret from the local secrets store. It returns
+// ErrSecretNotFound if the secret doesn't exist.
+//
+//  do.Name - secret name
+//
+func RemoveSecret(do *definitions.Do) error {
+	if err := checkName(do.Name); err != nil {
+		return err
+	}
+
+	log.WithField("=>", do.Name).Info("Removing secret")
+	if err := os.Remove(secretFile(do.Name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%v: %q", ErrSecretNotFound, do.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Environment resolves references to the secrets store (a map of
+// environment variable names to secret names, as in the "secrets"
+// section of a service definition) into a sorted list of KEY=VALUE
+// pairs to be passed to a container.
+func Environment(references map[string]string) ([]string, error) {
+	env := []string{}
+	for variable, name := range references {
+		value, err := get(name)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot set the %s environment variable: %v", variable, err)
+		}
+		env = append(env, variable+"="+value)
+	}
+	sort.Strings(env)
+
+	return env, nil
+}
+
+func get(name string) (string, error) {
+	sealed, err := ioutil.ReadFile(secretFile(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%v: %q", ErrSecretNotFound, name)
+		}
+		return "", err
+	}
+
+	key, err := storeKey(false)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := open(key, name, sealed)
+	if err != nil {
+		return "", err
+	}
+
+	reveal(string(value))
+	return string(value), nil
+}
+
+func checkName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("Secret name %q is invalid: use letters, digits, and the [._-] symbols only", name)
+	}
+	return nil
+}
+
+func secretFile(name string) string {
+	return filepath.Join(config.SecretsPath, name+extension)
+}
+
+// storeKey returns the secrets store encryption key: the hex encoded
+// $ERIS_SECRETS_KEY environment variable or the contents of the
+// config.SecretsKeyFile file, kept outside of the store directory.
+// If create is true and there is no key, a new random key is generated
+// and saved into the key file.
+func storeKey(create bool) ([]byte, error) {
+	if encoded := os.Getenv(KeyEnvVar); encoded != "" {
+		key, err := hex.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != keyLength {
+			return nil, fmt.Errorf("The $%s variable should be a %d byte hex encoded key", KeyEnvVar, keyLength)
+		}
+		return key, nil
+	}
+
+	keyFile := config.SecretsKeyFile
+	if err := moveLegacyKey(keyFile); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(keyFile)
+	if err == nil {
+		if info.Mode().Perm()&0077 != 0 {
+			return nil, fmt.Errorf("The secrets store key %s is accessible by other users. Run [chmod 600 %[1]s]", keyFile)
+		}
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the secrets store key: %v", err)
+		}
+		if len(key) != keyLength {
+			return nil, fmt.Errorf("The secrets store key %s is corrupted", keyFile)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("Cannot read the secrets store key: %v", err)
+	}
+
+	log.WithField("=>", keyFile).Info("Generating secrets store key")
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	key := make([]byte, keyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// moveLegacyKey moves the key kept inside the store directory
+// by earlier versions to the keyFile location.
+func moveLegacyKey(keyFile string) error {
+	legacy := filepath.Join(config.SecretsPath, ".key")
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(keyFile); err == nil {
+		return fmt.Errorf("Both %s and %s secrets store keys exist. Remove the one not used", legacy, keyFile)
+	}
+
+	log.WithFields(log.Fields{
+		"from": legacy,
+		"to":   keyFile,
+	}).Warn("Moving the secrets store key out of the store directory")
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(legacy, keyFile); err != nil {
+		return err
+	}
+	return os.Chmod(keyFile, 0600)
+}
+
+// seal encrypts the value with AES-GCM. The secret name is authenticated
+// along with the value, so that secret files cannot be swapped around.
+func seal(key []byte, name string, value []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, value, []byte(name)), nil
+}
+
+func open(key []byte, name string, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("Secret %q is corrupted", name)
+	}
+
+	value, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decrypt secret %q: %v", name, err)
+	}
+	return value, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
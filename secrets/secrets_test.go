@@ -0,0 +1,276 @@
+package secrets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+)
+
+var (
+	secretsDir = filepath.Join(os.TempDir(), "eris-secrets")
+	keyFile    = filepath.Join(os.TempDir(), "eris-secrets-key")
+)
+
+func TestMain(m *testing.M) {
+	log.SetLevel(log.ErrorLevel)
+	// log.SetLevel(log.InfoLevel)
+	// log.SetLevel(log.DebugLevel)
+
+	config.SecretsPath = secretsDir
+	config.SecretsKeyFile = keyFile
+	os.Unsetenv(KeyEnvVar)
+
+	exitCode := m.Run()
+	os.RemoveAll(secretsDir)
+	os.Remove(keyFile)
+	os.Exit(exitCode)
+}
+
+func TestSetGetSecret(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	do.Name = "db-password"
+	do.Value = "hunter2"
+	if err := SetSecret(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	sealed, err := ioutil.ReadFile(filepath.Join(secretsDir, "db-password.secret"))
+	if err != nil {
+		t.Fatalf("expected secret file created, got %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Fatalf("expected secret encrypted, got plain text")
+	}
+
+	do.Value = ""
+	value, err := GetSecret(do)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected := "hunter2"; value != expected {
+		t.Fatalf("expected %q, got %q", expected, value)
+	}
+}
+
+func TestStoreKeyOutsideStore(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+	defer os.Remove(keyFile)
+
+	do := definitions.NowDo()
+	do.Name = "db-password"
+	do.Value = "hunter2"
+	if err := SetSecret(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	files, _ := ioutil.ReadDir(secretsDir)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".secret") {
+			t.Fatalf("expected only secrets in the store directory, got %s", file.Name())
+		}
+	}
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("expected key file created, got %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	os.Chmod(keyFile, 0644)
+	if _, err := GetSecret(do); err == nil {
+		t.Fatalf("expected key readable by others to fail")
+	}
+}
+
+func TestStoreKeyLegacy(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+	defer os.Remove(keyFile)
+
+	os.MkdirAll(secretsDir, 0700)
+	legacy := []byte(strings.Repeat("k", 32))
+	ioutil.WriteFile(filepath.Join(secretsDir, ".key"), legacy, 0600)
+
+	key, err := storeKey(false)
+	if err != nil {
+		t.Fatalf("expected legacy key moved, got %v", err)
+	}
+	if !bytes.Equal(key, legacy) {
+		t.Fatalf("expected legacy key, got %q", key)
+	}
+	if _, err := os.Stat(filepath.Join(secretsDir, ".key")); !os.IsNotExist(err) {
+		t.Fatalf("expected legacy key removed from the store, got %v", err)
+	}
+}
+
+func TestStoreKeyEnvironment(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+	defer os.Unsetenv(KeyEnvVar)
+
+	os.Setenv(KeyEnvVar, strings.Repeat("ab", 32))
+	do := definitions.NowDo()
+	do.Name = "db-password"
+	do.Value = "hunter2"
+	if err := SetSecret(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		t.Fatalf("expected no key file created, got %v", err)
+	}
+
+	os.Setenv(KeyEnvVar, strings.Repeat("cd", 32))
+	if _, err := GetSecret(do); err == nil {
+		t.Fatalf("expected a different key to fail")
+	}
+
+	os.Setenv(KeyEnvVar, "abcd")
+	if _, err := GetSecret(do); err == nil {
+		t.Fatalf("expected a short key to fail")
+	}
+}
+
+func TestSetSecretBadName(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	do.Name = "../escape"
+	do.Value = "value"
+	if err := SetSecret(do); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+}
+
+func TestGetSecretNonExistent(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	do.Name = "non-existent"
+	if _, err := GetSecret(do); err == nil || !strings.Contains(err.Error(), ErrSecretNotFound.Error()) {
+		t.Fatalf("expected %v, got %v", ErrSecretNotFound, err)
+	}
+}
+
+func TestGetSecretSwapped(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	for _, name := range []string{"first", "second"} {
+		do.Name = name
+		do.Value = name + "-value"
+		if err := SetSecret(do); err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+	}
+
+	// Secrets are bound to their names.
+	os.Rename(filepath.Join(secretsDir, "first.secret"), filepath.Join(secretsDir, "second.secret"))
+
+	do.Name = "second"
+	if _, err := GetSecret(do); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+}
+
+func TestListRemoveSecrets(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	for _, name := range []string{"b", "a", "c"} {
+		do.Name = name
+		do.Value = "value"
+		if err := SetSecret(do); err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+	}
+
+	do.Name = "b"
+	if err := RemoveSecret(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if err := RemoveSecret(do); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+
+	names, err := ListSecrets(do)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected := []string{"a", "c"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	defer os.RemoveAll(secretsDir)
+
+	do := definitions.NowDo()
+	do.Name = "db-password"
+	do.Value = "hunter2"
+	if err := SetSecret(do); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	env, err := Environment(map[string]string{
+		"PGPASSWORD":        "db-password",
+		"POSTGRES_PASSWORD": "db-password",
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if expected := []string{"PGPASSWORD=hunter2", "POSTGRES_PASSWORD=hunter2"}; !reflect.DeepEqual(env, expected) {
+		t.Fatalf("expected %v, got %v", expected, env)
+	}
+
+	if _, err := Environment(map[string]string{"TOKEN": "non-existent"}); err == nil {
+		t.Fatal("expected failure, got nil")
+	}
+}
+
+func TestRedactEnvironment(t *testing.T) {
+	env := []string{"A=1", "SECRET=hunter2", "B=2=3"}
+	labels := map[string]string{
+		definitions.LabelSecrets: EnvironmentLabel(map[string]string{"SECRET": "db-password"}),
+	}
+
+	if expected, returned := []string{"A=1", "SECRET=" + Redacted, "B=2=3"}, RedactEnvironment(env, labels); !reflect.DeepEqual(expected, returned) {
+		t.Fatalf("expected %v, got %v", expected, returned)
+	}
+	if returned := RedactEnvironment(env, nil); !reflect.DeepEqual(env, returned) {
+		t.Fatalf("expected %v, got %v", env, returned)
+	}
+}
+
+func TestHook(t *testing.T) {
+	reveal("hunter2")
+
+	entry := &log.Entry{
+		Message: "password is hunter2",
+		Data: log.Fields{
+			"environment": []string{"A=1", "SECRET=hunter2"},
+			"other":       42,
+		},
+	}
+	if err := Hook().Fire(entry); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	if expected := "password is " + Redacted; entry.Message != expected {
+		t.Fatalf("expected %q, got %q", expected, entry.Message)
+	}
+	if expected := "[A=1 SECRET=" + Redacted + "]"; entry.Data["environment"] != expected {
+		t.Fatalf("expected %q, got %v", expected, entry.Data["environment"])
+	}
+	if expected := 42; entry.Data["other"] != expected {
+		t.Fatalf("expected %v, got %v", expected, entry.Data["other"])
+	}
+}
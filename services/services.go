@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 
@@ -238,7 +239,7 @@ func ConnectChainToService(chainFlag, chainNameAndOpts string, srv *definitions.
 	} else if strings.HasPrefix(srv.Chain, "$chain") {
 		// if there's a $chain and no flag or checked out chain, we err
 		var err error
-		chainName, err = util.GetHead()
+		chainName, err = util.GetProjectHead()
 		if chainName == "" || err != nil {
 			return nil, fmt.Errorf("Marmot disapproval face.\nYou tried to start a service which has a `$chain` variable but didn't give us a chain.\nPlease rerun the command either after [eris chains checkout CHAINNAME] *or* with a --chain flag.\n")
 		}
@@ -411,6 +412,72 @@ func PortsService(do *definitions.Do) error {
 	return nil
 }
 
+// ForwardService proxies TCP connections from local ports to the service
+// container ports (see util.Forward) until interrupted.
+//
+//  do.Name            - service name
+//  do.Operations.Args - ports to forward in the [IP:][LOCAL:]REMOTE format
+//
+func ForwardService(do *definitions.Do) error {
+	service, err := loaders.LoadServiceDefinition(do.Name)
+	if err != nil {
+		return err
+	}
+	if !util.IsService(service.Service.Name, true) {
+		return ErrServiceNotRunning
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	stop := make(chan struct{})
+	errs := make(chan error, len(do.Operations.Args))
+	for _, port := range do.Operations.Args {
+		go func(port string) {
+			errs <- util.Forward(service.Operations.SrvContainerName, port, stop)
+		}(port)
+	}
+
+	select {
+	case <-interrupt:
+		log.WithField("=>", do.Name).Warn("Stopped forwarding")
+	case err = <-errs:
+	}
+	close(stop)
+	return err
+}
+
+// DiffService compares the service definition with the existing service
+// container (see perform.DockerDiff) and displays the differences.
+//
+//  do.Name    - service name
+//  do.Apply   - recreate the container if it differs from the definition
+//  do.Timeout - seconds to wait for the service to stop before recreating
+//
+func DiffService(do *definitions.Do) error {
+	service, err := loaders.LoadServiceDefinition(do.Name)
+	if err != nil {
+		return err
+	}
+	if !util.IsService(service.Service.Name, false) {
+		return fmt.Errorf("The service container does not exist. Start it with [eris services start %s]", do.Name)
+	}
+
+	diffs, err := perform.DockerDiff(service.Service, service.Operations)
+	if err != nil {
+		return err
+	}
+	if err := perform.PrintDifferences(diffs); err != nil {
+		return err
+	}
+
+	if len(diffs) == 0 || !do.Apply {
+		return nil
+	}
+	return perform.DockerRebuild(service.Service, service.Operations, false, do.Timeout)
+}
+
 func LogsService(do *definitions.Do) error {
 	service, err := loaders.LoadServiceDefinition(do.Name)
 	if err != nil {
@@ -0,0 +1,224 @@
+package stacks
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/eris-ltd/eris-cli/chains"
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/loaders"
+	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/perform"
+	"github.com/eris-ltd/eris-cli/pkgs"
+	"github.com/eris-ltd/eris-cli/services"
+	"github.com/eris-ltd/eris-cli/util"
+)
+
+// Up brings up a stack of containers described by the stack definition
+// file: it makes the stack chain (if a chain type is given and the chain
+// doesn't exist yet) and starts it, then starts the stack services, and
+// finally deploys the stack packages to the chain. Containers created by
+// Up are labeled with the stack name (definitions.LabelStack).
+//
+//  do.Path     - stack definition file or a directory containing it (required)
+//  do.Compiler - IP:PORT of compiler to deploy the stack packages with
+//
+func Up(do *definitions.Do) error {
+	stack, err := loaders.LoadStack(do.Path)
+	if err != nil {
+		return err
+	}
+
+	if stack.Chain == nil && len(stack.Packages) != 0 {
+		return fmt.Errorf("Stack %s has packages to deploy but no chain to deploy them to", stack.Name)
+	}
+
+	log.WithField("=>", stack.Name).Warn("Bringing up stack")
+
+	var chainName string
+	if stack.Chain != nil {
+		chainName = stack.Chain.Name
+		if err := upChain(stack); err != nil {
+			return fmt.Errorf("Could not start chain %s: %v", chainName, err)
+		}
+	}
+
+	for _, name := range stack.Services {
+		log.WithField("=>", name).Warn("Starting service")
+
+		doService := definitions.NowDo()
+		doService.Operations.Args = []string{name}
+		doService.Operations.Labels = stackLabels(stack)
+		doService.ChainName = chainName
+		doService.Env = environment(stack)
+		if err := services.StartService(doService); err != nil {
+			return fmt.Errorf("Could not start service %s: %v", name, err)
+		}
+	}
+
+	for _, pkg := range stack.Packages {
+		log.WithField("=>", pkg.Path).Warn("Deploying package")
+
+		if err := pkgs.RunPackage(packageDo(do, pkg, chainName)); err != nil {
+			return fmt.Errorf("Could not deploy package %s: %v", pkg.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// Down stops and removes the containers created by Up for the stack
+// described by the stack definition file: first the services, then the
+// chain.
+//
+//  do.Path    - stack definition file or a directory containing it (required)
+//  do.RmD     - remove the stack data containers as well
+//  do.Volumes - remove the container volumes
+//  do.Force   - kill the containers instead of stopping them gracefully
+//  do.Timeout - number of seconds to wait before killing a container
+//
+func Down(do *definitions.Do) error {
+	stack, err := loaders.LoadStack(do.Path)
+	if err != nil {
+		return err
+	}
+
+	if do.Force {
+		do.Timeout = 0
+	}
+
+	log.WithField("=>", stack.Name).Warn("Tearing down stack")
+
+	members := Members(stack.Name)
+	for _, t := range []string{definitions.TypeService, definitions.TypeChain} {
+		for _, name := range members[t] {
+			ops := definitions.BlankOperation()
+			ops.SrvContainerName = util.ContainerName(t, name)
+			ops.DataContainerName = util.DataContainerName(name)
+
+			log.WithFields(log.Fields{
+				"=>":   name,
+				"type": t,
+			}).Warn("Removing container")
+			if err := perform.DockerStop(nil, ops, do.Timeout); err != nil {
+				return err
+			}
+			if err := perform.DockerRemove(nil, ops, do.RmD, do.Volumes, do.Force); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Members returns the sorted short names of the service and chain
+// containers labeled as belonging to the stack, keyed by container type.
+func Members(stackName string) map[string][]string {
+	members := make(map[string][]string)
+
+	util.ErisContainers(func(name string, details *util.Details) bool {
+		if details.Labels[definitions.LabelStack] != stackName {
+			return false
+		}
+		if details.Type == definitions.TypeData {
+			return false
+		}
+
+		members[details.Type] = append(members[details.Type], details.ShortName)
+		return true
+	}, false)
+
+	for _, names := range members {
+		sort.Strings(names)
+	}
+
+	return members
+}
+
+func upChain(stack *definitions.Stack) error {
+	chain := stack.Chain
+
+	doChain := definitions.NowDo()
+	doChain.Name = chain.Name
+	doChain.Path = chain.InitDir
+	doChain.Operations.Labels = stackLabels(stack)
+	doChain.Env = environment(stack)
+
+	if chain.Type != "" || len(chain.AccountTypes) != 0 {
+		if !util.DoesDirExist(filepath.Join(config.ChainsPath, chain.Name)) {
+			log.WithField("=>", chain.Name).Warn("Making chain")
+
+			doMake := definitions.NowDo()
+			doMake.Name = chain.Name
+			doMake.ChainType = chain.Type
+			doMake.AccountTypes = chain.AccountTypes
+			doMake.Output = true
+			doMake.RmD = true
+			if err := chains.MakeChain(doMake); err != nil {
+				return err
+			}
+		}
+
+		if doChain.Path == "" {
+			dir, err := chains.NodeDir(chain.Name)
+			if err != nil {
+				return err
+			}
+			doChain.Path = dir
+		}
+	}
+
+	log.WithField("=>", chain.Name).Warn("Starting chain")
+	return chains.StartChain(doChain)
+}
+
+// packageDo returns the [eris pkgs do] parameters for the stack package pkg,
+// using the [eris pkgs do] command defaults for values the stack doesn't set.
+func packageDo(do *definitions.Do, pkg *definitions.StackPackage, chainName string) *definitions.Do {
+	file := pkg.File
+	if file == "" {
+		file = "epm.yaml"
+	}
+
+	doPkg := definitions.NowDo()
+	doPkg.Path = pkg.Path
+	doPkg.ChainName = chainName
+	doPkg.DefaultAddr = pkg.Address
+	doPkg.EPMConfigFile = filepath.Join(pkg.Path, file)
+	doPkg.PackagePath = filepath.Join(pkg.Path, "contracts")
+	doPkg.ABIPath = filepath.Join(pkg.Path, "abi")
+	doPkg.Compiler = do.Compiler
+	doPkg.DefaultGas = pkgs.DefaultGas
+	doPkg.DefaultFee = pkgs.DefaultFee
+	doPkg.DefaultAmount = pkgs.DefaultAmount
+	doPkg.ChainPort = pkgs.DefaultChainPort
+	doPkg.KeysPort = pkgs.DefaultKeysPort
+	doPkg.Rm = true
+	doPkg.RmD = true
+	doPkg.OutputTable = true
+	doPkg.Overwrite = true
+
+	return doPkg
+}
+
+// stackLabels returns container labels marking the stack membership.
+func stackLabels(stack *definitions.Stack) map[string]string {
+	return util.SetLabel(nil, definitions.LabelStack, stack.Name)
+}
+
+// environment returns the stack environment overrides as
+// a sorted list of KEY=VALUE pairs. Like [eris services start --env],
+// overrides only reach the chain and the services listed in the stack,
+// not the services they depend on.
+func environment(stack *definitions.Stack) []string {
+	env := []string{}
+	for key, value := range stack.Environment {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	return env
+}
@@ -0,0 +1,333 @@
+package util
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+	"github.com/eris-ltd/eris-cli/log"
+
+	"github.com/fsouza/go-dockerclient/external/github.com/docker/docker/pkg/fileutils"
+)
+
+// BuildContextDir returns the absolute path to the build context directory.
+// Relative paths are relative to the service definition files directory.
+func BuildContextDir(build *definitions.Build) string {
+	dir := build.Context
+	if strings.HasPrefix(dir, "~/") {
+		dir = filepath.Join(config.HomeDir(), dir[2:])
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(config.ServicesPath, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// BuildDockerfile returns the Dockerfile path relative to the build context.
+func BuildDockerfile(build *definitions.Build) string {
+	if build.Dockerfile == "" {
+		return "Dockerfile"
+	}
+	return filepath.ToSlash(filepath.Clean(build.Dockerfile))
+}
+
+// BuildContextFiles returns paths (relative to the context directory, in
+// lexical order) of files and directories sent to the Docker daemon as the
+// build context. Paths matching patterns in the .dockerignore file are left
+// out, except for the Dockerfile and .dockerignore itself.
+func BuildContextFiles(dir, dockerfile string) ([]string, error) {
+	excludes, err := dockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Directories can't be skipped as a whole
+	// if files in them can be re-included.
+	exceptions := false
+	for _, pattern := range excludes {
+		if strings.HasPrefix(pattern, "!") {
+			exceptions = true
+		}
+	}
+
+	files := []string{}
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != dockerfile && rel != ".dockerignore" {
+			skip, err := fileutils.Matches(rel, excludes)
+			if err != nil {
+				return fmt.Errorf("Bad .dockerignore pattern: %v", err)
+			}
+			if skip {
+				if info.IsDir() && !exceptions {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func dockerignore(dir string) ([]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// BuildContextHash returns the hex encoded SHA-256 hash of the build
+// context files (names, modes, and contents) and the build parameters.
+// The hash changes whenever the image built from the context could.
+func BuildContextHash(build *definitions.Build) (string, error) {
+	dir, dockerfile := BuildContextDir(build), BuildDockerfile(build)
+
+	files, err := BuildContextFiles(dir, dockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%o\x00", file, info.Mode())
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hash, "%s\x00", link)
+		case info.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(hash, f)
+			f.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	fmt.Fprintf(hash, "dockerfile\x00%s\x00target\x00%s\x00", dockerfile, build.Target)
+	args := []string{}
+	for name := range build.Args {
+		args = append(args, name)
+	}
+	sort.Strings(args)
+	for _, name := range args {
+		fmt.Fprintf(hash, "arg\x00%s\x00%s\x00", name, build.Args[name])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// BuildContextTar returns the build context as a tar stream. The Dockerfile
+// in the stream is rewritten to apply the build arguments and the target
+// stage (see RewriteDockerfile).
+func BuildContextTar(build *definitions.Build) (io.ReadCloser, error) {
+	dir, dockerfile := BuildContextDir(build), BuildDockerfile(build)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(dockerfile)))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the Dockerfile: %v", err)
+	}
+	rewritten, unused, err := RewriteDockerfile(string(content), build.Args, build.Target)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range unused {
+		log.WithField("=>", name).Warn("Build argument not used by the Dockerfile")
+	}
+
+	files, err := BuildContextFiles(dir, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(w)
+		for _, file := range files {
+			if err := tarFile(tw, dir, file, file == dockerfile, rewritten); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+		w.CloseWithError(tw.Close())
+	}()
+	return r, nil
+}
+
+func tarFile(tw *tar.Writer, dir, file string, replace bool, content string) error {
+	path := filepath.Join(dir, filepath.FromSlash(file))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = file
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if replace {
+		header.Size = int64(len(content))
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	switch {
+	case replace:
+		_, err = io.WriteString(tw, content)
+	case info.Mode().IsRegular():
+		var f *os.File
+		if f, err = os.Open(path); err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+	}
+	return err
+}
+
+// RewriteDockerfile sets the default values of the ARG instructions to the
+// build arguments and drops the stages following the target stage (if any),
+// so that the target is built as the last stage. RewriteDockerfile returns
+// the rewritten Dockerfile and the names of arguments not declared in it.
+func RewriteDockerfile(content string, args map[string]string, target string) (string, []string, error) {
+	lines := strings.Split(content, "\n")
+
+	if target != "" {
+		found := false
+		for i, line := range lines {
+			name, ok := stageName(line)
+			if !ok {
+				continue
+			}
+			if found {
+				lines = lines[:i]
+				break
+			}
+			if name == target {
+				found = true
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("Build target %q not found in the Dockerfile", target)
+		}
+	}
+
+	used := make(map[string]bool)
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.EqualFold(fields[0], "ARG") {
+			continue
+		}
+		name := strings.SplitN(fields[1], "=", 2)[0]
+		if value, ok := args[name]; ok {
+			lines[i] = fmt.Sprintf("ARG %s=%s", name, quoteArg(value))
+			used[name] = true
+		}
+	}
+
+	unused := []string{}
+	for name := range args {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+
+	return strings.Join(lines, "\n"), unused, nil
+}
+
+// stageName returns the stage name of the FROM instruction
+// (FROM [--flag=value...] IMAGE [AS NAME] [# comment]) and true,
+// or false if the line is not a FROM instruction.
+func stageName(line string) (string, bool) {
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "#") {
+			fields = fields[:i]
+			break
+		}
+	}
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "FROM") {
+		return "", false
+	}
+
+	// Skip the flags, e.g. --platform=linux/amd64.
+	n := 1
+	for n < len(fields) && strings.HasPrefix(fields[n], "--") {
+		n++
+	}
+	// The image name is followed by AS NAME.
+	if n+2 < len(fields) && strings.EqualFold(fields[n+1], "AS") {
+		return fields[n+2], true
+	}
+	return "", true
+}
+
+func quoteArg(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return `"` + value + `"`
+}
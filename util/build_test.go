@@ -0,0 +1,143 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/definitions"
+)
+
+func TestRewriteDockerfile(t *testing.T) {
+	const dockerfile = `ARG BASE=alpine
+FROM ${BASE} AS builder
+ARG VERSION
+RUN make VERSION=$VERSION
+FROM builder AS release
+RUN make install
+FROM release AS debug
+RUN make debug`
+
+	rewritten, unused, err := RewriteDockerfile(dockerfile, map[string]string{
+		"VERSION": `1.2 "beta"`,
+		"MISSING": "x",
+	}, "release")
+	if err != nil {
+		t.Fatalf("expected Dockerfile to be rewritten, got %v", err)
+	}
+
+	const expected = `ARG BASE=alpine
+FROM ${BASE} AS builder
+ARG VERSION="1.2 \"beta\""
+RUN make VERSION=$VERSION
+FROM builder AS release
+RUN make install`
+	if rewritten != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, rewritten)
+	}
+	if !reflect.DeepEqual(unused, []string{"MISSING"}) {
+		t.Fatalf("expected MISSING to be unused, got %v", unused)
+	}
+
+	if _, _, err := RewriteDockerfile(dockerfile, nil, "unknown"); err == nil {
+		t.Fatalf("expected unknown target to fail")
+	}
+}
+
+func TestStageName(t *testing.T) {
+	for _, test := range []struct {
+		line, name string
+		from       bool
+	}{
+		{"FROM alpine", "", true},
+		{"FROM alpine AS builder", "builder", true},
+		{"from alpine as builder", "builder", true},
+		{"FROM --platform=linux/amd64 golang:1.6 AS builder", "builder", true},
+		{"FROM --platform=$BUILDPLATFORM --foo=bar golang AS builder", "builder", true},
+		{"FROM alpine AS builder # the build stage", "builder", true},
+		{"FROM alpine # AS builder", "", true},
+		{"  FROM alpine AS builder", "builder", true},
+		{"RUN echo FROM alpine AS builder", "", false},
+		{"# FROM alpine AS builder", "", false},
+		{"", "", false},
+	} {
+		name, from := stageName(test.line)
+		if name != test.name || from != test.from {
+			t.Fatalf("expected %q, %v for %q, got %q, %v", test.name, test.from, test.line, name, from)
+		}
+	}
+
+	const dockerfile = `FROM --platform=linux/amd64 golang AS builder # build
+RUN make
+FROM alpine AS release
+RUN make install`
+	rewritten, _, err := RewriteDockerfile(dockerfile, nil, "builder")
+	if err != nil {
+		t.Fatalf("expected Dockerfile to be rewritten, got %v", err)
+	}
+	if expected := "FROM --platform=linux/amd64 golang AS builder # build\nRUN make"; rewritten != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, rewritten)
+	}
+}
+
+func TestBuildContextHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-build")
+	if err != nil {
+		t.Fatalf("expected a temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for file, content := range map[string]string{
+		"Dockerfile":     "FROM alpine\n",
+		".dockerignore":  "logs\n*.tmp\n",
+		"main.go":        "package main\n",
+		"logs/today.log": "noise\n",
+		"scratch.tmp":    "noise\n",
+	} {
+		path := filepath.Join(dir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("expected to write %s, got %v", file, err)
+		}
+	}
+
+	files, err := BuildContextFiles(dir, "Dockerfile")
+	if err != nil {
+		t.Fatalf("expected context files, got %v", err)
+	}
+	if expected := []string{".dockerignore", "Dockerfile", "main.go"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected %v, got %v", expected, files)
+	}
+
+	build := &definitions.Build{Context: dir}
+	hash := func() string {
+		sum, err := BuildContextHash(build)
+		if err != nil {
+			t.Fatalf("expected context hash, got %v", err)
+		}
+		return sum
+	}
+
+	original := hash()
+
+	// Ignored files don't change the hash.
+	ioutil.WriteFile(filepath.Join(dir, "logs", "today.log"), []byte("more noise\n"), 0644)
+	if hash() != original {
+		t.Fatalf("expected ignored files not to change the hash")
+	}
+
+	// Build parameters do.
+	build.Args = map[string]string{"VERSION": "1.2"}
+	if hash() == original {
+		t.Fatalf("expected build arguments to change the hash")
+	}
+	build.Args = nil
+
+	// And so do context files.
+	ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // changed\n"), 0644)
+	if hash() == original {
+		t.Fatalf("expected context files to change the hash")
+	}
+}
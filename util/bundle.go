@@ -0,0 +1,176 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BundleManifestFile is the name of the contract bundle manifest file
+// in the bundle root directory.
+const BundleManifestFile = "manifest.json"
+
+// BundleManifest lists the contract bundle files and their digests.
+type BundleManifest struct {
+	// SHA-256 hex encoded digests of the bundle files
+	// by paths relative to the bundle root directory.
+	Files map[string]string `json:"files"`
+}
+
+// WriteBundleManifest computes digests of the files in the dir directory
+// and writes them to the bundle manifest file in that directory.
+func WriteBundleManifest(dir string) error {
+	files, err := bundleFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	manifest := BundleManifest{Files: make(map[string]string)}
+	for _, file := range files {
+		if manifest.Files[file], err = FileDigest(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, BundleManifestFile), append(content, '\n'), 0644)
+}
+
+// PackBundle writes the bundle manifest to the dir directory (see
+// WriteBundleManifest) and writes the bundle files along with the
+// manifest to w as a gzipped tar archive.
+func PackBundle(dir string, w io.Writer) error {
+	if err := WriteBundleManifest(dir); err != nil {
+		return err
+	}
+	files, err := bundleFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, file := range append(files, BundleManifestFile) {
+		if err := tarFile(tw, dir, file, false, ""); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// VerifyBundle checks the files in the dir directory against the bundle
+// manifest. It returns an error if the manifest is missing, any of the
+// files listed there is missing or has a different digest, or there are
+// files not listed in the manifest.
+func VerifyBundle(dir string) error {
+	content, err := ioutil.ReadFile(filepath.Join(dir, BundleManifestFile))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("Bundle has no %s file", BundleManifestFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("Cannot read the bundle manifest: %v", err)
+	}
+
+	files, err := bundleFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	present := make(map[string]bool)
+	for _, file := range files {
+		present[file] = true
+
+		expected, ok := manifest.Files[file]
+		if !ok {
+			return fmt.Errorf("Bundle file %s is not listed in the manifest", file)
+		}
+		digest, err := FileDigest(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(digest, expected) {
+			return fmt.Errorf("Bundle file %s has digest %s, expected %s", file, digest, expected)
+		}
+	}
+
+	for file := range manifest.Files {
+		if !present[file] {
+			return fmt.Errorf("Bundle file %s listed in the manifest is missing", file)
+		}
+	}
+	return nil
+}
+
+// bundleFiles returns paths (relative to dir, in lexical order) of regular
+// files and symbolic links in the bundle directory except the manifest.
+func bundleFiles(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel != BundleManifestFile {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// FileDigest returns the hex encoded SHA-256 digest of the file contents
+// or, for symbolic links, of the link target.
+func FileDigest(file string) (string, error) {
+	info, err := os.Lstat(file)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(hash, link)
+	} else {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if _, err := io.Copy(hash, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-bundle-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "contracts"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "contracts", "idi.sol"), []byte("contract IdisContractsFTW {}"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "epm.yaml"), []byte("jobs:"), 0644)
+
+	if err := VerifyBundle(dir); err == nil {
+		t.Fatalf("expected verification without a manifest to fail")
+	}
+
+	if err := WriteBundleManifest(dir); err != nil {
+		t.Fatalf("expected manifest written, got %v", err)
+	}
+	if err := VerifyBundle(dir); err != nil {
+		t.Fatalf("expected bundle verified, got %v", err)
+	}
+
+	// Modified file.
+	ioutil.WriteFile(filepath.Join(dir, "epm.yaml"), []byte("jobs: []"), 0644)
+	if err := VerifyBundle(dir); err == nil {
+		t.Fatalf("expected verification of a modified file to fail")
+	}
+	ioutil.WriteFile(filepath.Join(dir, "epm.yaml"), []byte("jobs:"), 0644)
+
+	// Unlisted file.
+	ioutil.WriteFile(filepath.Join(dir, "extra"), []byte{}, 0644)
+	if err := VerifyBundle(dir); err == nil {
+		t.Fatalf("expected verification with an unlisted file to fail")
+	}
+	os.Remove(filepath.Join(dir, "extra"))
+
+	// Missing file.
+	os.Remove(filepath.Join(dir, "contracts", "idi.sol"))
+	if err := VerifyBundle(dir); err == nil {
+		t.Fatalf("expected verification with a missing file to fail")
+	}
+}
@@ -1,9 +1,9 @@
 package util
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/eris-ltd/eris-cli/config"
@@ -19,31 +19,58 @@ func NullHead() error {
 }
 
 // Get the current active chain (top of the HEAD file)
-// Returns chain name
+// Returns chain name or an empty string if no chain is checked out
 func GetHead() (string, error) {
-	// TODO: only read the one line!
-	f, err := ioutil.ReadFile(config.HEAD)
-	if os.IsNotExist(err) {
-		if _, err := os.Create(config.HEAD); err != nil {
-			return "", err
-		}
-	} else {
-		return "", err
-	}
+	return readHead(config.HEAD)
+}
 
-	fspl := strings.Split(string(f), "\n")
-	head := fspl[0]
+// Get the chain in scope for the project the working directory belongs
+// to: the chain checked out for the project or, if none, the chain set
+// in the project definition file. Outside a project same as GetHead
+func GetProjectHead() (string, error) {
+	if config.Global == nil || config.Global.Project == nil {
+		return GetHead()
+	}
 
+	head, err := readHead(config.Global.Project.ProjectHEAD())
+	if err != nil {
+		return "", err
+	}
 	if head == "" {
-		return "", fmt.Errorf("There is no chain checked out")
+		head = config.Global.Project.Chain
 	}
-
 	return head, nil
 }
 
 // Add a new entry (name) to the top of the HEAD file
 // Expects the chain type and head (id) to be full (already resolved)
 func ChangeHead(name string) error {
+	return changeHead(config.HEAD, name)
+}
+
+// Add a new entry (name) to the top of the project HEAD file
+// (see GetProjectHead). Outside a project same as ChangeHead
+func ChangeProjectHead(name string) error {
+	if config.Global == nil || config.Global.Project == nil {
+		return ChangeHead(name)
+	}
+	return changeHead(config.Global.Project.ProjectHEAD(), name)
+}
+
+func readHead(file string) (string, error) {
+	// TODO: only read the one line!
+	f, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	fspl := strings.Split(string(f), "\n")
+	return fspl[0], nil
+}
+
+func changeHead(file, name string) error {
 	if !IsChain(name, false) && name != "" {
 		log.Debug("Chain name not known. Not saving")
 		return nil
@@ -52,9 +79,9 @@ func ChangeHead(name string) error {
 	log.Debug("Chain name known (or blank). Saving to head file")
 	// read in the entire head file and clip
 	// if we have reached the max length
-	b, err := ioutil.ReadFile(config.HEAD)
+	b, err := ioutil.ReadFile(file)
 	if os.IsNotExist(err) {
-		if _, err := os.Create(config.HEAD); err != nil {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
 			return err
 		}
 	} else if err != nil {
@@ -72,7 +99,7 @@ func ChangeHead(name string) error {
 	var s string
 	// handle empty head
 	s = name + "\n" + bsp
-	if err := ioutil.WriteFile(config.HEAD, []byte(s), 0666); err != nil {
+	if err := ioutil.WriteFile(file, []byte(s), 0666); err != nil {
 		return err
 	}
 
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+)
+
+func TestGetHead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-head")
+	if err != nil {
+		t.Fatalf("expected a temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saved := config.HEAD
+	config.HEAD = filepath.Join(dir, "HEAD")
+	defer func() { config.HEAD = saved }()
+
+	if head, err := GetHead(); head != "" || err != nil {
+		t.Fatalf("expected no chain checked out, got %q, %v", head, err)
+	}
+
+	ioutil.WriteFile(config.HEAD, []byte("marmot\nbeaver\n"), 0666)
+	if head, err := GetHead(); head != "marmot" || err != nil {
+		t.Fatalf("expected marmot, got %q, %v", head, err)
+	}
+}
+
+func TestGetProjectHead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-head")
+	if err != nil {
+		t.Fatalf("expected a temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saved, savedProject := config.HEAD, config.Global.Project
+	config.HEAD = filepath.Join(dir, "HEAD")
+	config.Global.Project = &config.Project{Path: filepath.Join(dir, "project")}
+	defer func() { config.HEAD, config.Global.Project = saved, savedProject }()
+
+	ioutil.WriteFile(config.HEAD, []byte("marmot\n"), 0666)
+	if head, err := GetProjectHead(); head != "" || err != nil {
+		t.Fatalf("expected the global HEAD ignored, got %q, %v", head, err)
+	}
+
+	config.Global.Project.Chain = "beaver"
+	if head, err := GetProjectHead(); head != "beaver" || err != nil {
+		t.Fatalf("expected the project chain, got %q, %v", head, err)
+	}
+
+	os.MkdirAll(filepath.Dir(config.Global.Project.ProjectHEAD()), 0755)
+	ioutil.WriteFile(config.Global.Project.ProjectHEAD(), []byte("otter\n"), 0666)
+	if head, err := GetProjectHead(); head != "otter" || err != nil {
+		t.Fatalf("expected the project checked out chain, got %q, %v", head, err)
+	}
+	if head, _ := GetHead(); head != "marmot" {
+		t.Fatalf("expected the global HEAD unchanged, got %q", head)
+	}
+}
@@ -2,20 +2,15 @@ package util
 
 import (
 	"errors"
-	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
 	"strings"
-	"time"
 
 	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/definitions"
 	"github.com/eris-ltd/eris-cli/log"
-	"github.com/eris-ltd/eris-cli/version"
+	"github.com/eris-ltd/eris-cli/secrets"
 
 	"github.com/docker/docker/pkg/jsonmessage"
-	"github.com/docker/docker/pkg/term"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/pborman/uuid"
 )
@@ -132,6 +127,7 @@ func ContainerDetails(name string) *Details {
 	}
 
 	labels := info.Config.Labels
+	info.Config.Env = secrets.RedactEnvironment(info.Config.Env, labels)
 
 	return &Details{
 		FullName:  name,
@@ -355,76 +351,74 @@ func SetLabel(labels map[string]string, name, value string) map[string]string {
 	return labels
 }
 
-// PullImage pulls an image with or without echo
-// to the writer.
+// PullImage pulls an image with or without echo to the writer.
+// Images hosted on the default registry are tried on each of the
+// configured registries in order (see Registries), with retries on
+// transient errors (see the ImagesPullRetries setting). An image pulled
+// from a mirror is tagged with its original name. If all the registries
+// fail, a *PullError listing each of them is returned.
 func PullImage(image string, writer io.Writer) error {
-	var tag string = "latest"
-
-	nameSplit := strings.Split(image, ":")
-	if len(nameSplit) == 2 {
-		tag = nameSplit[1]
-	}
-	if len(nameSplit) == 3 {
-		tag = nameSplit[2]
-	}
-	image = nameSplit[0]
+	return pullImage(image, displayStream(writer))
+}
 
-	auth := docker.AuthConfiguration{}
+// PullImageProgress is like PullImage, but instead of displaying the pull
+// progress, it passes each progress message to the progress function.
+func PullImageProgress(image string, progress func(*jsonmessage.JSONMessage)) error {
+	return pullImage(image, decodeStream(progress))
+}
 
-	r, w := io.Pipe()
-	opts := docker.PullImageOptions{
-		Repository:    image,
-		Registry:      config.Global.DefaultRegistry,
-		Tag:           tag,
-		OutputStream:  w,
-		RawJSONStream: true,
-	}
+func pullImage(image string, display func(io.Reader) error) error {
+	repository, tag := ParseImage(image)
+	registries, path := pullSources(repository)
 
-	if os.Getenv("ERIS_PULL_APPROVE") == "true" {
-		opts.OutputStream = ioutil.Discard
-	}
+	pullErr := &PullError{Image: image}
+	for _, registry := range registries {
+		source := path
+		if registry.Host != "" {
+			source = registry.Host + "/" + path
+		}
 
-	timeoutDuration, err := time.ParseDuration(config.Global.ImagesPullTimeout)
-	if err != nil {
-		return fmt.Errorf(`Cannot read the ImagesPullTimeout=%q value in eris.toml. Aborting`, config.Global.ImagesPullTimeout)
-	}
+		log.WithFields(log.Fields{
+			"=>":       image,
+			"registry": registry.Host,
+		}).Debug("Pulling image")
+		tries, err := pullFrom(registry, source, tag, display)
+		if err != nil {
+			pullErr.Attempts = append(pullErr.Attempts, PullAttempt{registry.Host, tries, err})
+			continue
+		}
 
-	ch := make(chan error)
-	timeout := make(chan error)
-	go func() {
-		defer w.Close()
-		defer close(ch)
-
-		if err := DockerClient.PullImage(opts, auth); err != nil {
-			opts.Repository = image
-			opts.Registry = version.BackupRegistry
-			if err := DockerClient.PullImage(opts, auth); err != nil {
-				ch <- DockerError(err)
+		if source != repository {
+			if err := tagPulledImage(source, repository, tag); err != nil {
+				return err
 			}
 		}
-	}()
-	go func() {
-		defer w.Close()
-		defer close(timeout)
-
-		select {
-		case <-time.After(timeoutDuration):
-			SendReport(fmt.Errorf("image pull timed out (%v)", timeoutDuration))
-			timeout <- ErrImagePullTimeout
-		}
-	}()
-	go jsonmessage.DisplayJSONMessagesStream(r, os.Stdout, os.Stdout.Fd(), term.IsTerminal(os.Stdout.Fd()), nil)
-	select {
-	case err := <-ch:
-		if err != nil {
-			return err
-		}
-	case err := <-timeout:
-		return err
+
+		// Spacer.
+		log.Warn()
+
+		return nil
 	}
 
-	// Spacer.
-	log.Warn()
+	return pullErr
+}
 
-	return nil
+// tagPulledImage tags the image pulled from a mirror with
+// the original repository name.
+func tagPulledImage(source, repository, tag string) error {
+	// Images can't be tagged with a digest.
+	if strings.Contains(tag, ":") {
+		log.WithField("=>", source+"@"+tag).Warn("Image pulled from a mirror by digest is left under the mirror name")
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"=>": source + ":" + tag,
+		"as": repository + ":" + tag,
+	}).Info("Tagging image pulled from a mirror")
+	return DockerError(DockerClient.TagImage(source+":"+tag, docker.TagImageOptions{
+		Repo:  repository,
+		Tag:   tag,
+		Force: true,
+	}))
 }
@@ -0,0 +1,152 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/log"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Time to wait for the published container port to accept a connection.
+var forwardDialTimeout = 10 * time.Second
+
+// Forward proxies TCP connections from a local port to the container port.
+// The port is given in the [IP:][LOCAL:]REMOTE format (the IP address to
+// listen on defaults to 127.0.0.1, LOCAL defaults to REMOTE).
+//
+// The host port the container port is published to is looked up for every
+// new connection, so the forward follows container restarts which change
+// the mapping. Connections are made to the Docker host (as set with the
+// DOCKER_HOST environment variable, the DockerHost setting, or Docker
+// Machine), so the forward works with remote Docker daemons as well.
+//
+// Forward blocks until the stop channel is closed.
+func Forward(container, port string, stop <-chan struct{}) error {
+	ip, local, remote := PortComponents(port)
+	if local == "" || strings.HasSuffix(remote, "/udp") {
+		return fmt.Errorf("Cannot forward %q. Use the [IP:][LOCAL:]REMOTE format with TCP ports", port)
+	}
+	if ip == "" {
+		ip = "127.0.0.1"
+	}
+
+	// Fail early if the port isn't published.
+	target, err := forwardTarget(container, docker.Port(remote))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(ip, local))
+	if err != nil {
+		return fmt.Errorf("Cannot listen on port %s: %v", local, err)
+	}
+	go func() {
+		<-stop
+		listener.Close()
+	}()
+
+	log.WithFields(log.Fields{
+		"from": listener.Addr(),
+		"to":   target,
+	}).Warnf("Forwarding port %s", remote)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		current, err := forwardTarget(container, docker.Port(remote))
+		if err != nil {
+			log.WithField("=>", remote).Errorf("Dropping connection: %v", err)
+			conn.Close()
+			continue
+		}
+		if current != target {
+			log.WithFields(log.Fields{
+				"from": target,
+				"to":   current,
+			}).Warnf("Port %s mapping changed", remote)
+			target = current
+		}
+
+		go proxy(conn, target)
+	}
+}
+
+// forwardTarget returns the address the container port is published to.
+func forwardTarget(container string, port docker.Port) (string, error) {
+	info, err := DockerClient.InspectContainer(container)
+	if err != nil {
+		return "", DockerError(err)
+	}
+	if !info.State.Running || info.NetworkSettings == nil {
+		return "", fmt.Errorf("Container %s is not running", container)
+	}
+
+	bindings := info.NetworkSettings.Ports[port]
+	if len(bindings) == 0 {
+		return "", fmt.Errorf("Port %s of container %s is not published", port, container)
+	}
+
+	host := dockerHostIP(DockerClient.Endpoint())
+	if ip := bindings[0].HostIP; host == "127.0.0.1" && ip != "" && ip != "0.0.0.0" {
+		host = ip
+	}
+	return net.JoinHostPort(host, bindings[0].HostPort), nil
+}
+
+// dockerHostIP returns the address of the host the Docker daemon
+// listening on the endpoint runs on.
+func dockerHostIP(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "unix" || u.Scheme == "npipe" {
+		return "127.0.0.1"
+	}
+
+	host, _, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host = u.Host
+	}
+	if host == "" {
+		return "127.0.0.1"
+	}
+	return host
+}
+
+// proxy copies data between the client connection and the
+// target address until both sides are done.
+func proxy(client net.Conn, target string) {
+	defer client.Close()
+
+	server, err := net.DialTimeout("tcp", target, forwardDialTimeout)
+	if err != nil {
+		log.WithField("=>", target).Errorf("Cannot connect: %v", err)
+		return
+	}
+	defer server.Close()
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		if conn, ok := dst.(*net.TCPConn); ok {
+			conn.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(server, client)
+	go pipe(client, server)
+	<-done
+	<-done
+}
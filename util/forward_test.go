@@ -0,0 +1,57 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestDockerHostIP(t *testing.T) {
+	for _, test := range []struct {
+		endpoint, host string
+	}{
+		{"unix:///var/run/docker.sock", "127.0.0.1"},
+		{"npipe:////./pipe/docker_engine", "127.0.0.1"},
+		{"tcp://192.168.99.100:2376", "192.168.99.100"},
+		{"https://docker.example.com:2376", "docker.example.com"},
+		{"tcp://10.0.0.5", "10.0.0.5"},
+		{"", "127.0.0.1"},
+	} {
+		if host := dockerHostIP(test.endpoint); host != test.host {
+			t.Fatalf("expected %q for %q, got %q", test.host, test.endpoint, host)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	// Echo server.
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected to listen, got %v", err)
+	}
+	defer server.Close()
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	client, conn := net.Pipe()
+	go proxy(conn, server.Addr().String())
+
+	if _, err := client.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("expected to write, got %v", err)
+	}
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatalf("expected to read, got %v", err)
+	}
+	if line != "hello\n" {
+		t.Fatalf("expected %q, got %q", "hello\n", line)
+	}
+	client.Close()
+}
@@ -0,0 +1,220 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/log"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// ImagesLock maps image references (in the REPOSITORY:TAG form) to
+// image digests they were resolved to by the [eris images lock] command.
+type ImagesLock map[string]string
+
+// ParseImage splits the image name into the repository (including the
+// registry host and port, if any) and the tag or digest parts. The tag
+// defaults to "latest".
+func ParseImage(image string) (repository, tag string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// NormalizeRepository returns the repository name with the implicit
+// Docker Hub registry host and "library/" namespace removed, so that
+// "docker.io/library/alpine" and "alpine" compare equal.
+func NormalizeRepository(repository string) string {
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		repository = strings.TrimPrefix(repository, prefix)
+	}
+	return strings.TrimPrefix(repository, "library/")
+}
+
+// ImageReference returns the image name in the canonical
+// REPOSITORY:TAG (or REPOSITORY@DIGEST) form.
+func ImageReference(image string) string {
+	repository, tag := ParseImage(image)
+	if strings.Contains(image, "@") {
+		return repository + "@" + tag
+	}
+	return repository + ":" + tag
+}
+
+// DefaultImageNames are short names of images Eris itself uses
+// (pulled by [eris init]).
+var DefaultImageNames = []string{
+	"data",
+	"keys",
+	"ipfs",
+	"db",
+	"cm",
+	"pm",
+	"compilers",
+}
+
+// DefaultImage returns the full image name (with the default registry
+// prefix) for the short default image name (see DefaultImageNames).
+// It returns an empty string for unknown names.
+func DefaultImage(name string) string {
+	image := map[string]string{
+		"data":      config.Global.ImageData,
+		"keys":      config.Global.ImageKeys,
+		"ipfs":      config.Global.ImageIPFS,
+		"db":        config.Global.ImageDB,
+		"cm":        config.Global.ImageCM,
+		"pm":        config.Global.ImagePM,
+		"compilers": config.Global.ImageCompilers,
+	}[name]
+
+	if image == "" {
+		return ""
+	}
+
+	// Attach default registry prefix.
+	if !strings.HasPrefix(image, config.Global.DefaultRegistry) {
+		image = path.Join(config.Global.DefaultRegistry, image)
+	}
+	return image
+}
+
+// LoadImagesLock reads the images lockfile. It returns an empty lock if the
+// lockfile doesn't exist.
+func LoadImagesLock() (ImagesLock, error) {
+	lock := make(ImagesLock)
+
+	content, err := ioutil.ReadFile(config.ImagesLockFile)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("Cannot read the images lockfile %s: %v", config.ImagesLockFile, err)
+	}
+	return lock, nil
+}
+
+// SaveImagesLock writes the images lockfile.
+func SaveImagesLock(lock ImagesLock) error {
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	log.WithField("=>", config.ImagesLockFile).Info("Writing images lockfile")
+	return ioutil.WriteFile(config.ImagesLockFile, append(content, '\n'), 0644)
+}
+
+// ImageDigest returns the registry digest of the local image.
+func ImageDigest(image string) (string, error) {
+	info, err := DockerClient.InspectImage(image)
+	if err != nil {
+		return "", DockerError(err)
+	}
+
+	repository, _ := ParseImage(image)
+	for _, repoDigest := range info.RepoDigests {
+		// Docker may report repository names in a different form
+		// (e.g. with the "docker.io/" prefix).
+		if name, digest := ParseImage(repoDigest); NormalizeRepository(name) == NormalizeRepository(repository) {
+			return digest, nil
+		}
+	}
+
+	return "", fmt.Errorf("Image %s has no registry digest (was it built locally?)", image)
+}
+
+// PinnedImage returns the REPOSITORY@DIGEST image name if the image
+// is locked in the images lockfile, the image name as given otherwise.
+func PinnedImage(image string) string {
+	if image == "" || strings.Contains(image, "@") {
+		return image
+	}
+
+	lock, err := LoadImagesLock()
+	if err != nil {
+		log.Warn(err)
+		return image
+	}
+
+	digest, ok := lock[ImageReference(image)]
+	if !ok {
+		return image
+	}
+
+	repository, _ := ParseImage(image)
+	log.WithFields(log.Fields{
+		"=>":     image,
+		"digest": digest,
+	}).Debug("Using locked image")
+	return repository + "@" + digest
+}
+
+// TagPinnedImage tags the image pulled as pinned (the REPOSITORY@DIGEST
+// name returned by PinnedImage) with the image name, so that it can also
+// be used by the REPOSITORY:TAG name (pulls by digest don't create tags).
+func TagPinnedImage(image, pinned string) error {
+	if image == pinned {
+		return nil
+	}
+
+	repository, tag := ParseImage(image)
+	log.WithFields(log.Fields{
+		"=>": pinned,
+		"as": repository + ":" + tag,
+	}).Debug("Tagging locked image")
+	return DockerError(DockerClient.TagImage(pinned, docker.TagImageOptions{
+		Repo:  repository,
+		Tag:   tag,
+		Force: true,
+	}))
+}
+
+// CheckImageDigest compares the digest of the local image with the one
+// recorded in the images lockfile. On mismatch, it either logs a warning or
+// returns an error, depending on the ImagesLockPolicy setting ("warn" or
+// "refuse").
+func CheckImageDigest(image string) error {
+	lock, err := LoadImagesLock()
+	if err != nil {
+		return err
+	}
+
+	locked, ok := lock[ImageReference(image)]
+	if !ok {
+		return nil
+	}
+
+	digest, err := ImageDigest(image)
+	if err != nil {
+		return err
+	}
+	if digest == locked {
+		return nil
+	}
+
+	if config.Global.ImagesLockPolicy == "refuse" {
+		return fmt.Errorf("Image %s digest %s doesn't match the locked digest %s.\nRun [eris images update] if the change is expected", image, digest, locked)
+	}
+
+	log.WithFields(log.Fields{
+		"=>":     image,
+		"digest": digest,
+		"locked": locked,
+	}).Warn("Image digest doesn't match the images lockfile")
+	log.Warn("The locked image will be used. Run [eris images update] if the change is expected")
+	return nil
+}
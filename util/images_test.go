@@ -0,0 +1,128 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eris-ltd/eris-cli/config"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var ParseImageTests = []struct {
+	in, repository, tag string
+}{
+	{"eris/ipfs", "eris/ipfs", "latest"},
+	{"quay.io/eris/db:0.12.0", "quay.io/eris/db", "0.12.0"},
+	{"localhost:5000/eris/db", "localhost:5000/eris/db", "latest"},
+	{"localhost:5000/eris/db:0.12.0", "localhost:5000/eris/db", "0.12.0"},
+	{"quay.io/eris/db@sha256:abcd", "quay.io/eris/db", "sha256:abcd"},
+}
+
+func TestParseImage(t *testing.T) {
+	for _, test := range ParseImageTests {
+		if repository, tag := ParseImage(test.in); repository != test.repository || tag != test.tag {
+			t.Fatalf("expected %q, %q, got %q, %q", test.repository, test.tag, repository, tag)
+		}
+	}
+}
+
+func TestImageReference(t *testing.T) {
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"eris/ipfs", "eris/ipfs:latest"},
+		{"localhost:5000/eris/db:0.12.0", "localhost:5000/eris/db:0.12.0"},
+		{"quay.io/eris/db@sha256:abcd", "quay.io/eris/db@sha256:abcd"},
+	} {
+		if actual := ImageReference(test.in); actual != test.out {
+			t.Fatalf("expected %q, got %q", test.out, actual)
+		}
+	}
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"alpine", "alpine"},
+		{"docker.io/library/alpine", "alpine"},
+		{"docker.io/eris/ipfs", "eris/ipfs"},
+		{"quay.io/eris/db", "quay.io/eris/db"},
+	} {
+		if actual := NormalizeRepository(test.in); actual != test.out {
+			t.Fatalf("expected %q, got %q", test.out, actual)
+		}
+	}
+	if NormalizeRepository("quay.io/eris/db") == NormalizeRepository("eris/db") {
+		t.Fatalf("expected repositories in different registries to differ")
+	}
+}
+
+func TestImagesLock(t *testing.T) {
+	defer os.Remove(config.ImagesLockFile)
+
+	lock, err := LoadImagesLock()
+	if err != nil {
+		t.Fatalf("expected empty lock, got %v", err)
+	}
+	if len(lock) != 0 {
+		t.Fatalf("expected empty lock, got %v", lock)
+	}
+
+	lock["quay.io/eris/db:0.12.0"] = "sha256:abcd"
+	if err := SaveImagesLock(lock); err != nil {
+		t.Fatalf("expected lock saved, got %v", err)
+	}
+
+	returned, err := LoadImagesLock()
+	if err != nil {
+		t.Fatalf("expected lock loaded, got %v", err)
+	}
+	if !reflect.DeepEqual(lock, returned) {
+		t.Fatalf("expected %v, got %v", lock, returned)
+	}
+
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"quay.io/eris/db:0.12.0", "quay.io/eris/db@sha256:abcd"},
+		{"quay.io/eris/db:0.11.0", "quay.io/eris/db:0.11.0"},
+		{"quay.io/eris/db@sha256:ef01", "quay.io/eris/db@sha256:ef01"},
+	} {
+		if actual := PinnedImage(test.in); actual != test.out {
+			t.Fatalf("expected %q, got %q", test.out, actual)
+		}
+	}
+}
+
+func TestTagPinnedImage(t *testing.T) {
+	var tagged string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tagged = r.URL.Path + "?" + r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("expected a client, got %v", err)
+	}
+	saved := DockerClient
+	DockerClient = client
+	defer func() { DockerClient = saved }()
+
+	if err := TagPinnedImage("quay.io/eris/data:0.12.0", "quay.io/eris/data:0.12.0"); err != nil || tagged != "" {
+		t.Fatalf("expected an unpinned image not to be tagged, got %q, %v", tagged, err)
+	}
+
+	if err := TagPinnedImage("quay.io/eris/data:0.12.0", "quay.io/eris/data@sha256:abcd"); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if !strings.HasPrefix(tagged, "/images/quay.io/eris/data@sha256:abcd/tag?") || !strings.Contains(tagged, "tag=0.12.0") || !strings.Contains(tagged, "repo=quay.io%2Feris%2Fdata") {
+		t.Fatalf("expected the pinned image tagged, got %q", tagged)
+	}
+}
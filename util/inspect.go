@@ -9,6 +9,7 @@ import (
 
 	"github.com/eris-ltd/eris-cli/config"
 	"github.com/eris-ltd/eris-cli/log"
+	"github.com/eris-ltd/eris-cli/secrets"
 
 	docker "github.com/fsouza/go-dockerclient"
 
@@ -17,6 +18,10 @@ import (
 )
 
 func PrintInspectionReport(cont *docker.Container, field string) error {
+	if cont.Config != nil {
+		cont.Config.Env = secrets.RedactEnvironment(cont.Config.Env, cont.Config.Labels)
+	}
+
 	switch field {
 	case "line":
 		parts, err := printLine(cont, false)
@@ -23,6 +23,21 @@ var (
 	timeout = time.Duration(10 * time.Second)
 )
 
+// IsIPFSHash returns true if hash is a base58 encoded SHA-256 multihash.
+func IsIPFSHash(hash string) bool {
+	const base58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	if len(hash) != 46 || !strings.HasPrefix(hash, "Qm") {
+		return false
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune(base58, c) {
+			return false
+		}
+	}
+	return true
+}
+
 func IPFSBaseGatewayUrl(gateway, port string) string {
 	if port == "" {
 		port = IpfsPort
@@ -9,55 +9,10 @@ import (
 	"github.com/eris-ltd/eris-cli/log"
 )
 
-//XXX this command absolutely needs a good test!!
-func MigrateDeprecatedDirs(dirsToMigrate map[string]string, prompt bool) error {
-	dirsMap, isMigNeed := dirCheckMaker(dirsToMigrate)
-	if isMigNeed {
-		log.Warn("Deprecated directories detected. Marmot migration commencing")
-	}
-
-	if !isMigNeed {
-		log.Info("Nothing to migrate")
-		return nil
-	} else if !prompt {
-		return Migrate(dirsMap)
-	} else if canWeMigrate() {
-		return Migrate(dirsMap)
-	}
-
-	return fmt.Errorf("permission to migrate not given")
-}
-
-//check that migration is actually needed
-func dirCheckMaker(dirsToMigrate map[string]string) (map[string]string, bool) {
-	newMigration := make(map[string]string)
-
-	for depDir, newDir := range dirsToMigrate {
-		log.WithFields(log.Fields{
-			"old":        depDir,
-			"old exists": DoesDirExist(depDir),
-			"new":        newDir,
-			"new exists": DoesDirExist(newDir),
-		}).Debug("Checking Directories to Migrate")
-		if !DoesDirExist(depDir) && DoesDirExist(newDir) { //already migrated, nothing to see here
-			continue
-		} else {
-			newMigration[depDir] = newDir
-		}
-	}
-	return newMigration, (len(newMigration) > 0)
-}
-
-func canWeMigrate() bool {
-	log.Warn("Permission to migrate deprecated directories required")
-	if QueryYesOrNo("Would you like to continue?") == Yes {
-		log.Debug("Confirmation verified. Proceeding")
-		return true
-	} else {
-		return false
-	}
-}
-
+// Migrate moves the deprecated directories (keys of the dirsToMigrate
+// map) to the new ones (values), merging their contents if both exist.
+// Eris root directory layout changes are made with the versioned
+// migrations of the migrate package.
 func Migrate(dirsToMigrate map[string]string) error {
 	for depDir, newDir := range dirsToMigrate {
 		log.WithFields(log.Fields{
@@ -81,7 +36,7 @@ func Migrate(dirsToMigrate map[string]string) error {
 			if err := os.Remove(depDir); err != nil {
 				return err
 			}
-		} else if !DoesDirExist(depDir) && DoesDirExist(newDir) { // old is gone, new is there; continue (dirCheckMaker should check this)
+		} else if !DoesDirExist(depDir) && DoesDirExist(newDir) { // old is gone, new is there; continue
 			continue
 		} else { //should never throw
 			return fmt.Errorf("unknown and unresolveable conflict between directory to deprecate (%s) and new directory (%s)\n", depDir, newDir)
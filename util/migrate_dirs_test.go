@@ -52,7 +52,7 @@ func TestMigrationSimple(t *testing.T) {
 	}
 
 	//migrate them
-	if err := MigrateDeprecatedDirs(dirsToMigrate, false); err != nil { //false = don't prompt
+	if err := Migrate(dirsToMigrate); err != nil {
 		ifExit(err) //but some errors are ok ?
 	}
 
@@ -94,7 +94,7 @@ func TestMigrationMoveFile(t *testing.T) {
 		dirsToMigrate[d] = newDirs[n]
 	}
 
-	if err := MigrateDeprecatedDirs(dirsToMigrate, false); err != nil { //false = don't prompt
+	if err := Migrate(dirsToMigrate); err != nil {
 		ifExit(err)
 	}
 
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"io/ioutil"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/definitions"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// OrphanFilter returns a function reporting whether an Eris container
+// has lost its owner:
+//
+//  - a data container with no chain or service of the same name
+//    (neither a container nor a definition);
+//  - a chain container with no chain directory in config.ChainsPath;
+//  - a service container with no service definition file.
+//
+// Existing containers and definitions are looked up once,
+// when OrphanFilter is called.
+func OrphanFilter() func(details *Details) bool {
+	chains := make(map[string]bool)
+	services := make(map[string]bool)
+
+	containers, _ := DockerClient.ListContainers(docker.ListContainersOptions{All: true})
+	for _, container := range containers {
+		name := container.Labels[definitions.LabelShortName]
+		switch container.Labels[definitions.LabelType] {
+		case definitions.TypeChain:
+			chains[name] = true
+		case definitions.TypeService:
+			services[name] = true
+		}
+	}
+
+	chainDirs := make(map[string]bool)
+	files, _ := ioutil.ReadDir(config.ChainsPath)
+	for _, file := range files {
+		if file.IsDir() {
+			chainDirs[file.Name()] = true
+		}
+	}
+
+	serviceFiles := make(map[string]bool)
+	for _, name := range GetGlobalLevelConfigFilesByType("services", false) {
+		serviceFiles[name] = true
+	}
+
+	return func(details *Details) bool {
+		name := details.ShortName
+		switch details.Type {
+		case definitions.TypeData:
+			return !chains[name] && !services[name] && !chainDirs[name] && !serviceFiles[name]
+		case definitions.TypeChain:
+			return !chainDirs[name]
+		case definitions.TypeService:
+			return !serviceFiles[name]
+		}
+		return false
+	}
+}
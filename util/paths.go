@@ -217,6 +217,16 @@ func MoveFile(src, dst string) error {
 	return os.Remove(src)
 }
 
+// Contains returns true if the list has the item.
+func Contains(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
 // Tilde converts the leading home directory in the path to the `~` symbol.
 // Doesn't modify the path on Windows.
 func Tilde(path string) string {
@@ -252,3 +262,18 @@ func DoesFileExist(file string) bool {
 	}
 	return true
 }
+
+// DirSize returns the total size of regular files under the path.
+func DirSize(path string) (int64, error) {
+	var total int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,297 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/go-units"
+)
+
+// Image pull states.
+const (
+	PullWaiting  = "waiting"
+	PullPulling  = "pulling"
+	PullPulled   = "pulled"
+	PullUpToDate = "up to date"
+	PullFailed   = "failed"
+)
+
+// PullSummary lists the results of PullImages.
+type PullSummary struct {
+	Pulled   []string
+	UpToDate []string
+	Failed   map[string]error
+}
+
+// PullImages pulls images concurrently (the ImagesPullConcurrency setting
+// limits the number of simultaneous pulls), displaying the combined pull
+// progress of all images to the writer. A failed pull doesn't stop the
+// others; the failures are listed in the summary returned.
+func PullImages(images []string, writer io.Writer) *PullSummary {
+	jobs := config.Global.ImagesPullConcurrency
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	progress := newPullProgress(images, writer)
+	progress.start()
+
+	var (
+		wg    sync.WaitGroup
+		queue = make(chan string)
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range queue {
+				progress.setStatus(image, PullPulling)
+				err := PullImageProgress(image, func(message *jsonmessage.JSONMessage) {
+					progress.update(image, message)
+				})
+				progress.finish(image, err)
+			}
+		}()
+	}
+	for _, image := range images {
+		queue <- image
+	}
+	close(queue)
+	wg.Wait()
+
+	progress.stop()
+	return progress.summary()
+}
+
+// pullProgress tracks and displays the progress of concurrent image pulls.
+type pullProgress struct {
+	sync.Mutex
+
+	writer   io.Writer
+	terminal bool
+	images   []string
+	states   map[string]*pullState
+
+	// Number of lines last rendered (terminal only).
+	lines int
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+type pullState struct {
+	status   string
+	upToDate bool
+	err      error
+
+	// Current and total bytes per image layer.
+	layers map[string]*jsonmessage.JSONProgress
+}
+
+func newPullProgress(images []string, writer io.Writer) *pullProgress {
+	progress := &pullProgress{
+		writer: writer,
+		images: images,
+		states: make(map[string]*pullState),
+		done:   make(chan struct{}),
+	}
+	if file, ok := writer.(*os.File); ok {
+		progress.terminal = term.IsTerminal(file.Fd())
+	}
+	for _, image := range images {
+		progress.states[image] = &pullState{
+			status: PullWaiting,
+			layers: make(map[string]*jsonmessage.JSONProgress),
+		}
+	}
+	return progress
+}
+
+// start periodically redraws the progress view on a terminal.
+func (p *pullProgress) start() {
+	if !p.terminal {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Lock()
+				p.render()
+				p.Unlock()
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *pullProgress) stop() {
+	close(p.done)
+	p.wg.Wait()
+
+	if p.terminal {
+		p.Lock()
+		p.render()
+		p.Unlock()
+	}
+}
+
+func (p *pullProgress) setStatus(image, status string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.states[image].status = status
+	if !p.terminal {
+		fmt.Fprintf(p.writer, "%s: %s\n", image, status)
+	}
+}
+
+func (p *pullProgress) update(image string, message *jsonmessage.JSONMessage) {
+	p.Lock()
+	defer p.Unlock()
+
+	state := p.states[image]
+	if strings.HasPrefix(message.Status, "Status: Image is up to date") {
+		state.upToDate = true
+		return
+	}
+	if message.ID == "" {
+		return
+	}
+
+	layer, ok := state.layers[message.ID]
+	if !ok {
+		layer = &jsonmessage.JSONProgress{}
+		state.layers[message.ID] = layer
+	}
+
+	switch message.Status {
+	case "Downloading":
+		if message.Progress != nil {
+			layer.Current = message.Progress.Current
+			layer.Total = message.Progress.Total
+		}
+	case "Download complete", "Pull complete", "Already exists":
+		layer.Current = layer.Total
+	}
+}
+
+func (p *pullProgress) finish(image string, err error) {
+	status := PullPulled
+	switch {
+	case err != nil:
+		status = PullFailed
+	case p.upToDate(image):
+		status = PullUpToDate
+	}
+
+	p.Lock()
+	p.states[image].err = err
+	p.Unlock()
+
+	p.setStatus(image, status)
+}
+
+func (p *pullProgress) upToDate(image string) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.states[image].upToDate
+}
+
+// bytes returns current and total bytes downloaded for the image.
+func (s *pullState) bytes() (current, total int64) {
+	for _, layer := range s.layers {
+		current += layer.Current
+		total += layer.Total
+	}
+	return
+}
+
+// render redraws the progress view over the previously drawn one.
+// It must be called with the lock held.
+func (p *pullProgress) render() {
+	var (
+		buf                 bytes.Buffer
+		current, total      int64
+		finished, remaining int
+	)
+
+	// 6 - minwidth, 1 - tabwidth (tab characters width), 5 - padding, ' ' - padchar, 0 - flags.
+	tw := tabwriter.NewWriter(&buf, 6, 1, 5, ' ', 0)
+	for _, image := range p.images {
+		state := p.states[image]
+		c, t := state.bytes()
+		current += c
+		total += t
+
+		switch state.status {
+		case PullPulled, PullUpToDate, PullFailed:
+			finished++
+		default:
+			remaining++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", image, state.status, sizes(c, t))
+	}
+	fmt.Fprintf(tw, "%d of %d images\t\t%s\n", finished, finished+remaining, sizes(current, total))
+	tw.Flush()
+
+	if p.lines > 0 {
+		fmt.Fprintf(p.writer, "\033[%dA", p.lines)
+	}
+	p.lines = 0
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		fmt.Fprint(p.writer, "\033[2K"+line)
+		p.lines++
+	}
+}
+
+func (p *pullProgress) summary() *PullSummary {
+	p.Lock()
+	defer p.Unlock()
+
+	summary := &PullSummary{Failed: make(map[string]error)}
+	for _, image := range p.images {
+		state := p.states[image]
+		switch state.status {
+		case PullPulled:
+			summary.Pulled = append(summary.Pulled, image)
+		case PullUpToDate:
+			summary.UpToDate = append(summary.UpToDate, image)
+		case PullFailed:
+			summary.Failed[image] = state.err
+		}
+	}
+	sort.Strings(summary.Pulled)
+	sort.Strings(summary.UpToDate)
+
+	return summary
+}
+
+func sizes(current, total int64) string {
+	if total == 0 {
+		return ""
+	}
+	return units.HumanSize(float64(current)) + " / " + units.HumanSize(float64(total))
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+func TestPullProgress(t *testing.T) {
+	var buf bytes.Buffer
+	images := []string{"quay.io/eris/data", "quay.io/eris/keys", "quay.io/eris/db"}
+	progress := newPullProgress(images, &buf)
+
+	progress.setStatus(images[0], PullPulling)
+	for _, message := range []*jsonmessage.JSONMessage{
+		{ID: "a", Status: "Downloading", Progress: &jsonmessage.JSONProgress{Current: 10, Total: 100}},
+		{ID: "b", Status: "Downloading", Progress: &jsonmessage.JSONProgress{Current: 5, Total: 50}},
+		{ID: "a", Status: "Download complete"},
+	} {
+		progress.update(images[0], message)
+	}
+	if current, total := progress.states[images[0]].bytes(); current != 105 || total != 150 {
+		t.Fatalf("expected 105 / 150 bytes, got %d / %d", current, total)
+	}
+	progress.finish(images[0], nil)
+
+	progress.setStatus(images[1], PullPulling)
+	progress.update(images[1], &jsonmessage.JSONMessage{Status: "Status: Image is up to date for quay.io/eris/keys:latest"})
+	progress.finish(images[1], nil)
+
+	progress.setStatus(images[2], PullPulling)
+	progress.finish(images[2], errors.New("not found"))
+
+	summary := progress.summary()
+	if !reflect.DeepEqual(summary.Pulled, []string{images[0]}) {
+		t.Fatalf("expected %v pulled, got %v", images[:1], summary.Pulled)
+	}
+	if !reflect.DeepEqual(summary.UpToDate, []string{images[1]}) {
+		t.Fatalf("expected %v up to date, got %v", images[1:2], summary.UpToDate)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[images[2]] == nil {
+		t.Fatalf("expected %v failed, got %v", images[2], summary.Failed)
+	}
+
+	// Not a terminal: status changes only.
+	if expected := images[2] + ": " + PullFailed + "\n"; !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("expected output to end with %q, got %q", expected, buf.String())
+	}
+}
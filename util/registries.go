@@ -0,0 +1,282 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/log"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/term"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var (
+	// Initial delay between pull retries (doubled after each retry).
+	pullBackoff = 2 * time.Second
+
+	// Time to wait for a timed out pull to be cancelled. The pull is
+	// cancelled on the next progress message the Docker daemon sends.
+	pullCancelWait = 10 * time.Second
+)
+
+// PullAttempt describes a failed attempt to pull an image from a registry.
+type PullAttempt struct {
+	Registry string
+	Tries    int
+	Err      error
+}
+
+// PullError is returned by PullImage when the image couldn't be pulled
+// from any of the registries tried.
+type PullError struct {
+	Image    string
+	Attempts []PullAttempt
+}
+
+func (e *PullError) Error() string {
+	lines := []string{fmt.Sprintf("Cannot pull image %s:", e.Image)}
+	for _, attempt := range e.Attempts {
+		lines = append(lines, fmt.Sprintf("  %s (%d tries): %v", attempt.Registry, attempt.Tries, attempt.Err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TimedOut returns true if any of the pull attempts timed out
+// (see the ImagesPullTimeout setting).
+func (e *PullError) TimedOut() bool {
+	for _, attempt := range e.Attempts {
+		if attempt.Err == ErrImagePullTimeout {
+			return true
+		}
+	}
+	return false
+}
+
+// Registries returns the ordered list of registries to pull images hosted
+// on the default registry from: the Registries setting, with the
+// DefaultRegistry setting prepended and the BackupRegistry setting
+// appended unless they are already listed.
+func Registries() []config.Registry {
+	registries := []config.Registry{}
+	seen := make(map[string]bool)
+	add := func(registry config.Registry) {
+		if registry.Host == "" || seen[registry.Host] {
+			return
+		}
+		seen[registry.Host] = true
+		registries = append(registries, registry)
+	}
+
+	if !hasRegistry(config.Global.Registries, config.Global.DefaultRegistry) {
+		add(config.Registry{Host: config.Global.DefaultRegistry})
+	}
+	for _, registry := range config.Global.Registries {
+		add(registry)
+	}
+	add(config.Registry{Host: config.Global.BackupRegistry})
+
+	return registries
+}
+
+// pullSources returns the registries to try for the image and the image
+// repository without the registry host. Images not hosted on any of the
+// configured registries are only pulled from where they are.
+func pullSources(repository string) ([]config.Registry, string) {
+	host, path := splitRegistry(repository)
+
+	registries := Registries()
+	if hasRegistry(registries, host) {
+		return registries, path
+	}
+
+	for _, registry := range config.Global.Registries {
+		if registry.Host == host {
+			return []config.Registry{registry}, path
+		}
+	}
+	return []config.Registry{{Host: host}}, path
+}
+
+// splitRegistry splits the repository name into the registry
+// host (empty for Docker Hub) and the path parts.
+func splitRegistry(repository string) (host, path string) {
+	i := strings.Index(repository, "/")
+	if i < 0 {
+		return "", repository
+	}
+	if first := repository[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+		return first, repository[i+1:]
+	}
+	return "", repository
+}
+
+func hasRegistry(registries []config.Registry, host string) bool {
+	for _, registry := range registries {
+		if registry.Host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// transient returns true if the pull error is
+// worth retrying on the same registry.
+func transient(err error) bool {
+	if err == nil || err == ErrImagePullTimeout {
+		return false
+	}
+	if e, ok := err.(*docker.Error); ok {
+		return e.Status >= 500 || e.Status == 429
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"not found",
+		"unauthorized",
+		"authentication required",
+		"denied",
+		"manifest unknown",
+	} {
+		if strings.Contains(message, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// pullFrom pulls the image from the registry, retrying
+// on transient errors. It returns the number of tries.
+func pullFrom(registry config.Registry, repository, tag string, display func(io.Reader) error) (int, error) {
+	auth := docker.AuthConfiguration{
+		Username:      registry.Username,
+		Password:      registry.Password,
+		Email:         registry.Email,
+		ServerAddress: registry.Host,
+	}
+
+	retries := config.Global.ImagesPullRetries
+	if retries < 0 {
+		retries = 0
+	}
+
+	backoff := pullBackoff
+	for try := 1; ; try++ {
+		err := pullOnce(repository, tag, auth, display)
+		if err == nil || !transient(err) || try > retries {
+			return try, err
+		}
+
+		log.WithFields(log.Fields{
+			"=>":    repository + ":" + tag,
+			"error": err,
+		}).Warnf("Retrying image pull in %v", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// pullOnce pulls the image once, giving up after the ImagesPullTimeout
+// setting duration. The pull JSON stream is passed to the display function.
+func pullOnce(repository, tag string, auth docker.AuthConfiguration, display func(io.Reader) error) error {
+	timeoutDuration, err := time.ParseDuration(config.Global.ImagesPullTimeout)
+	if err != nil {
+		return fmt.Errorf(`Cannot read the ImagesPullTimeout=%q value in eris.toml. Aborting`, config.Global.ImagesPullTimeout)
+	}
+
+	r, w := io.Pipe()
+	opts := docker.PullImageOptions{
+		Repository:    repository,
+		Tag:           tag,
+		OutputStream:  w,
+		RawJSONStream: true,
+	}
+
+	// Errors occurred after the pull has started are
+	// only reported in the JSON stream.
+	displayed := make(chan error, 1)
+	go func() {
+		err := display(r)
+		io.Copy(ioutil.Discard, r)
+		displayed <- err
+	}()
+
+	pull := make(chan error, 1)
+	go func() {
+		err := DockerClient.PullImage(opts, auth)
+		w.Close()
+		if err == nil {
+			err = <-displayed
+		}
+		pull <- err
+	}()
+
+	select {
+	case err := <-pull:
+		if err != nil {
+			return DockerError(err)
+		}
+	case <-time.After(timeoutDuration):
+		// Closing the stream fails the next write of the pull progress,
+		// which makes the Docker client drop the connection and
+		// the Docker daemon abort the pull.
+		r.CloseWithError(ErrImagePullTimeout)
+		select {
+		case <-pull:
+		case <-time.After(pullCancelWait):
+			log.WithField("=>", repository+":"+tag).Debug("Image pull still not cancelled, leaving it")
+		}
+		return ErrImagePullTimeout
+	}
+
+	return nil
+}
+
+// displayStream returns a function displaying the pull JSON stream
+// to the writer.
+func displayStream(writer io.Writer) func(io.Reader) error {
+	if os.Getenv("ERIS_PULL_APPROVE") == "true" {
+		writer = ioutil.Discard
+	}
+
+	return func(r io.Reader) error {
+		var fd uintptr
+		isTerminal := false
+		if file, ok := writer.(*os.File); ok {
+			fd = file.Fd()
+			isTerminal = term.IsTerminal(fd)
+		}
+		return jsonmessage.DisplayJSONMessagesStream(r, writer, fd, isTerminal, nil)
+	}
+}
+
+// decodeStream returns a function passing each message
+// of the pull JSON stream to the progress function.
+func decodeStream(progress func(*jsonmessage.JSONMessage)) func(io.Reader) error {
+	return func(r io.Reader) error {
+		decoder := json.NewDecoder(r)
+		for {
+			var message jsonmessage.JSONMessage
+			if err := decoder.Decode(&message); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if message.Error != nil {
+				return message.Error
+			}
+			if message.ErrorMessage != "" {
+				return errors.New(message.ErrorMessage)
+			}
+			progress(&message)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestRegistries(t *testing.T) {
+	defer func(settings config.Settings) { config.Global.Settings = settings }(config.Global.Settings)
+
+	config.Global.DefaultRegistry = "quay.io"
+	config.Global.BackupRegistry = "docker.io"
+	config.Global.Registries = []config.Registry{
+		{Host: "mirror.local:5000", Username: "marmot"},
+		{Host: "docker.io"},
+	}
+
+	hosts := []string{}
+	for _, registry := range Registries() {
+		hosts = append(hosts, registry.Host)
+	}
+	if expected := []string{"quay.io", "mirror.local:5000", "docker.io"}; !reflect.DeepEqual(hosts, expected) {
+		t.Fatalf("expected %v, got %v", expected, hosts)
+	}
+
+	// Default registry listed explicitly keeps its position.
+	config.Global.Registries = []config.Registry{
+		{Host: "mirror.local:5000"},
+		{Host: "quay.io", Username: "marmot"},
+	}
+	registries := Registries()
+	if len(registries) != 3 || registries[1].Host != "quay.io" || registries[1].Username != "marmot" {
+		t.Fatalf("expected quay.io with credentials second, got %v", registries)
+	}
+}
+
+func TestPullSources(t *testing.T) {
+	defer func(settings config.Settings) { config.Global.Settings = settings }(config.Global.Settings)
+
+	config.Global.DefaultRegistry = "quay.io"
+	config.Global.BackupRegistry = ""
+	config.Global.Registries = []config.Registry{{Host: "mirror.local:5000"}}
+
+	for _, test := range []struct {
+		repository string
+		hosts      []string
+		path       string
+	}{
+		{"quay.io/eris/db", []string{"quay.io", "mirror.local:5000"}, "eris/db"},
+		{"mirror.local:5000/eris/db", []string{"quay.io", "mirror.local:5000"}, "eris/db"},
+		{"gcr.io/project/image", []string{"gcr.io"}, "project/image"},
+		{"eris/ipfs", []string{""}, "eris/ipfs"},
+		{"ubuntu", []string{""}, "ubuntu"},
+	} {
+		registries, path := pullSources(test.repository)
+		hosts := []string{}
+		for _, registry := range registries {
+			hosts = append(hosts, registry.Host)
+		}
+		if !reflect.DeepEqual(hosts, test.hosts) || path != test.path {
+			t.Fatalf("%s: expected %v, %q, got %v, %q", test.repository, test.hosts, test.path, hosts, path)
+		}
+	}
+}
+
+func TestTransient(t *testing.T) {
+	for _, test := range []struct {
+		err       error
+		transient bool
+	}{
+		{errors.New("net/http: TLS handshake timeout"), true},
+		{errors.New("read: connection reset by peer"), true},
+		{&docker.Error{Status: 503}, true},
+		{&docker.Error{Status: 404}, false},
+		{errors.New("Error: image eris/foo not found"), false},
+		{errors.New("unauthorized: access to the requested resource is not authorized"), false},
+		{ErrImagePullTimeout, false},
+	} {
+		if actual := transient(test.err); actual != test.transient {
+			t.Fatalf("%v: expected %v, got %v", test.err, test.transient, actual)
+		}
+	}
+}
+
+func TestPullErrorMessage(t *testing.T) {
+	err := &PullError{
+		Image: "quay.io/eris/db:0.12.0",
+		Attempts: []PullAttempt{
+			{"quay.io", 4, errors.New("connection reset by peer")},
+			{"mirror.local:5000", 1, ErrImagePullTimeout},
+		},
+	}
+
+	expected := `Cannot pull image quay.io/eris/db:0.12.0:
+  quay.io (4 tries): connection reset by peer
+  mirror.local:5000 (1 tries): image pull timed out`
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+	if !err.TimedOut() {
+		t.Fatalf("expected the error to be a timeout")
+	}
+}
+
+func TestPullOnceTimeoutCancels(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		gone := w.(http.CloseNotifier).CloseNotify()
+		for i := 0; i < 100; i++ {
+			if _, err := fmt.Fprintf(w, `{"status": "Downloading", "id": "layer%d"}`+"\n", i); err != nil {
+				break
+			}
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-gone:
+				close(cancelled)
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("expected a client, got %v", err)
+	}
+	savedClient, savedTimeout := DockerClient, config.Global.ImagesPullTimeout
+	DockerClient, config.Global.ImagesPullTimeout = client, "200ms"
+	defer func() { DockerClient, config.Global.ImagesPullTimeout = savedClient, savedTimeout }()
+
+	err = pullOnce("eris/ipfs", "latest", docker.AuthConfiguration{}, func(r io.Reader) error {
+		_, err := io.Copy(ioutil.Discard, r)
+		return err
+	})
+	if err != ErrImagePullTimeout {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the pull cancelled")
+	}
+}
@@ -0,0 +1,399 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+	"github.com/eris-ltd/eris-cli/log"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Reservation is a host port reserved for a chain or service container.
+type Reservation struct {
+	Port      string    // host port number
+	Exposed   string    // container port, e.g. "46657/tcp"
+	Container string    // container name
+	Type      string    // container type
+	Name      string    // chain or service name
+	Created   time.Time // reservation time
+}
+
+// Protocol returns the reserved port protocol ("tcp" or "udp").
+func (r *Reservation) Protocol() string {
+	return protocol(r.Exposed)
+}
+
+// LoadReservations reads the host port reservations (config.PortsFile).
+// It returns an empty list if there are no reservations.
+func LoadReservations() ([]*Reservation, error) {
+	reservations := []*Reservation{}
+
+	content, err := ioutil.ReadFile(config.PortsFile)
+	if os.IsNotExist(err) {
+		return reservations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &reservations); err != nil {
+		return nil, fmt.Errorf("Cannot read the port reservations file %s: %v", config.PortsFile, err)
+	}
+	return reservations, nil
+}
+
+func saveReservations(reservations []*Reservation) error {
+	sort.Sort(byPort(reservations))
+
+	content, err := json.MarshalIndent(reservations, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a concurrent reader
+	// never sees a partially written file.
+	log.WithField("=>", config.PortsFile).Debug("Writing port reservations")
+	file, err := ioutil.TempFile(filepath.Dir(config.PortsFile), ".ports")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), config.PortsFile)
+}
+
+var (
+	// How long to wait for another eris process to release
+	// the port reservations lock.
+	reservationsLockWait = 10 * time.Second
+	// A lock older than that is considered left behind
+	// by a killed process and is removed.
+	reservationsLockStale = time.Minute
+)
+
+// lockReservations takes the port reservations lock file, so that
+// concurrent eris processes don't overwrite each other's reservations.
+// The returned function releases the lock.
+func lockReservations() (func(), error) {
+	lockFile := config.PortsFile + ".lock"
+
+	deadline := time.Now().Add(reservationsLockWait)
+	for {
+		file, err := os.OpenFile(lockFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) > reservationsLockStale {
+			log.WithField("=>", lockFile).Warn("Removing a stale port reservations lock")
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Cannot lock the port reservations file: %s is held by another eris process. Remove it if no other eris command is running", lockFile)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// CheckPorts returns an error if any of the host ports the container is
+// about to bind is reserved by another existing container, published by
+// another running container, or (with a local Docker daemon) used by
+// another process.
+func CheckPorts(container string, bindings map[docker.Port][]docker.PortBinding) error {
+	reservations, usage, err := lockedPortUsage()
+	if err != nil {
+		return err
+	}
+
+	for _, exposed := range sortedPorts(bindings) {
+		for _, binding := range bindings[exposed] {
+			if binding.HostPort == "" {
+				continue
+			}
+			if reason := usage.conflict(reservations, container, binding.HostPort, protocol(string(exposed))); reason != "" {
+				return fmt.Errorf("Host port %s for the %s port of %s is %s. Reassign it with the --ports flag, pick free ports with the --allocate-ports flag, or see [eris ports ls]",
+					binding.HostPort, exposed, container, reason)
+			}
+		}
+	}
+	return nil
+}
+
+// ReservePorts records the host ports the container binds, replacing
+// previous reservations of the container. It returns an error if another
+// existing container reserved or published any of the ports in the meantime
+// (e.g. a concurrent eris process allocated the same ports). Reservations
+// of removed containers for the same ports are dropped.
+func ReservePorts(container, typ, name string, bindings map[docker.Port][]docker.PortBinding) error {
+	unlock, err := lockReservations()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	reservations, err := LoadReservations()
+	if err != nil {
+		return err
+	}
+	usage, err := currentPortUsage()
+	if err != nil {
+		return err
+	}
+
+	added := []*Reservation{}
+	bound := make(map[string]bool)
+	for _, exposed := range sortedPorts(bindings) {
+		for _, binding := range bindings[exposed] {
+			if binding.HostPort == "" {
+				continue
+			}
+			if reason := usage.taken(reservations, container, binding.HostPort, protocol(string(exposed))); reason != "" {
+				return fmt.Errorf("Host port %s for the %s port of %s is %s", binding.HostPort, exposed, container, reason)
+			}
+			added = append(added, &Reservation{
+				Port:      binding.HostPort,
+				Exposed:   string(exposed),
+				Container: container,
+				Type:      typ,
+				Name:      name,
+				Created:   time.Now(),
+			})
+			bound[binding.HostPort+"/"+protocol(string(exposed))] = true
+		}
+	}
+
+	kept := []*Reservation{}
+	for _, r := range reservations {
+		if r.Container == container || bound[r.Port+"/"+r.Protocol()] {
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	return saveReservations(append(kept, added...))
+}
+
+// AllocatePorts hands out host ports for the container ports (in the
+// definition file format, see PortComponents). Ports previously reserved
+// for the container are handed out again if they are still free; otherwise
+// the first free port starting from the published port in the definition
+// is used. AllocatePorts returns assignments in the PUBLISHED:EXPOSED format
+// accepted by MapPorts.
+func AllocatePorts(container string, ports []string) ([]string, error) {
+	reservations, usage, err := lockedPortUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]string)
+	for _, r := range reservations {
+		if r.Container == container {
+			previous[r.Exposed] = r.Port
+		}
+	}
+
+	assignments := []string{}
+	taken := make(map[string]bool)
+	for _, entry := range ports {
+		_, published, exposed := PortComponents(entry)
+		proto := protocol(exposed)
+
+		free := func(port string) bool {
+			return !taken[port+"/"+proto] && usage.conflict(reservations, container, port, proto) == ""
+		}
+
+		port := ""
+		if p, ok := previous[exposed]; ok && free(p) {
+			port = p
+		} else {
+			start, err := strconv.Atoi(published)
+			if err != nil {
+				return nil, fmt.Errorf("Cannot allocate a host port for %q: %v", entry, err)
+			}
+			for n := start; n <= 65535; n++ {
+				if free(strconv.Itoa(n)) {
+					port = strconv.Itoa(n)
+					break
+				}
+			}
+		}
+		if port == "" {
+			return nil, fmt.Errorf("No free host port left for the %s port of %s", exposed, container)
+		}
+
+		log.WithFields(log.Fields{
+			"=>":   container,
+			"port": exposed,
+		}).Debugf("Allocated host port %s", port)
+		taken[port+"/"+proto] = true
+		assignments = append(assignments, port+":"+exposed)
+	}
+	return assignments, nil
+}
+
+// portUsage describes host ports in use.
+type portUsage struct {
+	// Existing containers.
+	containers map[string]bool
+	// Host ports (in the PORT/PROTOCOL format) published
+	// by running containers and the container names.
+	published map[string]string
+	// True if the Docker daemon runs on this host.
+	local bool
+}
+
+func currentPortUsage() (*portUsage, error) {
+	usage := &portUsage{
+		containers: make(map[string]bool),
+		published:  make(map[string]string),
+		local:      dockerHostIP(DockerClient.Endpoint()) == "127.0.0.1",
+	}
+
+	containers, err := DockerClient.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return nil, DockerError(err)
+	}
+	for _, container := range containers {
+		if len(container.Names) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(container.Names[0], "/")
+		usage.containers[name] = true
+
+		for _, port := range container.Ports {
+			if port.PublicPort != 0 {
+				usage.published[fmt.Sprintf("%d/%s", port.PublicPort, port.Type)] = name
+			}
+		}
+	}
+	return usage, nil
+}
+
+// lockedPortUsage reads the port reservations and the current port
+// usage while holding the port reservations lock.
+func lockedPortUsage() ([]*Reservation, *portUsage, error) {
+	unlock, err := lockReservations()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	reservations, err := LoadReservations()
+	if err != nil {
+		return nil, nil, err
+	}
+	usage, err := currentPortUsage()
+	if err != nil {
+		return nil, nil, err
+	}
+	return reservations, usage, nil
+}
+
+// taken returns the reason the host port is reserved or published
+// by another existing container or an empty string if it is not.
+func (u *portUsage) taken(reservations []*Reservation, container, port, proto string) string {
+	for _, r := range reservations {
+		if r.Container != container && r.Port == port && r.Protocol() == proto && u.containers[r.Container] {
+			return fmt.Sprintf("reserved by the %s %s", r.Type, r.Name)
+		}
+	}
+	if owner, ok := u.published[port+"/"+proto]; ok && owner != container {
+		return fmt.Sprintf("published by the %s container", owner)
+	}
+	return ""
+}
+
+// conflict returns the reason the host port cannot be bound by the
+// container or an empty string if the port is free.
+func (u *portUsage) conflict(reservations []*Reservation, container, port, proto string) string {
+	if reason := u.taken(reservations, container, port, proto); reason != "" {
+		return reason
+	}
+	if u.local && proto == "tcp" {
+		listener, err := net.Listen("tcp", ":"+port)
+		if isAddrInUse(err) {
+			return "in use by another process"
+		}
+		if err == nil {
+			listener.Close()
+		}
+	}
+	return ""
+}
+
+// isAddrInUse returns true if the error is EADDRINUSE. Other errors
+// (e.g. EACCES for ports below 1024 when not running as root) say
+// nothing about the port being used by the Docker daemon's host and
+// are not reported as conflicts.
+func isAddrInUse(err error) bool {
+	opErr, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	if syscallErr, ok := opErr.Err.(*os.SyscallError); ok {
+		return syscallErr.Err == syscall.EADDRINUSE
+	}
+	return opErr.Err == syscall.EADDRINUSE
+}
+
+// protocol returns the protocol of the exposed port ("tcp" by default).
+func protocol(exposed string) string {
+	if parts := strings.SplitN(exposed, "/", 2); len(parts) == 2 && parts[1] != "" {
+		return parts[1]
+	}
+	return "tcp"
+}
+
+func sortedPorts(bindings map[docker.Port][]docker.PortBinding) []docker.Port {
+	names := []string{}
+	for port := range bindings {
+		names = append(names, string(port))
+	}
+	sort.Strings(names)
+
+	ports := []docker.Port{}
+	for _, name := range names {
+		ports = append(ports, docker.Port(name))
+	}
+	return ports
+}
+
+type byPort []*Reservation
+
+func (b byPort) Len() int      { return len(b) }
+func (b byPort) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byPort) Less(i, j int) bool {
+	n, _ := strconv.Atoi(b[i].Port)
+	m, _ := strconv.Atoi(b[j].Port)
+	if n != m {
+		return n < m
+	}
+	return b[i].Protocol() < b[j].Protocol()
+}
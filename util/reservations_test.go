@@ -0,0 +1,205 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/eris-ltd/eris-cli/config"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestProtocol(t *testing.T) {
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"46657/tcp", "tcp"},
+		{"53/udp", "udp"},
+		{"46657", "tcp"},
+		{"46657/", "tcp"},
+	} {
+		if out := protocol(test.in); out != test.out {
+			t.Fatalf("expected %q for %q, got %q", test.out, test.in, out)
+		}
+	}
+}
+
+// fakeContainers points DockerClient to a Docker API server listing
+// the named containers. The returned function restores the client.
+func fakeContainers(t *testing.T, names ...string) func() {
+	containers := []docker.APIContainers{}
+	for _, name := range names {
+		containers = append(containers, docker.APIContainers{Names: []string{"/" + name}})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(containers)
+	}))
+
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("expected a client, got %v", err)
+	}
+	saved := DockerClient
+	DockerClient = client
+	return func() {
+		DockerClient = saved
+		server.Close()
+	}
+}
+
+func TestReservePorts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-ports")
+	if err != nil {
+		t.Fatalf("expected a temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saved := config.PortsFile
+	config.PortsFile = filepath.Join(dir, "ports.json")
+	defer func() { config.PortsFile = saved }()
+
+	restore := fakeContainers(t, "eris_chain_a", "eris_chain_b")
+	if err := ReservePorts("eris_chain_a", "chain", "a", map[docker.Port][]docker.PortBinding{
+		"46656/tcp": {{HostPort: "46656"}},
+		"46657/tcp": {{HostPort: "46657"}},
+	}); err != nil {
+		t.Fatalf("expected ports to be reserved, got %v", err)
+	}
+
+	// The chain a still exists.
+	if err := ReservePorts("eris_chain_b", "chain", "b", map[docker.Port][]docker.PortBinding{
+		"46657/tcp": {{HostPort: "46657"}},
+	}); err == nil {
+		t.Fatalf("expected the port reserved by an existing container not to be reserved again")
+	}
+	restore()
+
+	// The chain a is removed, the chain b takes over the 46657 port.
+	defer fakeContainers(t, "eris_chain_b")()
+	if err := ReservePorts("eris_chain_b", "chain", "b", map[docker.Port][]docker.PortBinding{
+		"46657/tcp": {{HostPort: "46657"}},
+		"53/udp":    {{HostPort: "46657"}},
+	}); err != nil {
+		t.Fatalf("expected ports to be reserved, got %v", err)
+	}
+
+	reservations, err := LoadReservations()
+	if err != nil {
+		t.Fatalf("expected reservations to load, got %v", err)
+	}
+	if len(reservations) != 3 {
+		t.Fatalf("expected 3 reservations, got %d", len(reservations))
+	}
+	for i, expected := range []struct {
+		port, protocol, container string
+	}{
+		{"46656", "tcp", "eris_chain_a"},
+		{"46657", "tcp", "eris_chain_b"},
+		{"46657", "udp", "eris_chain_b"},
+	} {
+		r := reservations[i]
+		if r.Port != expected.port || r.Protocol() != expected.protocol || r.Container != expected.container {
+			t.Fatalf("expected %v, got %s/%s by %s", expected, r.Port, r.Protocol(), r.Container)
+		}
+	}
+}
+
+func TestPortConflict(t *testing.T) {
+	reservations := []*Reservation{
+		{Port: "46657", Exposed: "46657/tcp", Container: "eris_chain_a", Type: "chain", Name: "a"},
+		{Port: "46656", Exposed: "46656/tcp", Container: "eris_chain_gone", Type: "chain", Name: "gone"},
+	}
+	usage := &portUsage{
+		containers: map[string]bool{"eris_chain_a": true, "eris_service_ipfs": true},
+		published:  map[string]string{"8080/tcp": "eris_service_ipfs"},
+	}
+
+	for _, test := range []struct {
+		container, port, proto string
+		conflict               bool
+	}{
+		{"eris_chain_b", "46657", "tcp", true},
+		{"eris_chain_a", "46657", "tcp", false},
+		{"eris_chain_b", "46657", "udp", false},
+		{"eris_chain_b", "46656", "tcp", false},
+		{"eris_chain_b", "8080", "tcp", true},
+		{"eris_service_ipfs", "8080", "tcp", false},
+	} {
+		if reason := usage.conflict(reservations, test.container, test.port, test.proto); (reason != "") != test.conflict {
+			t.Fatalf("expected conflict=%v for %s/%s of %s, got %q", test.conflict, test.port, test.proto, test.container, reason)
+		}
+	}
+}
+
+func TestReservePortsLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-ports")
+	if err != nil {
+		t.Fatalf("expected a temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	saved, savedWait := config.PortsFile, reservationsLockWait
+	config.PortsFile = filepath.Join(dir, "ports.json")
+	reservationsLockWait = 100 * time.Millisecond
+	defer func() { config.PortsFile, reservationsLockWait = saved, savedWait }()
+
+	bindings := map[docker.Port][]docker.PortBinding{"46657/tcp": {{HostPort: "46657"}}}
+	defer fakeContainers(t)()
+
+	// Another eris process holds the lock.
+	if err := ioutil.WriteFile(config.PortsFile+".lock", []byte("1\n"), 0644); err != nil {
+		t.Fatalf("expected a lock file, got %v", err)
+	}
+	if err := ReservePorts("eris_chain_a", "chain", "a", bindings); err == nil {
+		t.Fatalf("expected ReservePorts to fail while the lock is held")
+	}
+
+	// The lock left behind by a killed process.
+	stale := time.Now().Add(-2 * reservationsLockStale)
+	if err := os.Chtimes(config.PortsFile+".lock", stale, stale); err != nil {
+		t.Fatalf("expected lock file times to change, got %v", err)
+	}
+	if err := ReservePorts("eris_chain_a", "chain", "a", bindings); err != nil {
+		t.Fatalf("expected the stale lock to be removed, got %v", err)
+	}
+	if _, err := os.Stat(config.PortsFile + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock to be released, got %v", err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected to read the dir, got %v", err)
+	}
+	if len(files) != 1 || files[0].Name() != "ports.json" {
+		t.Fatalf("expected only the reservations file to be left, got %v", files)
+	}
+}
+
+func TestIsAddrInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected to listen, got %v", err)
+	}
+	defer listener.Close()
+
+	_, err = net.Listen("tcp", listener.Addr().String())
+	if !isAddrInUse(err) {
+		t.Fatalf("expected the address to be in use, got %v", err)
+	}
+
+	denied := &net.OpError{Op: "listen", Net: "tcp", Err: os.NewSyscallError("bind", syscall.EACCES)}
+	if isAddrInUse(denied) {
+		t.Fatalf("expected a permission error not to be a conflict")
+	}
+	if isAddrInUse(nil) {
+		t.Fatalf("expected no error not to be a conflict")
+	}
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestRipemd160(t *testing.T) {
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"", "9c1185a5c5e9fc54612808977ee8f548b2258d31"},
+		{"abc", "8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"},
+		{"message digest", "5d0689ef49d2fae572b881b123a85ffa21595f36"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "12a053384a9c0c88e405a06c27dcf49ada62eb2b"},
+		{strings.Repeat("1234567890", 8), "9b752e45573d4b39f4dbd3323cab82bf63326bfb"},
+	} {
+		if out := hex.EncodeToString(Ripemd160([]byte(test.in))); out != test.out {
+			t.Fatalf("expected %s for %q, got %s", test.out, test.in, out)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package util
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/docker/go-units"
 	"github.com/fsouza/go-dockerclient/external/github.com/docker/docker/pkg/archive"
 )
 
@@ -46,18 +52,234 @@ func PackTarball(pathToTar, nameOfTar string) (string, error) {
 	return fileName, nil
 }
 
-// give a tarballs' path
-// and the target installation directory
-// for the ball in question
+// Limits enforced by UnpackTarball.
+var (
+	// Maximum total size of the unpacked files in bytes.
+	MaxUnpackSize int64 = 256 * 1024 * 1024
+	// Maximum number of archive entries.
+	MaxUnpackEntries = 10000
+)
+
+// Archive types returned by ArchiveType.
+const (
+	ArchiveGzip  = "gzip"
+	ArchiveBzip2 = "bzip2"
+	ArchiveTar   = "tar"
+)
+
+// ArchiveType detects the archive type from the first bytes of its
+// content (at least 512 bytes are needed to recognize uncompressed tar
+// archives). It returns an empty string if the content is not a
+// (possibly compressed) tar archive.
+func ArchiveType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return ArchiveGzip
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return ArchiveBzip2
+	case len(header) >= 262 && bytes.HasPrefix(header[257:], []byte("ustar")):
+		return ArchiveTar
+	}
+	return ""
+}
+
+// UnpackTarball extracts a tar archive (compressed with gzip, bzip2, or
+// uncompressed; the type is detected from the content) into the
+// installPath directory. Entries leading outside installPath (absolute
+// paths, ".." elements, links pointing outside, or paths through links),
+// special files, and archives exceeding MaxUnpackSize or MaxUnpackEntries
+// are refused. Permission bits other than the executable ones are not
+// preserved.
 func UnpackTarball(tarBallPath, installPath string) error {
-	// open tarball for reading
-	reader, err := os.Open(tarBallPath)
-	defer reader.Close()
+	file, err := os.Open(tarBallPath)
 	if err != nil {
-		return fmt.Errorf("error opening %s: %v\n", tarBallPath, err)
+		return fmt.Errorf("Cannot open %s: %v", tarBallPath, err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReaderSize(file, 512)
+	header, _ := buffered.Peek(512)
+
+	var reader io.Reader
+	switch ArchiveType(header) {
+	case ArchiveGzip:
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("Cannot read %s: %v", tarBallPath, err)
+		}
+		defer gz.Close()
+		reader = gz
+	case ArchiveBzip2:
+		reader = bzip2.NewReader(buffered)
+	case ArchiveTar:
+		reader = buffered
+	default:
+		return fmt.Errorf("File %s is not a tar archive", tarBallPath)
+	}
+
+	if err := os.MkdirAll(installPath, 0755); err != nil {
+		return err
+	}
+	root, err := filepath.Abs(installPath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		size    int64
+		entries int
+	)
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Cannot read %s: %v", tarBallPath, err)
+		}
+
+		if entries++; entries > MaxUnpackEntries {
+			return fmt.Errorf("Archive %s has more than %d entries", tarBallPath, MaxUnpackEntries)
+		}
+		if size += header.Size; size > MaxUnpackSize {
+			return fmt.Errorf("Archive %s unpacks to more than %s", tarBallPath, units.BytesSize(float64(MaxUnpackSize)))
+		}
+
+		if err := unpackEntry(archive, header, root); err != nil {
+			return fmt.Errorf("Cannot unpack %s: %v", tarBallPath, err)
+		}
+	}
+}
+
+func unpackEntry(archive *tar.Reader, header *tar.Header, root string) error {
+	target, err := unpackPath(root, header.Name)
+	if err != nil || target == root {
+		return err
 	}
+	if err := checkParents(root, target); err != nil {
+		return err
+	}
+
+	// Never write through whatever is already there.
+	if info, err := os.Lstat(target); err == nil && (!info.IsDir() || header.Typeflag != tar.TypeDir) {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+
+	mode := os.FileMode(0644)
+	if header.Mode&0111 != 0 {
+		mode = 0755
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0755)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, io.LimitReader(archive, header.Size))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	case tar.TypeSymlink:
+		if !linkInside(root, filepath.Dir(target), header.Linkname) {
+			return fmt.Errorf("%s links outside of the install directory", header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeLink:
+		source, err := unpackPath(root, header.Linkname)
+		if err != nil {
+			return err
+		}
+		if err := checkParents(root, source); err != nil {
+			return err
+		}
+		if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+			return fmt.Errorf("%s links to a missing or special file %s", header.Name, header.Linkname)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.Link(source, target)
+	case tar.TypeXGlobalHeader:
+		return nil
+	}
+	return fmt.Errorf("%s has an unsupported type (%q)", header.Name, header.Typeflag)
+}
+
+// unpackPath returns the absolute path the archive entry unpacks to
+// or an error if it is outside of root.
+func unpackPath(root, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("%s is an absolute path", name)
+	}
+	target := filepath.Join(root, filepath.FromSlash(name))
+	if !insideDir(root, target) {
+		return "", fmt.Errorf("%s points outside of the install directory", name)
+	}
+	return target, nil
+}
+
+// checkParents returns an error if any of the path elements
+// between root and target is not a directory.
+func checkParents(root, target string) error {
+	for dir := filepath.Dir(target); dir != root && insideDir(root, dir); dir = filepath.Dir(dir) {
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s goes through a link or file", target)
+		}
+	}
+	return nil
+}
+
+// linkInside returns true if the symbolic link in the dir directory
+// pointing to link resolves inside root. Absolute links and links going
+// through other links are considered to be outside.
+func linkInside(root, dir, link string) bool {
+	if filepath.IsAbs(link) || strings.HasPrefix(link, "/") {
+		return false
+	}
+
+	current := dir
+	for _, element := range strings.Split(filepath.ToSlash(link), "/") {
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, element)
+			if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return false
+			}
+		}
+		if !insideDir(root, current) {
+			return false
+		}
+	}
+	return true
+}
 
-	return UntarForDocker(reader, "", installPath)
+func insideDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
 func GetFromGithub(org, repo, branch, p, directory, fileName string) error {
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name, content, link string
+	typ                 byte
+}
+
+func writeTarball(t *testing.T, dir string, entries []tarEntry) string {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Linkname: entry.link,
+			Typeflag: entry.typ,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+		if entry.typ == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("expected to write header, got %v", err)
+		}
+		if header.Size > 0 {
+			tw.Write([]byte(entry.content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+
+	file := filepath.Join(dir, "bundle.tar.gz")
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("expected to write tarball, got %v", err)
+	}
+	return file
+}
+
+func TestArchiveType(t *testing.T) {
+	header := make([]byte, 512)
+	copy(header[257:], "ustar")
+
+	for _, test := range []struct {
+		header   []byte
+		expected string
+	}{
+		{[]byte{0x1f, 0x8b, 0x08}, ArchiveGzip},
+		{[]byte("BZh91AY"), ArchiveBzip2},
+		{header, ArchiveTar},
+		{[]byte("QmdbzmNH1iDg2H86Uk3USuJ2vaugvwU7HubvCxMC2fUykm"), ""},
+		{nil, ""},
+	} {
+		if archive := ArchiveType(test.header); archive != test.expected {
+			t.Fatalf("expected %q, got %q", test.expected, archive)
+		}
+	}
+}
+
+func TestUnpackTarball(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-tar-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tarball := writeTarball(t, dir, []tarEntry{
+		{name: "./", typ: tar.TypeDir},
+		{name: "contracts/", typ: tar.TypeDir},
+		{name: "contracts/idi.sol", content: "contract IdisContractsFTW {}"},
+		{name: "epm.yaml", content: "jobs:"},
+		{name: "current", link: "contracts/idi.sol", typ: tar.TypeSymlink},
+	})
+
+	install := filepath.Join(dir, "install")
+	if err := UnpackTarball(tarball, install); err != nil {
+		t.Fatalf("expected to unpack, got %v", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(install, "current"))
+	if err != nil || string(content) != "contract IdisContractsFTW {}" {
+		t.Fatalf("expected unpacked files, got %q, %v", content, err)
+	}
+}
+
+func TestUnpackTarballRefused(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"dot dot", []tarEntry{{name: "../escape", content: "x"}}},
+		{"nested dot dot", []tarEntry{{name: "a/../../escape", content: "x"}}},
+		{"absolute", []tarEntry{{name: "/tmp/escape", content: "x"}}},
+		{"symlink outside", []tarEntry{{name: "link", link: "../..", typ: tar.TypeSymlink}}},
+		{"absolute symlink", []tarEntry{{name: "link", link: "/etc/passwd", typ: tar.TypeSymlink}}},
+		{"through symlink", []tarEntry{
+			{name: "link", link: ".", typ: tar.TypeSymlink},
+			{name: "link/file", content: "x"},
+		}},
+		{"symlink through symlink", []tarEntry{
+			{name: "sub/", typ: tar.TypeDir},
+			{name: "sub/up", link: "..", typ: tar.TypeSymlink},
+			{name: "link", link: "sub/up/../..", typ: tar.TypeSymlink},
+		}},
+		{"hard link outside", []tarEntry{{name: "link", link: "../escape", typ: tar.TypeLink}}},
+		{"device", []tarEntry{{name: "null", typ: tar.TypeChar}}},
+	} {
+		dir, err := ioutil.TempDir("", "eris-tar-")
+		if err != nil {
+			t.Fatalf("expected temp dir, got %v", err)
+		}
+
+		tarball := writeTarball(t, dir, test.entries)
+		if err := UnpackTarball(tarball, filepath.Join(dir, "a", "install")); err == nil {
+			os.RemoveAll(dir)
+			t.Fatalf("%s: expected unpack to fail", test.name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "a", "escape")); err == nil {
+			os.RemoveAll(dir)
+			t.Fatalf("%s: expected no file outside the install directory", test.name)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestUnpackTarballLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-tar-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(size int64, entries int) {
+		MaxUnpackSize, MaxUnpackEntries = size, entries
+	}(MaxUnpackSize, MaxUnpackEntries)
+
+	tarball := writeTarball(t, dir, []tarEntry{
+		{name: "a", content: strings.Repeat("a", 100)},
+		{name: "b", content: strings.Repeat("b", 100)},
+	})
+
+	MaxUnpackSize, MaxUnpackEntries = 150, 10
+	if err := UnpackTarball(tarball, filepath.Join(dir, "size")); err == nil {
+		t.Fatalf("expected size limit to be enforced")
+	}
+
+	MaxUnpackSize, MaxUnpackEntries = 1000, 1
+	if err := UnpackTarball(tarball, filepath.Join(dir, "entries")); err == nil {
+		t.Fatalf("expected entry count limit to be enforced")
+	}
+}
+
+func TestUnpackTarballNotArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eris-tar-")
+	if err != nil {
+		t.Fatalf("expected temp dir, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "bundle.tar.gz")
+	ioutil.WriteFile(file, []byte("QmdbzmNH1iDg2H86Uk3USuJ2vaugvwU7HubvCxMC2fUykm"), 0644)
+	if err := UnpackTarball(file, filepath.Join(dir, "install")); err == nil {
+		t.Fatalf("expected unpack to fail")
+	}
+}